@@ -0,0 +1,304 @@
+package bike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigApp string = "bike app"
+
+type appOpts struct {
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+	signals      []os.Signal
+}
+
+type AppOption func(options *appOpts)
+
+// WithStartTimeout limita cuánto se espera a que cada componente esté listo. Por
+// defecto 30 segundos. Panics si d no es positivo.
+func WithStartTimeout(d time.Duration) AppOption {
+	return func(options *appOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: start timeout must be positive", SigApp))
+		}
+		options.startTimeout = d
+	}
+}
+
+// WithStopTimeout limita cuánto se espera a que cada componente se detenga.
+// Panics si d no es positivo.
+func WithStopTimeout(d time.Duration) AppOption {
+	return func(options *appOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: stop timeout must be positive", SigApp))
+		}
+		options.stopTimeout = d
+	}
+}
+
+// WithSignals reemplaza las señales que detienen la aplicación, por defecto
+// SIGINT y SIGTERM. Panics si no se entrega ninguna.
+func WithSignals(signals ...os.Signal) AppOption {
+	return func(options *appOpts) {
+		if len(signals) == 0 {
+			panic(fmt.Sprintf("%s: at least one signal is required", SigApp))
+		}
+		options.signals = signals
+	}
+}
+
+// readier lo implementan los componentes cuyo Start bloquea, para avisar que ya
+// están atendiendo. Sin Ready el App espera a que Start retorne, así que un Start
+// que bloquea sin Ready falla al vencer el start timeout.
+type readier interface {
+	Ready() <-chan struct{}
+}
+
+type component struct {
+	name      string
+	lifecycle interfaces.Lifecycle
+	deps      []string
+}
+
+// running es un componente iniciado. err es válido después de cerrar done.
+type running struct {
+	component
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// App compone los adaptadores de una aplicación: los inicia según sus
+// dependencias, espera una señal o un error fatal y los detiene en orden inverso.
+type App struct {
+	stderr  io.Writer
+	options appOpts
+
+	mu         sync.Mutex
+	components []component
+	started    bool
+	ready      chan struct{}
+}
+
+func NewApp(stderr io.Writer, opts ...AppOption) *App {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigApp))
+	}
+
+	a := &App{
+		stderr: stderr,
+		options: appOpts{
+			startTimeout: 30 * time.Second,
+			stopTimeout:  10 * time.Second,
+			signals:      []os.Signal{os.Interrupt, syscall.SIGTERM},
+		},
+		ready: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&a.options)
+	}
+	return a
+}
+
+// Add registra un componente que se inicia después de los componentes dependsOn.
+// Panics si name está vacío o repetido, si c es nil o si el App ya está corriendo.
+func (a *App) Add(name string, c interfaces.Lifecycle, dependsOn ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if name == "" || c == nil {
+		panic(fmt.Sprintf("%s: component needs a name and a lifecycle", SigApp))
+	}
+	if a.started {
+		panic(fmt.Sprintf("%s: cannot add component %s after Run", SigApp, name))
+	}
+	for _, existing := range a.components {
+		if existing.name == name {
+			panic(fmt.Sprintf("%s: component %s already added", SigApp, name))
+		}
+	}
+
+	a.components = append(a.components, component{name: name, lifecycle: c, deps: dependsOn})
+}
+
+// Ready se cierra cuando todos los componentes están iniciados.
+func (a *App) Ready() <-chan struct{} {
+	return a.ready
+}
+
+// Run inicia los componentes y bloquea hasta que ctx se cancele, llegue una de
+// las señales configuradas o un componente falle. Luego los detiene en orden
+// inverso. Retorna el primer error fatal o, si no hubo, los errores al detener.
+func (a *App) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.started {
+		a.mu.Unlock()
+		return fmt.Errorf("%s: already running", SigApp)
+	}
+	a.started = true
+	components := a.components
+	a.mu.Unlock()
+
+	order, err := sortComponents(components)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, a.options.signals...)
+	defer stop()
+
+	failed := make(chan error, len(order))
+	var started []*running
+	fatal := func() error {
+		for _, c := range order {
+			r, err := a.start(ctx, c, failed)
+			if r != nil {
+				started = append(started, r)
+			}
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+
+		fmt.Fprintf(a.stderr, "%s: started %d components", SigApp, len(started))
+		close(a.ready)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-failed:
+			return err
+		}
+	}()
+
+	fmt.Fprintf(a.stderr, "%s: shutting down", SigApp)
+	var stopErrs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		if err := a.stop(started[i]); err != nil {
+			fmt.Fprintf(a.stderr, "%s: %v", SigApp, err)
+			stopErrs = append(stopErrs, err)
+		}
+	}
+
+	if fatal != nil {
+		return fatal
+	}
+	return errors.Join(stopErrs...)
+}
+
+// start lanza Start en su propia goroutine y espera a que el componente esté listo.
+// El contexto del componente no hereda la cancelación de ctx, para que cada uno
+// se detenga recién cuando le toca en el orden de shutdown.
+func (a *App) start(ctx context.Context, c component, failed chan<- error) (*running, error) {
+	fmt.Fprintf(a.stderr, "%s: starting %s", SigApp, c.name)
+
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r := &running{component: c, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		if err := c.lifecycle.Start(cctx); err != nil {
+			r.err = fmt.Errorf("%s: component %s failed: %w", SigApp, c.name, err)
+			failed <- r.err
+		}
+	}()
+
+	var ready <-chan struct{}
+	if rd, ok := c.lifecycle.(readier); ok {
+		ready = rd.Ready()
+	}
+	timeout := time.NewTimer(a.options.startTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-ready:
+		return r, nil
+	case <-r.done:
+		return r, r.err
+	case <-ctx.Done():
+		return r, nil
+	case <-timeout.C:
+		return r, fmt.Errorf("%s: component %s not ready after %s", SigApp, c.name, a.options.startTimeout)
+	}
+}
+
+// stop llama a Stop, cancela el contexto del componente y espera a que Start
+// retorne, todo dentro del stop timeout.
+func (a *App) stop(r *running) error {
+	fmt.Fprintf(a.stderr, "%s: stopping %s", SigApp, r.name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.options.stopTimeout)
+	defer cancel()
+
+	err := r.lifecycle.Stop(ctx)
+	r.cancel()
+	if err != nil {
+		return fmt.Errorf("failed to stop %s: %w", r.name, err)
+	}
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("component %s did not stop in %s", r.name, a.options.stopTimeout)
+	}
+}
+
+// sortComponents ordena los componentes para que cada uno parta después de sus
+// dependencias, respetando el orden de registro cuando no hay restricciones.
+func sortComponents(components []component) ([]component, error) {
+	byName := make(map[string]component, len(components))
+	for _, c := range components {
+		byName[c.name] = c
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(components))
+	order := make([]component, 0, len(components))
+
+	var visit func(c component, path []string) error
+	visit = func(c component, path []string) error {
+		switch state[c.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%s: dependency cycle %v", SigApp, append(path, c.name))
+		}
+
+		state[c.name] = visiting
+		for _, dep := range c.deps {
+			d, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("%s: component %s depends on unknown component %s", SigApp, c.name, dep)
+			}
+			if err := visit(d, append(path, c.name)); err != nil {
+				return err
+			}
+		}
+		state[c.name] = visited
+		order = append(order, c)
+		return nil
+	}
+
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
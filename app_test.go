@@ -0,0 +1,290 @@
+package bike
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/driving/httpserver"
+	"github.com/go-on-bike/bike/interfaces"
+	"github.com/go-on-bike/bike/logformatter"
+)
+
+// events registra el orden en que los componentes parten y se detienen.
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+func (e *events) String() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return strings.Join(e.list, ",")
+}
+
+// fakeComponent parte de inmediato, salvo que block sea true: entonces su Start
+// bloquea hasta que se cancele su contexto, como un servidor.
+type fakeComponent struct {
+	name     string
+	events   *events
+	block    bool
+	startErr error
+	stopErr  error
+	stopWait time.Duration
+	ready    chan struct{}
+	fail     chan error
+}
+
+func newFake(name string, ev *events) *fakeComponent {
+	return &fakeComponent{name: name, events: ev, ready: make(chan struct{}), fail: make(chan error, 1)}
+}
+
+func (f *fakeComponent) Start(ctx context.Context) error {
+	f.events.add("start " + f.name)
+	if f.startErr != nil {
+		return f.startErr
+	}
+	if !f.block {
+		return nil
+	}
+
+	close(f.ready)
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-f.fail:
+		return err
+	}
+}
+
+func (f *fakeComponent) Stop(ctx context.Context) error {
+	f.events.add("stop " + f.name)
+	if f.stopWait > 0 {
+		select {
+		case <-time.After(f.stopWait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return f.stopErr
+}
+
+// blockingComponent expone Ready para que el App no espere a que Start retorne.
+type blockingComponent struct{ *fakeComponent }
+
+func (b blockingComponent) Ready() <-chan struct{} { return b.ready }
+
+func newBlocking(name string, ev *events) blockingComponent {
+	f := newFake(name, ev)
+	f.block = true
+	return blockingComponent{f}
+}
+
+func runApp(t *testing.T, app *App) (context.CancelFunc, <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- app.Run(ctx) }()
+	t.Cleanup(cancel)
+	return cancel, result
+}
+
+func waitResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("app did not stop")
+		return nil
+	}
+}
+
+func TestAppOrder(t *testing.T) {
+	ev := &events{}
+	app := NewApp(&strings.Builder{})
+	app.Add("http", newBlocking("http", ev), "db", "logs")
+	app.Add("db", newFake("db", ev), "logs")
+	app.Add("logs", newBlocking("logs", ev))
+
+	cancel, result := runApp(t, app)
+	<-app.Ready()
+	cancel()
+
+	if err := waitResult(t, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "start logs,start db,start http,stop http,stop db,stop logs"
+	if got := ev.String(); got != want {
+		t.Fatalf("expected events %q, got %q", want, got)
+	}
+}
+
+func TestAppFailures(t *testing.T) {
+	t.Run("fatal error stops every component", func(t *testing.T) {
+		ev := &events{}
+		db := newFake("db", ev)
+		worker := newBlocking("worker", ev)
+
+		app := NewApp(&strings.Builder{})
+		app.Add("db", db)
+		app.Add("worker", worker, "db")
+
+		_, result := runApp(t, app)
+		<-app.Ready()
+
+		errBoom := errors.New("boom")
+		worker.fail <- errBoom
+
+		if err := waitResult(t, result); !errors.Is(err, errBoom) {
+			t.Fatalf("expected boom error, got %v", err)
+		}
+		if got := ev.String(); got != "start db,start worker,stop worker,stop db" {
+			t.Fatalf("unexpected events %q", got)
+		}
+	})
+
+	t.Run("start error skips later components", func(t *testing.T) {
+		ev := &events{}
+		db := newFake("db", ev)
+		db.startErr = errors.New("no database")
+
+		app := NewApp(&strings.Builder{})
+		app.Add("logs", newBlocking("logs", ev))
+		app.Add("db", db, "logs")
+		app.Add("http", newBlocking("http", ev), "db")
+
+		_, result := runApp(t, app)
+		if err := waitResult(t, result); err == nil || !strings.Contains(err.Error(), "no database") {
+			t.Fatalf("expected start error, got %v", err)
+		}
+		if got := ev.String(); got != "start logs,start db,stop db,stop logs" {
+			t.Fatalf("unexpected events %q", got)
+		}
+	})
+
+	t.Run("stop errors and timeouts are reported", func(t *testing.T) {
+		ev := &events{}
+		slow := newFake("slow", ev)
+		slow.stopWait = time.Second
+		broken := newFake("broken", ev)
+		broken.stopErr = errors.New("cannot close")
+
+		app := NewApp(&strings.Builder{}, WithStopTimeout(20*time.Millisecond))
+		app.Add("slow", slow)
+		app.Add("broken", broken)
+
+		cancel, result := runApp(t, app)
+		<-app.Ready()
+		cancel()
+
+		err := waitResult(t, result)
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "cannot close") {
+			t.Fatalf("expected both stop errors, got %v", err)
+		}
+	})
+
+	t.Run("start timeout", func(t *testing.T) {
+		if NewApp(&strings.Builder{}).options.startTimeout <= 0 {
+			t.Fatal("expected a default start timeout so a blocking Start cannot hang Run")
+		}
+
+		ev := &events{}
+		stuck := newFake("stuck", ev)
+		stuck.block = true
+
+		app := NewApp(&strings.Builder{}, WithStartTimeout(20*time.Millisecond))
+		app.Add("stuck", stuck)
+
+		_, result := runApp(t, app)
+		if err := waitResult(t, result); err == nil || !strings.Contains(err.Error(), "not ready") {
+			t.Fatalf("expected start timeout, got %v", err)
+		}
+	})
+
+	t.Run("invalid dependencies", func(t *testing.T) {
+		ev := &events{}
+
+		unknown := NewApp(&strings.Builder{})
+		unknown.Add("http", newFake("http", ev), "db")
+		if err := unknown.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown component db") {
+			t.Fatalf("expected unknown dependency error, got %v", err)
+		}
+
+		cycle := NewApp(&strings.Builder{})
+		cycle.Add("a", newFake("a", ev), "b")
+		cycle.Add("b", newFake("b", ev), "a")
+		if err := cycle.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "cycle") {
+			t.Fatalf("expected cycle error, got %v", err)
+		}
+
+		if ev.String() != "" {
+			t.Fatalf("expected no component to start, got %q", ev.String())
+		}
+	})
+
+	t.Run("invalid registrations panic", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected panic with duplicate component")
+			}
+		}()
+		app := NewApp(&strings.Builder{})
+		app.Add("db", newFake("db", &events{}))
+		app.Add("db", newFake("db", &events{}))
+	})
+}
+
+func TestAppSignals(t *testing.T) {
+	ev := &events{}
+	app := NewApp(&strings.Builder{}, WithSignals(syscall.SIGUSR1))
+	app.Add("http", newBlocking("http", ev))
+
+	_, result := runApp(t, app)
+	<-app.Ready()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("failed to send signal: %v", err)
+	}
+	if err := waitResult(t, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ev.String(); got != "start http,stop http" {
+		t.Fatalf("unexpected events %q", got)
+	}
+}
+
+func TestAppAdapters(t *testing.T) {
+	formatter, _ := logformatter.NewLogFormatter(&strings.Builder{}, nil, true, 0)
+	server := httpserver.NewServer(formatter, httpserver.WithAddr("127.0.0.1:0"))
+
+	var _ interfaces.Lifecycle = formatter
+	var _ interfaces.Lifecycle = server
+
+	app := NewApp(&strings.Builder{})
+	app.Add("logs", formatter)
+	app.Add("http", server, "logs")
+
+	cancel, result := runApp(t, app)
+	<-app.Ready()
+	if server.Addr() == "" {
+		t.Fatal("expected server to be listening once the app is ready")
+	}
+	cancel()
+
+	if err := waitResult(t, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
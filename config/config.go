@@ -0,0 +1,300 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const SigConfig string = "config"
+
+// Loader carga una estructura T desde capas de configuración. De menor a mayor
+// prioridad: tags default, archivos en el orden agregado, variables de entorno y
+// flags. Los campos se describen con tags:
+//
+//	type Config struct {
+//		Addr  string        `default:":8080" env:"ADDR" validate:"required"`
+//		Admin string        `config:"admin_email" validate:"email,max=64"`
+//		DB    struct {
+//			URL     string        `validate:"required"`
+//			Timeout time.Duration `default:"5s" flag:"db-timeout" usage:"query timeout"`
+//		}
+//	}
+//
+// config cambia la clave en archivos (por defecto el nombre en snake_case), env
+// fija el nombre de la variable de entorno, flag el nombre del flag y validate
+// las reglas que se aplican después de cargar todas las capas.
+type Loader[T any] struct {
+	stderr  io.Writer
+	options loaderOpts
+	fields  []field
+
+	flagOnce   sync.Once
+	flagValues map[string]string
+	flagErr    error
+
+	mu      sync.RWMutex
+	current T
+	loaded  bool
+	stamps  map[string]stamp
+	subs    []func(T)
+
+	started  atomic.Bool
+	ready    chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewLoader crea un loader para T. Panics si T no es una estructura o si sus tags
+// son inválidos, ya que esto representa un error de programación.
+func NewLoader[T any](stderr io.Writer, opts ...LoaderOption) *Loader[T] {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigConfig))
+	}
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%s: config type must be a struct, got %s", SigConfig, t))
+	}
+
+	l := &Loader[T]{
+		stderr:  stderr,
+		options: loaderOpts{pollInterval: time.Second},
+		fields:  fieldsOf(t, nil, nil),
+		ready:   make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&l.options)
+	}
+
+	if l.options.flags != nil {
+		l.registerFlags()
+	}
+	return l
+}
+
+// Load lee todas las capas, valida el resultado y lo guarda como la configuración
+// actual. Si hay errores de conversión o validación los retorna todos juntos en
+// un *ValidationError y la configuración actual no cambia.
+func (l *Loader[T]) Load() (T, error) {
+	cfg, stamps, err := l.load()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	l.mu.Lock()
+	l.current = cfg
+	l.loaded = true
+	l.stamps = stamps
+	l.mu.Unlock()
+
+	return cfg, nil
+}
+
+// Current retorna la última configuración cargada con éxito.
+func (l *Loader[T]) Current() T {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current
+}
+
+// Subscribe registra fn para recibir cada configuración nueva que Start recargue.
+func (l *Loader[T]) Subscribe(fn func(cfg T)) {
+	if fn == nil {
+		panic(fmt.Sprintf("%s: subscriber cannot be nil", SigConfig))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subs = append(l.subs, fn)
+}
+
+func (l *Loader[T]) load() (T, map[string]stamp, error) {
+	var cfg T
+	root := reflect.ValueOf(&cfg).Elem()
+	var errs []FieldError
+
+	set := func(f field, raw string, source string) {
+		if err := setValue(root.FieldByIndex(f.index), raw); err != nil {
+			errs = append(errs, FieldError{Field: f.key(), Rule: "parse", Reason: fmt.Sprintf("invalid value %q from %s: %v", raw, source, err)})
+		}
+	}
+
+	for _, f := range l.fields {
+		if f.def != nil {
+			set(f, *f.def, "default")
+		}
+	}
+
+	stamps := map[string]stamp{}
+	for _, file := range l.options.files {
+		st, tree, err := readFile(file)
+		stamps[file.path] = st
+		if err != nil {
+			return cfg, nil, fmt.Errorf("%s: %w", SigConfig, err)
+		}
+		errs = append(errs, l.applyTree(root, tree, file.path)...)
+	}
+
+	for _, f := range l.fields {
+		if name := l.envName(f); name != "" {
+			if raw, ok := os.LookupEnv(name); ok {
+				set(f, raw, "env "+name)
+			}
+		}
+	}
+
+	if l.options.flags != nil {
+		l.flagOnce.Do(func() {
+			l.flagErr = l.options.flags.Parse(l.options.args)
+		})
+		if l.flagErr != nil {
+			return cfg, nil, fmt.Errorf("%s: failed to parse flags: %w", SigConfig, l.flagErr)
+		}
+		for _, f := range l.fields {
+			if raw, ok := l.flagValues[f.key()]; ok {
+				set(f, raw, "flag -"+l.flagName(f))
+			}
+		}
+	}
+
+	// no se valida un valor que no se pudo convertir
+	if len(errs) == 0 {
+		errs = validate(root, l.fields)
+	}
+	if len(errs) > 0 {
+		return cfg, nil, fmt.Errorf("%s: %w", SigConfig, &ValidationError{Fields: errs})
+	}
+	return cfg, stamps, nil
+}
+
+// applyTree asigna los valores de un archivo y reporta las claves desconocidas.
+func (l *Loader[T]) applyTree(root reflect.Value, tree map[string]any, source string) []FieldError {
+	byKey := make(map[string]field, len(l.fields))
+	tables := map[string]bool{}
+	for _, f := range l.fields {
+		byKey[f.key()] = f
+		for i := 1; i < len(f.path); i++ {
+			tables[strings.Join(f.path[:i], ".")] = true
+		}
+	}
+
+	var errs []FieldError
+	var walk func(node map[string]any, prefix string)
+	walk = func(node map[string]any, prefix string) {
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+
+			switch v := node[k].(type) {
+			case map[string]any:
+				if tables[key] {
+					walk(v, key)
+					continue
+				}
+				if _, ok := byKey[key]; ok {
+					errs = append(errs, FieldError{Field: key, Rule: "parse", Reason: "expected a value in " + source + ", got a table"})
+					continue
+				}
+			case string:
+				if f, ok := byKey[key]; ok {
+					if err := setValue(root.FieldByIndex(f.index), v); err != nil {
+						errs = append(errs, FieldError{Field: key, Rule: "parse", Reason: fmt.Sprintf("invalid value %q from %s: %v", v, source, err)})
+					}
+					continue
+				}
+			case []string:
+				if f, ok := byKey[key]; ok {
+					if err := setList(root.FieldByIndex(f.index), v); err != nil {
+						errs = append(errs, FieldError{Field: key, Rule: "parse", Reason: fmt.Sprintf("invalid list from %s: %v", source, err)})
+					}
+					continue
+				}
+			}
+			errs = append(errs, FieldError{Field: key, Rule: "unknown", Reason: "unknown key in " + source})
+		}
+	}
+	walk(tree, "")
+	return errs
+}
+
+func (l *Loader[T]) envName(f field) string {
+	if f.env != "" {
+		return f.env
+	}
+	if l.options.envPrefix == "" {
+		return ""
+	}
+	return l.options.envPrefix + "_" + strings.ToUpper(strings.Join(f.path, "_"))
+}
+
+func (l *Loader[T]) flagName(f field) string {
+	if f.flag != "" {
+		return f.flag
+	}
+	return strings.ReplaceAll(f.key(), "_", "-")
+}
+
+// registerFlags define un flag por campo. Solo se guardan los flags entregados,
+// para que los demás no pisen las capas anteriores.
+func (l *Loader[T]) registerFlags() {
+	l.flagValues = map[string]string{}
+	for _, f := range l.fields {
+		key := f.key()
+		store := func(raw string) error {
+			l.flagValues[key] = raw
+			return nil
+		}
+		if f.typ.Kind() == reflect.Bool {
+			l.options.flags.BoolFunc(l.flagName(f), f.usage, store)
+			continue
+		}
+		l.options.flags.Func(l.flagName(f), f.usage, store)
+	}
+}
+
+// stamp identifica una versión de un archivo para detectar cambios.
+type stamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func readFile(f file) (stamp, map[string]any, error) {
+	info, err := os.Stat(f.path)
+	if errors.Is(err, fs.ErrNotExist) && f.optional {
+		return stamp{}, map[string]any{}, nil
+	}
+	if err != nil {
+		return stamp{}, nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	st := stamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return st, nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	tree, err := parse(f.format, data)
+	if err != nil {
+		return st, nil, fmt.Errorf("failed to parse %s: %w", f.path, err)
+	}
+	return st, tree, nil
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type dbConfig struct {
+	URL     string        `validate:"required"`
+	Timeout time.Duration `default:"5s" validate:"min=1s,max=1m"`
+}
+
+type testConfig struct {
+	Addr    string   `default:":8080" env:"TEST_ADDR" validate:"required"`
+	Admin   string   `config:"admin_email" validate:"email,max=64"`
+	Debug   bool     `usage:"enable debug logs"`
+	Workers int      `default:"4" validate:"min=1,max=64"`
+	Tags    []string `validate:"max=3"`
+	Level   string   `default:"info" validate:"oneof=debug info warn error"`
+	DB      dbConfig
+	Ignored string `config:"-"`
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func validationErr(t *testing.T, err error) *ValidationError {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	return verr
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.yaml", `
+addr: ":9000"
+admin_email: admin@bike.cl
+workers: 8
+tags: [road, gravel]
+db:
+  url: file:base.db
+  timeout: 10s
+`)
+	local := writeFile(t, dir, "local.toml", `
+workers = 2
+
+[db]
+url = "file:local.db"
+`)
+
+	t.Setenv("TEST_ADDR", ":7000")
+	t.Setenv("APP_DB_TIMEOUT", "20s")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l := NewLoader[testConfig](&strings.Builder{},
+		WithFile(base),
+		WithFile(local),
+		WithOptionalFile(filepath.Join(dir, "missing.json")),
+		WithEnvPrefix("APP"),
+		WithFlags(fs, []string{"-debug", "-workers", "16"}),
+	)
+
+	cfg, err := l.Load()
+	if err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+
+	want := testConfig{
+		Addr:    ":7000",
+		Admin:   "admin@bike.cl",
+		Debug:   true,
+		Workers: 16,
+		Tags:    []string{"road", "gravel"},
+		Level:   "info",
+		DB:      dbConfig{URL: "file:local.db", Timeout: 20 * time.Second},
+	}
+	if cfg.Addr != want.Addr || cfg.Admin != want.Admin || cfg.Debug != want.Debug ||
+		cfg.Workers != want.Workers || strings.Join(cfg.Tags, ",") != "road,gravel" ||
+		cfg.Level != want.Level || cfg.DB != want.DB {
+		t.Fatalf("expected %+v, got %+v", want, cfg)
+	}
+	if current := l.Current(); current.Workers != 16 {
+		t.Fatalf("expected current config to be stored, got %+v", current)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Run("aggregates every invalid field", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeFile(t, dir, "config.json", `{
+			"admin_email": "not-an-email",
+			"workers": 100,
+			"tags": ["a", "b", "c", "d"],
+			"level": "trace"
+		}`)
+
+		l := NewLoader[testConfig](&strings.Builder{}, WithFile(path))
+		_, err := l.Load()
+
+		verr := validationErr(t, err)
+		got := map[string]string{}
+		for _, f := range verr.Fields {
+			got[f.Field] = f.Rule
+		}
+		want := map[string]string{
+			"admin_email": "email",
+			"workers":     "max",
+			"tags":        "max",
+			"level":       "oneof",
+			"db.url":      "required",
+		}
+		for field, rule := range want {
+			if got[field] != rule {
+				t.Errorf("expected %s to fail %s, got %q", field, rule, got[field])
+			}
+		}
+		if len(verr.Fields) != len(want) {
+			t.Errorf("expected %d errors, got %v", len(want), verr)
+		}
+	})
+
+	t.Run("reports unknown keys and bad values", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeFile(t, dir, "config.yaml", `
+workres: 3
+workers: many
+db:
+  url: file:test.db
+  timout: 1s
+`)
+
+		l := NewLoader[testConfig](&strings.Builder{}, WithFile(path))
+		_, err := l.Load()
+
+		verr := validationErr(t, err)
+		msg := verr.Error()
+		for _, part := range []string{"field workres: unknown key", "field db.timout: unknown key", `invalid value "many"`} {
+			if !strings.Contains(msg, part) {
+				t.Errorf("expected %q in %q", part, msg)
+			}
+		}
+	})
+
+	t.Run("missing required file", func(t *testing.T) {
+		l := NewLoader[testConfig](&strings.Builder{}, WithFile(filepath.Join(t.TempDir(), "missing.yaml")))
+		if _, err := l.Load(); err == nil || errors.As(err, new(*ValidationError)) {
+			t.Fatalf("expected read error, got %v", err)
+		}
+	})
+
+	t.Run("failed load keeps current config", func(t *testing.T) {
+		t.Setenv("APP_DB_URL", "file:ok.db")
+		l := NewLoader[testConfig](&strings.Builder{}, WithEnvPrefix("APP"))
+		if _, err := l.Load(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		t.Setenv("APP_WORKERS", "0")
+		if _, err := l.Load(); err == nil {
+			t.Fatal("expected validation error")
+		}
+		if l.Current().Workers != 4 {
+			t.Fatalf("expected previous config to stay, got %+v", l.Current())
+		}
+	})
+
+	t.Run("invalid tags panic", func(t *testing.T) {
+		cases := map[string]func(){
+			"unknown rule": func() {
+				NewLoader[struct {
+					A string `validate:"shiny"`
+				}](&strings.Builder{})
+			},
+			"format rule on int": func() {
+				NewLoader[struct {
+					A int `validate:"email"`
+				}](&strings.Builder{})
+			},
+			"unsupported type": func() {
+				NewLoader[struct{ A map[string]string }](&strings.Builder{})
+			},
+			"not a struct": func() { NewLoader[int](&strings.Builder{}) },
+		}
+		for name, fn := range cases {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Fatal("expected panic")
+					}
+				}()
+				fn()
+			})
+		}
+	})
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Addr":       "addr",
+		"HTTPPort":   "http_port",
+		"DB":         "db",
+		"MaxRetries": "max_retries",
+		"UserID":     "user_id",
+	}
+	for in, want := range cases {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "workers: 2\ndb:\n  url: file:test.db\n")
+
+	stderr := &lockedWriter{}
+	l := NewLoader[testConfig](stderr, WithFile(path), WithPollInterval(5*time.Millisecond))
+
+	if err := l.Start(context.Background()); err == nil {
+		t.Fatal("expected error starting before Load")
+	}
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+
+	updates := make(chan testConfig, 1)
+	l.Subscribe(func(cfg testConfig) { updates <- cfg })
+
+	done := make(chan error, 1)
+	go func() { done <- l.Start(context.Background()) }()
+	<-l.Ready()
+
+	writeFile(t, dir, "config.yaml", "workers: 100\ndb:\n  url: file:test.db\n")
+	waitFor(t, func() bool { return strings.Contains(stderr.String(), "reload failed") })
+	if l.Current().Workers != 2 {
+		t.Fatalf("expected invalid reload to keep config, got %+v", l.Current())
+	}
+
+	writeFile(t, dir, "config.yaml", "workers: 6\ndb:\n  url: file:test.db\n")
+	select {
+	case cfg := <-updates:
+		if cfg.Workers != 6 {
+			t.Fatalf("expected reloaded config, got %+v", cfg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Stop(ctx); err != nil {
+		t.Fatalf("unexpected error stopping: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error from Start: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.Write(p)
+}
+
+func (w *lockedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.String()
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// field describe un valor configurable de la estructura, con su path de claves.
+type field struct {
+	path  []string
+	index []int
+	typ   reflect.Type
+	def   *string
+	env   string
+	flag  string
+	usage string
+	rules []rule
+}
+
+func (f field) key() string {
+	return strings.Join(f.path, ".")
+}
+
+// isLeaf indica si t se asigna desde un único valor en vez de recorrerse como tabla.
+func isLeaf(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// supported indica si setValue sabe asignar un valor de tipo t.
+func supported(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && isLeaf(t.Elem()) && supported(t.Elem())
+	}
+	return false
+}
+
+// fieldsOf recorre t y retorna sus campos configurables. Las estructuras anónimas
+// se aplanan y las anidadas agregan su clave al path.
+// Panics si un campo tiene un tipo no soportado o un tag inválido.
+func fieldsOf(t reflect.Type, path []string, index []int) []field {
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Tag.Get("config")
+		if name == "-" {
+			continue
+		}
+
+		idx := append(append([]int{}, index...), i)
+		if sf.Anonymous && name == "" && !isLeaf(sf.Type) {
+			fields = append(fields, fieldsOf(sf.Type, path, idx)...)
+			continue
+		}
+
+		if name == "" {
+			name = snakeCase(sf.Name)
+		}
+		p := append(append([]string{}, path...), name)
+
+		if !isLeaf(sf.Type) {
+			fields = append(fields, fieldsOf(sf.Type, p, idx)...)
+			continue
+		}
+		if !supported(sf.Type) {
+			panic(fmt.Sprintf("%s: field %s has unsupported type %s", SigConfig, strings.Join(p, "."), sf.Type))
+		}
+
+		f := field{
+			path:  p,
+			index: idx,
+			typ:   sf.Type,
+			env:   sf.Tag.Get("env"),
+			flag:  sf.Tag.Get("flag"),
+			usage: sf.Tag.Get("usage"),
+		}
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			f.def = &def
+		}
+		f.rules = parseRules(f, sf.Tag.Get("validate"))
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// snakeCase convierte un nombre de campo a snake_case, respetando siglas:
+// HTTPPort queda como http_port.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// splitList separa una lista escrita como texto, por ejemplo en una variable de entorno.
+func splitList(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	items := strings.Split(raw, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// setValue asigna raw a v, convirtiéndolo según el tipo de v. Las listas se
+// separan por comas.
+func setValue(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		return setList(v, splitList(raw))
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// setList reemplaza el contenido de la lista v por items.
+func setList(v reflect.Value, items []string) error {
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("expected a single value, got a list")
+	}
+	list := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := setValue(list.Index(i), item); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	v.Set(list)
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type file struct {
+	path     string
+	format   string
+	optional bool
+}
+
+type loaderOpts struct {
+	files        []file
+	envPrefix    string
+	flags        *flag.FlagSet
+	args         []string
+	pollInterval time.Duration
+}
+
+type LoaderOption func(options *loaderOpts)
+
+// formatOf deduce el formato de un archivo por su extensión.
+func formatOf(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml", true
+	case ".json":
+		return "json", true
+	case ".toml":
+		return "toml", true
+	}
+	return "", false
+}
+
+func withFile(path string, optional bool) LoaderOption {
+	return func(options *loaderOpts) {
+		if path == "" {
+			panic(fmt.Sprintf("%s: file path cannot be empty", SigConfig))
+		}
+		format, ok := formatOf(path)
+		if !ok {
+			panic(fmt.Sprintf("%s: unsupported file format %q, use .yaml, .yml, .json or .toml", SigConfig, path))
+		}
+		options.files = append(options.files, file{path: path, format: format, optional: optional})
+	}
+}
+
+// WithFile agrega un archivo YAML, JSON o TOML según su extensión. Los archivos
+// se aplican en el orden en que se agregan, y cada uno pisa a los anteriores.
+// Load falla si el archivo no existe. Panics si el path está vacío o el formato
+// no es soportado.
+func WithFile(path string) LoaderOption {
+	return withFile(path, false)
+}
+
+// WithOptionalFile es como WithFile, pero se ignora si el archivo no existe.
+func WithOptionalFile(path string) LoaderOption {
+	return withFile(path, true)
+}
+
+// WithEnvPrefix lee de variables de entorno los campos sin tag env, con el nombre
+// PREFIX_CAMPO_ANIDADO. Los campos con tag env se leen siempre.
+// Panics si prefix está vacío.
+func WithEnvPrefix(prefix string) LoaderOption {
+	return func(options *loaderOpts) {
+		if prefix == "" {
+			panic(fmt.Sprintf("%s: env prefix cannot be empty", SigConfig))
+		}
+		options.envPrefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+	}
+}
+
+// WithFlags registra en fs un flag por campo y lo parsea con args en el primer Load.
+// Solo los flags entregados en args pisan las otras fuentes.
+// Panics si fs es nil.
+func WithFlags(fs *flag.FlagSet, args []string) LoaderOption {
+	return func(options *loaderOpts) {
+		if fs == nil {
+			panic(fmt.Sprintf("%s: flag set cannot be nil", SigConfig))
+		}
+		options.flags = fs
+		options.args = args
+	}
+}
+
+// WithPollInterval establece cada cuánto Start revisa si los archivos cambiaron.
+// Panics si d no es positivo.
+func WithPollInterval(d time.Duration) LoaderOption {
+	return func(options *loaderOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: poll interval must be positive", SigConfig))
+		}
+		options.pollInterval = d
+	}
+}
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Los parsers convierten cada archivo a un árbol de tablas, donde las hojas son
+// string o []string. La conversión al tipo del campo la hace setValue, igual que
+// para variables de entorno y flags.
+
+func parse(format string, data []byte) (map[string]any, error) {
+	switch format {
+	case "json":
+		return parseJSON(data)
+	case "yaml":
+		return parseYAML(data)
+	case "toml":
+		return parseTOML(data)
+	}
+	return nil, fmt.Errorf("unsupported format %s", format)
+}
+
+func parseJSON(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	tree, err := fromJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	return tree.(map[string]any), nil
+}
+
+// fromJSON reemplaza los escalares de JSON por su texto.
+func fromJSON(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			if item == nil {
+				continue
+			}
+			converted, err := fromJSON(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = converted
+		}
+		return out, nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			converted, err := fromJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			s, ok := converted.(string)
+			if !ok {
+				return nil, fmt.Errorf("lists can only contain values")
+			}
+			items[i] = s
+		}
+		return items, nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", v)
+}
+
+// stripComment elimina un comentario que empieza con # fuera de comillas.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// syntax distingue las reglas de las comillas simples: en YAML dos comillas
+// seguidas escapan una, en TOML un string literal no tiene escapes de ningún tipo.
+type syntax int
+
+const (
+	yamlSyntax syntax = iota
+	tomlSyntax
+)
+
+// scalar interpreta un valor entre comillas dobles, simples o sin comillas.
+func scalar(raw string, syn syntax) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"':
+		return strconv.Unquote(raw)
+	case len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		inner := raw[1 : len(raw)-1]
+		if syn == tomlSyntax {
+			if strings.Contains(inner, "'") {
+				return "", fmt.Errorf("literal string %s cannot contain a single quote", raw)
+			}
+			return inner, nil
+		}
+		return strings.ReplaceAll(inner, "''", "'"), nil
+	}
+	return raw, nil
+}
+
+// inlineList interpreta una lista como [a, "b", 'c'].
+func inlineList(raw string, syn syntax) ([]string, error) {
+	inner := strings.TrimSpace(raw[1 : len(raw)-1])
+	if inner == "" {
+		return []string{}, nil
+	}
+
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range inner {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, inner[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in list %s", raw)
+	}
+	if last := strings.TrimSpace(inner[start:]); last != "" {
+		items = append(items, last)
+	}
+
+	for i, item := range items {
+		s, err := scalar(item, syn)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = s
+	}
+	return items, nil
+}
+
+func value(raw string, syn syntax) (any, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "[") && strings.HasSuffix(raw, "]") {
+		return inlineList(raw, syn)
+	}
+	return scalar(raw, syn)
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML soporta el subconjunto de YAML que se usa en configuración: tablas
+// anidadas por indentación, escalares, listas en bloque con "-" y listas en línea.
+// No soporta anclas, strings multilínea ni tablas dentro de listas.
+func parseYAML(data []byte) (map[string]any, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripComment(strings.TrimRight(line, "\r")), " \t")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(line) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	tree, next, err := yamlMap(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].num)
+	}
+	return tree, nil
+}
+
+func yamlMap(lines []yamlLine, pos, indent int) (map[string]any, int, error) {
+	tree := map[string]any{}
+	for pos < len(lines) && lines[pos].indent >= indent {
+		line := lines[pos]
+		if line.indent > indent {
+			return nil, pos, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if strings.HasPrefix(line.text, "- ") || line.text == "-" {
+			return nil, pos, fmt.Errorf("line %d: unexpected list item", line.num)
+		}
+
+		key, rest, ok := strings.Cut(line.text, ": ")
+		if !ok {
+			if !strings.HasSuffix(line.text, ":") {
+				return nil, pos, fmt.Errorf("line %d: expected key: value", line.num)
+			}
+			key = strings.TrimSuffix(line.text, ":")
+		}
+		key, err := scalar(key, yamlSyntax)
+		if err != nil {
+			return nil, pos, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		pos++
+
+		if rest = strings.TrimSpace(rest); rest != "" {
+			if rest == "~" || rest == "null" {
+				continue
+			}
+			v, err := value(rest, yamlSyntax)
+			if err != nil {
+				return nil, pos, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			tree[key] = v
+			continue
+		}
+
+		// sin valor: lo que sigue con más indentación es una tabla o una lista
+		if pos >= len(lines) || lines[pos].indent <= indent {
+			if pos < len(lines) && lines[pos].indent == indent && strings.HasPrefix(lines[pos].text, "-") {
+				items, next, err := yamlList(lines, pos, indent)
+				if err != nil {
+					return nil, pos, err
+				}
+				tree[key], pos = items, next
+			}
+			continue
+		}
+		if strings.HasPrefix(lines[pos].text, "-") {
+			items, next, err := yamlList(lines, pos, lines[pos].indent)
+			if err != nil {
+				return nil, pos, err
+			}
+			tree[key], pos = items, next
+			continue
+		}
+		child, next, err := yamlMap(lines, pos, lines[pos].indent)
+		if err != nil {
+			return nil, next, err
+		}
+		tree[key], pos = child, next
+	}
+	return tree, pos, nil
+}
+
+func yamlList(lines []yamlLine, pos, indent int) ([]string, int, error) {
+	items := []string{}
+	for pos < len(lines) && lines[pos].indent == indent && strings.HasPrefix(lines[pos].text, "-") {
+		line := lines[pos]
+		item, err := scalar(strings.TrimPrefix(line.text, "-"), yamlSyntax)
+		if err != nil {
+			return nil, pos, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		if strings.HasSuffix(item, ":") || strings.Contains(item, ": ") {
+			return nil, pos, fmt.Errorf("line %d: tables inside lists are not supported", line.num)
+		}
+		items = append(items, item)
+		pos++
+	}
+	return items, pos, nil
+}
+
+// parseTOML soporta el subconjunto de TOML que se usa en configuración: tablas
+// [a.b], claves con puntos, strings, números, booleanos y listas de escalares,
+// que pueden ocupar varias líneas. No soporta tablas en línea ni arreglos de tablas.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := map[string]any{}
+	current := root
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimSpace(stripComment(strings.TrimRight(lines[i], "\r")))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if strings.HasPrefix(line, "[[") || !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid table header", num)
+			}
+			table, err := tomlTable(root, line[1:len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			current = table
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", num)
+		}
+		raw = strings.TrimSpace(raw)
+		for strings.HasPrefix(raw, "[") && !strings.HasSuffix(raw, "]") && i+1 < len(lines) {
+			i++
+			raw += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+		if strings.HasPrefix(raw, "{") {
+			return nil, fmt.Errorf("line %d: inline tables are not supported", num)
+		}
+
+		v, err := value(raw, tomlSyntax)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+
+		keys := strings.Split(strings.TrimSpace(key), ".")
+		table, err := tomlTable(current, strings.Join(keys[:len(keys)-1], "."))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		last, err := scalar(keys[len(keys)-1], tomlSyntax)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		if _, exists := table[last]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %s", num, last)
+		}
+		table[last] = v
+	}
+	return root, nil
+}
+
+// tomlTable retorna la tabla en path dentro de root, creándola si no existe.
+func tomlTable(root map[string]any, path string) (map[string]any, error) {
+	table := root
+	if strings.TrimSpace(path) == "" {
+		return table, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		name, err := scalar(part, tomlSyntax)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, fmt.Errorf("empty table name in %s", path)
+		}
+		child, exists := table[name]
+		if !exists {
+			next := map[string]any{}
+			table[name] = next
+			table = next
+			continue
+		}
+		next, ok := child.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a table", name)
+		}
+		table = next
+	}
+	return table, nil
+}
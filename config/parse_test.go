@@ -0,0 +1,133 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	want := map[string]any{
+		"name":  "bike # not a comment",
+		"quote": `say "hi"`,
+		"port":  "8080",
+		"debug": "true",
+		"tags":  []string{"road", "gravel"},
+		"db": map[string]any{
+			"url": "file:test.db",
+			"pool": map[string]any{
+				"size": "4",
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		format string
+		data   string
+	}{
+		"yaml": {"yaml", `
+# comentario
+name: "bike # not a comment"
+quote: 'say "hi"'
+port: 8080   # puerto
+debug: true
+tags:
+  - road
+  - "gravel"
+empty: ~
+db:
+  url: file:test.db
+  pool:
+    size: 4
+`},
+		"yaml inline list": {"yaml", `
+name: "bike # not a comment"
+quote: "say \"hi\""
+port: 8080
+debug: true
+tags: [road, 'gravel']
+db:
+  url: file:test.db
+  pool:
+      size: 4
+`},
+		"toml": {"toml", `
+name = "bike # not a comment"
+quote = 'say "hi"'
+port = 8080 # puerto
+debug = true
+tags = [
+  "road",
+  "gravel",
+]
+
+[db]
+url = "file:test.db"
+pool.size = 4
+`},
+		"json": {"json", `{
+			"name": "bike # not a comment",
+			"quote": "say \"hi\"",
+			"port": 8080,
+			"debug": true,
+			"tags": ["road", "gravel"],
+			"empty": null,
+			"db": {"url": "file:test.db", "pool": {"size": 4}}
+		}`},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := parse(tc.format, []byte(tc.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected %#v, got %#v", want, got)
+			}
+		})
+	}
+}
+
+func TestParseSingleQuotes(t *testing.T) {
+	yaml, err := parse("yaml", []byte("a: 'it''s'\n"))
+	if err != nil || yaml["a"] != "it's" {
+		t.Fatalf("expected yaml to unescape doubled quotes, got %#v (%v)", yaml, err)
+	}
+
+	// Los strings literales de TOML no tienen escapes
+	toml, err := parse("toml", []byte(`path = 'C:\temp\new'`+"\n"+`rx = ['\d+', 'a\tb']`+"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if toml["path"] != `C:\temp\new` || !reflect.DeepEqual(toml["rx"], []string{`\d+`, `a\tb`}) {
+		t.Fatalf("expected literal strings untouched, got %#v", toml)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]struct {
+		format string
+		data   string
+	}{
+		"yaml bad indentation":  {"yaml", "db:\n  url: a\n    pool: 4\n"},
+		"yaml missing colon":    {"yaml", "just text\n"},
+		"yaml table in list":    {"yaml", "items:\n  - name: a\n"},
+		"yaml tabs":             {"yaml", "db:\n\turl: a\n"},
+		"toml missing equals":   {"toml", "name \"bike\"\n"},
+		"toml duplicate key":    {"toml", "a = 1\na = 2\n"},
+		"toml array of tables":  {"toml", "[[bikes]]\nname = \"a\"\n"},
+		"toml inline table":     {"toml", "db = { url = \"a\" }\n"},
+		"toml key is not table": {"toml", "db = 1\n[db]\nurl = \"a\"\n"},
+		"toml quote in literal": {"toml", "a = 'it''s'\n"},
+		"json lists of tables":  {"json", `{"items": [{"a": 1}]}`},
+		"json invalid":          {"json", `{"a": `},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parse(tc.format, []byte(tc.data)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
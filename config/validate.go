@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-on-bike/bike/validator"
+)
+
+// FieldError describe un campo que no se pudo cargar o que no cumple una regla.
+type FieldError struct {
+	// Field es el path del campo con puntos, por ejemplo "db.url".
+	Field string
+	// Rule es la regla que falló, o "parse" si el valor no se pudo convertir y
+	// "unknown" si un archivo trae una clave que no existe.
+	Rule   string
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field %s: %s", e.Field, e.Reason)
+}
+
+// ValidationError agrupa todos los errores de un Load, para reportarlos de una vez.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d invalid fields: %s", len(e.Fields), strings.Join(msgs, "; "))
+}
+
+// rule es una regla del tag validate, ya compilada para el tipo del campo.
+type rule struct {
+	name  string
+	arg   string
+	check func(v reflect.Value) bool
+}
+
+// formatRules validan strings con las funciones del paquete validator. No se
+// aplican a strings vacíos, para eso está required.
+var formatRules = map[string]func(string) bool{
+	"email":  validator.StringEmail,
+	"uuid":   validator.StringUUID,
+	"digits": validator.StringDigit,
+	"rut":    validator.StringRut,
+}
+
+// parseRules compila un tag como "required,email,max=64".
+// Panics si una regla no existe o no aplica al tipo del campo.
+func parseRules(f field, tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		r := rule{name: name, arg: arg}
+
+		switch {
+		case name == "required":
+			r.check = func(v reflect.Value) bool {
+				if v.Kind() == reflect.String {
+					return validator.NotEmpty(v.String())
+				}
+				if v.Kind() == reflect.Slice {
+					return v.Len() > 0
+				}
+				return !v.IsZero()
+			}
+		case formatRules[name] != nil:
+			if f.typ.Kind() != reflect.String {
+				panic(fmt.Sprintf("%s: rule %s on field %s needs a string", SigConfig, name, f.key()))
+			}
+			match := formatRules[name]
+			r.check = func(v reflect.Value) bool { return v.String() == "" || match(v.String()) }
+		case name == "min" || name == "max":
+			r.check = boundRule(f, name, arg)
+		case name == "oneof":
+			allowed := strings.Fields(arg)
+			if len(allowed) == 0 {
+				panic(fmt.Sprintf("%s: rule oneof on field %s needs values", SigConfig, f.key()))
+			}
+			r.check = func(v reflect.Value) bool {
+				return validator.AllowedValues(fmt.Sprint(v.Interface()), allowed...)
+			}
+		default:
+			panic(fmt.Sprintf("%s: unknown rule %q on field %s", SigConfig, name, f.key()))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// boundRule compila min y max: largo para strings y listas, valor para números.
+func boundRule(f field, name, arg string) func(v reflect.Value) bool {
+	invalid := func() {
+		panic(fmt.Sprintf("%s: rule %s on field %s has invalid argument %q", SigConfig, name, f.key(), arg))
+	}
+	within := func(n float64, bound float64) bool {
+		if name == "min" {
+			return n >= bound
+		}
+		return n <= bound
+	}
+
+	switch {
+	case f.typ == durationType:
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			invalid()
+		}
+		return func(v reflect.Value) bool { return within(float64(v.Int()), float64(bound)) }
+	case f.typ.Kind() == reflect.String:
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			invalid()
+		}
+		if name == "min" {
+			return func(v reflect.Value) bool { return validator.MinChar(v.String(), n) }
+		}
+		return func(v reflect.Value) bool { return validator.MaxChar(v.String(), n) }
+	}
+
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		invalid()
+	}
+	switch f.typ.Kind() {
+	case reflect.Slice:
+		return func(v reflect.Value) bool { return within(float64(v.Len()), bound) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) bool { return within(float64(v.Int()), bound) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) bool { return within(float64(v.Uint()), bound) }
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) bool { return within(v.Float(), bound) }
+	}
+	panic(fmt.Sprintf("%s: rule %s does not apply to field %s of type %s", SigConfig, name, f.key(), f.typ))
+}
+
+// validate aplica las reglas de cada campo sobre la estructura cargada.
+func validate(root reflect.Value, fields []field) []FieldError {
+	var errs []FieldError
+	for _, f := range fields {
+		v := root.FieldByIndex(f.index)
+		for _, r := range f.rules {
+			if r.check(v) {
+				continue
+			}
+			reason := "fails rule " + r.name
+			if r.arg != "" {
+				reason += "=" + r.arg
+			}
+			errs = append(errs, FieldError{Field: f.key(), Rule: r.name, Reason: reason})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Start revisa los archivos cada poll interval y, si alguno cambió, recarga la
+// configuración y la entrega a los suscriptores. Una recarga inválida se registra
+// en stderr y se mantiene la configuración anterior. Bloquea hasta que ctx se
+// cancele o se llame a Stop, y requiere un Load previo.
+func (l *Loader[T]) Start(ctx context.Context) error {
+	l.mu.RLock()
+	loaded := l.loaded
+	l.mu.RUnlock()
+	if !loaded {
+		return fmt.Errorf("%s: Load must succeed before Start", SigConfig)
+	}
+	if !l.started.CompareAndSwap(false, true) {
+		return fmt.Errorf("%s: already started", SigConfig)
+	}
+	defer close(l.done)
+	close(l.ready)
+
+	ticker := time.NewTicker(l.options.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-l.stop:
+			return nil
+		case <-ticker.C:
+			if l.changed() {
+				l.reload()
+			}
+		}
+	}
+}
+
+// Ready se cierra cuando Start empieza a vigilar los archivos.
+func (l *Loader[T]) Ready() <-chan struct{} {
+	return l.ready
+}
+
+// Stop detiene Start y espera a que termine hasta el deadline de ctx.
+// Si Start no ha partido retorna de inmediato.
+func (l *Loader[T]) Stop(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	if !l.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// changed compara el estado de los archivos con el de la última carga y lo
+// actualiza, para que un archivo inválido no se reintente en cada tick.
+func (l *Loader[T]) changed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	changed := false
+	for _, f := range l.options.files {
+		var st stamp
+		info, err := os.Stat(f.path)
+		switch {
+		case err == nil:
+			st = stamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+		case !errors.Is(err, fs.ErrNotExist):
+			continue
+		}
+		if st != l.stamps[f.path] {
+			l.stamps[f.path] = st
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (l *Loader[T]) reload() {
+	cfg, err := l.Load()
+	if err != nil {
+		fmt.Fprintf(l.stderr, "%s: reload failed, keeping current config: %v", SigConfig, err)
+		return
+	}
+	fmt.Fprintf(l.stderr, "%s: reloaded", SigConfig)
+
+	l.mu.RLock()
+	subs := append([]func(T){}, l.subs...)
+	l.mu.RUnlock()
+
+	for _, fn := range subs {
+		fn(cfg)
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigCache string = "cache"
+
+// loadTimeout limita cuánto puede tardar una carga de GetOrLoad, que no se
+// cancela con el contexto de quien la inició.
+const loadTimeout = 30 * time.Second
+
+// call es una carga en curso a la que se suman las llamadas concurrentes.
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// group deduplica cargas concurrentes de la misma llave, al estilo de singleflight.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do ejecuta fn una sola vez por llave entre las llamadas concurrentes, en su
+// propia goroutine. Cualquier llamada, también la que inició la carga, puede
+// abandonar la espera si su ctx se cancela sin afectar a las demás.
+func (g *group) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go func() {
+			c.value, c.err = fn()
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return slices.Clone(c.value), c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// getOrLoad implementa GetOrLoad sobre Get y Set de cualquier cache. Si el valor
+// se cargó pero no pudo guardarse, retorna el valor junto al error de Set.
+// La carga recibe un contexto con los valores de ctx pero sin su cancelación,
+// limitado por loadTimeout, para que quien la inició no la corte para el resto.
+func getOrLoad(ctx context.Context, c interfaces.Cache, g *group, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if load == nil {
+		return nil, fmt.Errorf("%s: load function cannot be nil", SigCache)
+	}
+
+	value, err := c.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, interfaces.ErrCacheMiss) {
+		return nil, err
+	}
+
+	return g.do(ctx, key, func() ([]byte, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to load %s: %w", SigCache, key, err)
+		}
+		if err := c.Set(loadCtx, key, value, ttl); err != nil {
+			return value, err
+		}
+		return value, nil
+	})
+}
+
+func validate(key string, ttl time.Duration) error {
+	if key == "" {
+		return fmt.Errorf("%s: key cannot be empty", SigCache)
+	}
+	if ttl < 0 {
+		return fmt.Errorf("%s: ttl cannot be negative, got %s", SigCache, ttl)
+	}
+	return nil
+}
+
+func miss(key string) error {
+	return fmt.Errorf("%s: %s: %w", SigCache, key, interfaces.ErrCacheMiss)
+}
@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/interfaces"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// cacheFactory crea un cache cuyo reloj apunta a now.
+type cacheFactory func(t *testing.T, now *time.Time) interfaces.Cache
+
+func newTestSQL(t *testing.T, now *time.Time) *SQLCache {
+	dbURL := "file:" + filepath.Join(t.TempDir(), "test.db")
+	c := sqlhandler.NewConnector(&strings.Builder{}, sqlhandler.WithURL(dbURL))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	cache := NewSQL(&strings.Builder{}, c.DB(), "libsql")
+	if err := cache.Migrate(); err != nil {
+		t.Fatalf("unexpected error migrating: %v", err)
+	}
+	cache.now = func() time.Time { return *now }
+	return cache
+}
+
+func newTestMemory(t *testing.T, now *time.Time, opts ...MemoryOption) *MemoryCache {
+	cache := NewMemory(opts...)
+	cache.now = func() time.Time { return *now }
+	return cache
+}
+
+func TestCaches(t *testing.T) {
+	factories := map[string]cacheFactory{
+		"sql":    func(t *testing.T, now *time.Time) interfaces.Cache { return newTestSQL(t, now) },
+		"memory": func(t *testing.T, now *time.Time) interfaces.Cache { return newTestMemory(t, now) },
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			testCache(t, factory)
+		})
+	}
+}
+
+func testCache(t *testing.T, factory cacheFactory) {
+	ctx := context.Background()
+
+	t.Run("set, get and delete", func(t *testing.T) {
+		now := time.Now()
+		c := factory(t, &now)
+
+		if _, err := c.Get(ctx, "bike"); !errors.Is(err, interfaces.ErrCacheMiss) {
+			t.Fatalf("expected ErrCacheMiss, got %v", err)
+		}
+		if err := c.Set(ctx, "bike", []byte("road"), 0); err != nil {
+			t.Fatalf("unexpected error setting: %v", err)
+		}
+		if err := c.Set(ctx, "bike", []byte("gravel"), 0); err != nil {
+			t.Fatalf("unexpected error overwriting: %v", err)
+		}
+
+		value, err := c.Get(ctx, "bike")
+		if err != nil || string(value) != "gravel" {
+			t.Fatalf("expected gravel, got %q (%v)", value, err)
+		}
+
+		if err := c.Delete(ctx, "bike"); err != nil {
+			t.Fatalf("unexpected error deleting: %v", err)
+		}
+		if _, err := c.Get(ctx, "bike"); !errors.Is(err, interfaces.ErrCacheMiss) {
+			t.Fatalf("expected ErrCacheMiss after delete, got %v", err)
+		}
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		now := time.Now()
+		c := factory(t, &now)
+
+		c.Set(ctx, "short", []byte("x"), time.Minute)
+		c.Set(ctx, "forever", []byte("y"), 0)
+
+		now = now.Add(time.Minute)
+		if _, err := c.Get(ctx, "short"); !errors.Is(err, interfaces.ErrCacheMiss) {
+			t.Fatalf("expected expired entry to miss, got %v", err)
+		}
+		if _, err := c.Get(ctx, "forever"); err != nil {
+			t.Fatalf("expected entry without ttl to stay, got %v", err)
+		}
+	})
+
+	t.Run("get or load deduplicates loads", func(t *testing.T) {
+		now := time.Now()
+		c := factory(t, &now)
+
+		var loads atomic.Int32
+		release := make(chan struct{})
+		load := func(ctx context.Context) ([]byte, error) {
+			loads.Add(1)
+			<-release
+			return []byte("loaded"), nil
+		}
+
+		var wg sync.WaitGroup
+		results := make(chan string, 5)
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := c.GetOrLoad(ctx, "bike", time.Minute, load)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				results <- string(value)
+			}()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		close(results)
+
+		for value := range results {
+			if value != "loaded" {
+				t.Fatalf("expected loaded value, got %q", value)
+			}
+		}
+		if loads.Load() != 1 {
+			t.Fatalf("expected a single load, got %d", loads.Load())
+		}
+
+		value, err := c.GetOrLoad(ctx, "bike", time.Minute, func(ctx context.Context) ([]byte, error) {
+			return nil, errors.New("should use cached value")
+		})
+		if err != nil || string(value) != "loaded" {
+			t.Fatalf("expected cached value, got %q (%v)", value, err)
+		}
+	})
+
+	t.Run("canceled callers do not cancel the load", func(t *testing.T) {
+		now := time.Now()
+		c := factory(t, &now)
+
+		release := make(chan struct{})
+		loaded := []byte("loaded")
+		load := func(ctx context.Context) ([]byte, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return loaded, nil
+		}
+
+		canceled, cancel := context.WithCancel(ctx)
+		first := make(chan error)
+		go func() {
+			_, err := c.GetOrLoad(canceled, "bike", time.Minute, load)
+			first <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		second := make(chan []byte)
+		go func() {
+			value, err := c.GetOrLoad(ctx, "bike", time.Minute, load)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			second <- value
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		cancel()
+		if err := <-first; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the canceled caller to stop waiting, got %v", err)
+		}
+		close(release)
+
+		value := <-second
+		loaded[0] = 'X'
+		if string(value) != "loaded" {
+			t.Fatalf("expected an isolated copy of the loaded value, got %q", value)
+		}
+	})
+
+	t.Run("load errors are not cached", func(t *testing.T) {
+		now := time.Now()
+		c := factory(t, &now)
+
+		errBoom := errors.New("boom")
+		_, err := c.GetOrLoad(ctx, "bike", 0, func(ctx context.Context) ([]byte, error) { return nil, errBoom })
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected boom error, got %v", err)
+		}
+
+		value, err := c.GetOrLoad(ctx, "bike", 0, func(ctx context.Context) ([]byte, error) { return []byte("ok"), nil })
+		if err != nil || string(value) != "ok" {
+			t.Fatalf("expected second load to run, got %q (%v)", value, err)
+		}
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		now := time.Now()
+		c := factory(t, &now)
+
+		if err := c.Set(ctx, "", []byte("x"), 0); err == nil {
+			t.Fatal("expected error with empty key")
+		}
+		if err := c.Set(ctx, "bike", []byte("x"), -time.Second); err == nil {
+			t.Fatal("expected error with negative ttl")
+		}
+		if _, err := c.GetOrLoad(ctx, "bike", 0, nil); err == nil {
+			t.Fatal("expected error with nil loader")
+		}
+	})
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		now := time.Now()
+		c := newTestMemory(t, &now, WithShards(1), WithMaxEntries(2))
+
+		c.Set(ctx, "a", []byte("1"), 0)
+		c.Set(ctx, "b", []byte("2"), 0)
+		c.Get(ctx, "a")
+		c.Set(ctx, "c", []byte("3"), 0)
+
+		if _, err := c.Get(ctx, "b"); !errors.Is(err, interfaces.ErrCacheMiss) {
+			t.Fatalf("expected b to be evicted, got %v", err)
+		}
+		if _, err := c.Get(ctx, "a"); err != nil {
+			t.Fatalf("expected a to stay, got %v", err)
+		}
+
+		stats := c.Stats()
+		if stats.Evictions != 1 || stats.Entries != 2 || stats.Sets != 3 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("limits entries across shards", func(t *testing.T) {
+		now := time.Now()
+		c := newTestMemory(t, &now, WithMaxEntries(1))
+
+		for _, key := range []string{"a", "b", "c", "d"} {
+			c.Set(ctx, key, []byte(key), 0)
+		}
+		if stats := c.Stats(); stats.Entries != 1 || stats.Evictions != 3 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		if _, err := c.Get(ctx, "d"); err != nil {
+			t.Fatalf("expected last entry to stay, got %v", err)
+		}
+	})
+
+	t.Run("limits bytes", func(t *testing.T) {
+		now := time.Now()
+		c := newTestMemory(t, &now, WithShards(1), WithMaxBytes(10))
+
+		c.Set(ctx, "a", []byte("1234"), 0)
+		c.Set(ctx, "b", []byte("1234"), 0)
+		if stats := c.Stats(); stats.Bytes != 10 || stats.Entries != 2 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+
+		c.Set(ctx, "c", []byte("12"), 0)
+		if _, err := c.Get(ctx, "a"); !errors.Is(err, interfaces.ErrCacheMiss) {
+			t.Fatalf("expected a to be evicted, got %v", err)
+		}
+		if err := c.Set(ctx, "huge", make([]byte, 20), 0); err == nil {
+			t.Fatal("expected error for entry larger than the limit")
+		}
+	})
+
+	t.Run("counts hits, misses and expirations", func(t *testing.T) {
+		now := time.Now()
+		c := newTestMemory(t, &now)
+
+		c.Set(ctx, "a", []byte("1"), time.Minute)
+		c.Set(ctx, "b", []byte("2"), time.Minute)
+		c.Get(ctx, "a")
+		c.Get(ctx, "missing")
+
+		now = now.Add(time.Minute)
+		if removed := c.Purge(); removed != 2 {
+			t.Fatalf("expected 2 purged entries, got %d", removed)
+		}
+
+		stats := c.Stats()
+		if stats.Hits != 1 || stats.Misses != 1 || stats.Expirations != 2 || stats.Entries != 0 || stats.Bytes != 0 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("values are copied", func(t *testing.T) {
+		now := time.Now()
+		c := newTestMemory(t, &now)
+
+		value := []byte("road")
+		c.Set(ctx, "bike", value, 0)
+		value[0] = 'l'
+
+		got, _ := c.Get(ctx, "bike")
+		got[1] = 'a'
+		if again, _ := c.Get(ctx, "bike"); string(again) != "road" {
+			t.Fatalf("expected stored value to be isolated, got %q", again)
+		}
+	})
+}
+
+func TestSQLCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := newTestSQL(t, &now)
+
+	// Otra instancia sobre la misma base de datos ve los mismos valores
+	other := NewSQL(&strings.Builder{}, c.db, "libsql")
+	other.now = c.now
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), 0)
+	if value, err := other.Get(ctx, "a"); err != nil || string(value) != "1" {
+		t.Fatalf("expected shared value, got %q (%v)", value, err)
+	}
+
+	now = now.Add(time.Minute)
+	removed, err := c.Purge(ctx)
+	if err != nil || removed != 1 {
+		t.Fatalf("expected 1 purged entry, got %d (%v)", removed, err)
+	}
+	if _, err := other.Get(ctx, "b"); err != nil {
+		t.Fatalf("expected b to stay, got %v", err)
+	}
+}
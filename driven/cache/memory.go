@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+type memoryOpts struct {
+	shards     int
+	maxEntries int
+	maxBytes   int64
+}
+
+type MemoryOption func(options *memoryOpts)
+
+// WithShards establece en cuántas partes, cada una con su propio lock, se divide
+// el cache. Se redondea a la siguiente potencia de 2. Panics si n no es positivo.
+func WithShards(n int) MemoryOption {
+	return func(options *memoryOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: shards must be positive", SigCache))
+		}
+		options.shards = n
+	}
+}
+
+// WithMaxEntries limita la cantidad de llaves en todo el cache. Al superarlo se
+// descartan las menos usadas recientemente de cada shard. 0 significa sin límite.
+// Panics si n es negativo.
+func WithMaxEntries(n int) MemoryOption {
+	return func(options *memoryOpts) {
+		if n < 0 {
+			panic(fmt.Sprintf("%s: max entries cannot be negative", SigCache))
+		}
+		options.maxEntries = n
+	}
+}
+
+// WithMaxBytes limita el tamaño total de llaves y valores. Al superarlo se
+// descartan las entradas menos usadas recientemente. 0 significa sin límite.
+// Panics si n es negativo.
+func WithMaxBytes(n int64) MemoryOption {
+	return func(options *memoryOpts) {
+		if n < 0 {
+			panic(fmt.Sprintf("%s: max bytes cannot be negative", SigCache))
+		}
+		options.maxBytes = n
+	}
+}
+
+// Stats son las métricas acumuladas de un MemoryCache.
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Sets        uint64
+	Evictions   uint64
+	Expirations uint64
+	Entries     int
+	Bytes       int64
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e *entry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+type shard struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+	bytes int64
+}
+
+// MemoryCache es un cache LRU con TTL dividido en shards para reducir la
+// contención. Los límites son de todo el cache, pero el orden LRU es por shard:
+// al superarlos se descarta la entrada menos usada del shard donde se escribió,
+// o de los siguientes si ese shard no tiene otras.
+type MemoryCache struct {
+	shards     []*shard
+	mask       uint64
+	maxEntries int64
+	maxBytes   int64
+	now        func() time.Time
+	loads      group
+
+	entries, bytes                             atomic.Int64
+	hits, misses, sets, evictions, expirations atomic.Uint64
+}
+
+var _ interfaces.Cache = (*MemoryCache)(nil)
+
+func NewMemory(opts ...MemoryOption) *MemoryCache {
+	options := memoryOpts{shards: 16}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	n := 1
+	for n < options.shards {
+		n <<= 1
+	}
+
+	c := &MemoryCache{
+		shards:     make([]*shard, n),
+		mask:       uint64(n - 1),
+		maxEntries: int64(options.maxEntries),
+		maxBytes:   options.maxBytes,
+		now:        time.Now,
+	}
+	for i := range c.shards {
+		c.shards[i] = &shard{items: map[string]*list.Element{}, lru: list.New()}
+	}
+
+	return c
+}
+
+func (c *MemoryCache) shardFor(key string) (int, *shard) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	i := int(h.Sum64() & c.mask)
+	return i, c.shards[i]
+}
+
+// Get retorna una copia del valor de key, o interfaces.ErrCacheMiss.
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	_, s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, miss(key)
+	}
+
+	e := elem.Value.(*entry)
+	if !e.expiresAt.IsZero() && !e.expiresAt.After(c.now()) {
+		c.remove(s, elem)
+		c.expirations.Add(1)
+		c.misses.Add(1)
+		return nil, miss(key)
+	}
+
+	s.lru.MoveToFront(elem)
+	c.hits.Add(1)
+	return slices.Clone(e.value), nil
+}
+
+// Set guarda una copia de value. Retorna error si el valor es más grande que el
+// límite de WithMaxBytes.
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := validate(key, ttl); err != nil {
+		return err
+	}
+
+	e := &entry{key: key, value: slices.Clone(value)}
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(ttl)
+	}
+	if c.maxBytes > 0 && e.size() > c.maxBytes {
+		return fmt.Errorf("%s: entry %s of %d bytes exceeds limit of %d bytes", SigCache, key, e.size(), c.maxBytes)
+	}
+
+	i, s := c.shardFor(key)
+	s.mu.Lock()
+	if elem, ok := s.items[key]; ok {
+		c.remove(s, elem)
+	}
+	elem := s.lru.PushFront(e)
+	s.items[key] = elem
+	s.bytes += e.size()
+	c.entries.Add(1)
+	c.bytes.Add(e.size())
+	c.sets.Add(1)
+	s.mu.Unlock()
+
+	c.evict(i, elem)
+	return nil
+}
+
+// evict descarta entradas mientras el cache supere sus límites, partiendo por el
+// shard from y sin tocar keep, la entrada recién escrita. Se llama sin tener el
+// lock de ningún shard, para no bloquearse con otro Set que desaloje en orden
+// distinto.
+func (c *MemoryCache) evict(from int, keep *list.Element) {
+	for i := 0; i < len(c.shards) && c.overLimit(); {
+		s := c.shards[(from+i)&int(c.mask)]
+		s.mu.Lock()
+		back := s.lru.Back()
+		if back == keep {
+			back = back.Prev()
+		}
+		if back == nil || !c.overLimit() {
+			s.mu.Unlock()
+			i++
+			continue
+		}
+		c.remove(s, back)
+		c.evictions.Add(1)
+		s.mu.Unlock()
+	}
+}
+
+func (c *MemoryCache) overLimit() bool {
+	return (c.maxEntries > 0 && c.entries.Load() > c.maxEntries) || (c.maxBytes > 0 && c.bytes.Load() > c.maxBytes)
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	_, s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		c.remove(s, elem)
+	}
+	return nil
+}
+
+func (c *MemoryCache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	return getOrLoad(ctx, c, &c.loads, key, ttl, load)
+}
+
+// Purge elimina las entradas expiradas. Fuera de Purge una entrada expirada solo
+// se elimina al leerla, o al desalojarse por el límite de tamaño como cualquier
+// otra; Purge libera la memoria de las que nadie vuelve a leer.
+func (c *MemoryCache) Purge() int {
+	now := c.now()
+	removed := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for elem := s.lru.Back(); elem != nil; {
+			prev := elem.Prev()
+			if e := elem.Value.(*entry); !e.expiresAt.IsZero() && !e.expiresAt.After(now) {
+				c.remove(s, elem)
+				removed++
+			}
+			elem = prev
+		}
+		s.mu.Unlock()
+	}
+	c.expirations.Add(uint64(removed))
+	return removed
+}
+
+// Stats retorna las métricas del cache.
+func (c *MemoryCache) Stats() Stats {
+	stats := Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Sets:        c.sets.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+	for _, s := range c.shards {
+		s.mu.Lock()
+		stats.Entries += len(s.items)
+		stats.Bytes += s.bytes
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// remove saca elem de s. Se llama con el lock de s tomado.
+func (c *MemoryCache) remove(s *shard, elem *list.Element) {
+	e := s.lru.Remove(elem).(*entry)
+	delete(s.items, e.key)
+	s.bytes -= e.size()
+	c.entries.Add(-1)
+	c.bytes.Add(-e.size())
+}
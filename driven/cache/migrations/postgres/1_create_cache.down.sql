@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS cache_entries_expires;
+DROP TABLE IF EXISTS cache_entries;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS cache_entries (
+    cache_key TEXT PRIMARY KEY,
+    value BYTEA NOT NULL,
+    expires_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS cache_entries_expires ON cache_entries (expires_at);
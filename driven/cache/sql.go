@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// MigrationTable es donde Migrate anota las versiones aplicadas de cache_entries.
+const MigrationTable string = "cache_migrations"
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// SQLCache guarda los valores en la tabla cache_entries, de modo que varios
+// procesos que comparten la base de datos vean el mismo cache. La deduplicación de
+// GetOrLoad es por proceso. Las entradas expiradas se ignoran al leer y se borran
+// con Purge.
+type SQLCache struct {
+	stderr  io.Writer
+	db      *sql.DB
+	dialect query.Dialect
+	now     func() time.Time
+	loads   group
+}
+
+var _ interfaces.Cache = (*SQLCache)(nil)
+
+// NewSQL crea un cache sobre db. Con un driver de Postgres, como "pgx" o
+// "postgres", usa su esquema y placeholders; con cualquier otro, los de SQLite.
+func NewSQL(stderr io.Writer, db *sql.DB, driver string) *SQLCache {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigCache))
+	}
+	if db == nil {
+		panic(fmt.Sprintf("%s: db cannot be nil", SigCache))
+	}
+
+	return &SQLCache{stderr: stderr, db: db, dialect: query.DialectFor(driver), now: time.Now}
+}
+
+// Migrate prepara cache_entries para el dialecto del cache. Es seguro llamarlo en
+// cada arranque: solo aplica lo que falta.
+func (c *SQLCache) Migrate() error {
+	dir := "migrations/sqlite"
+	if c.dialect == query.Dollar {
+		dir = "migrations/postgres"
+	}
+	if err := sqlhandler.MigrateFS(c.stderr, c.db, migrationFiles, dir, MigrationTable, c.dialect == query.Dollar); err != nil {
+		return fmt.Errorf("%s: %w", SigCache, err)
+	}
+	return nil
+}
+
+func (c *SQLCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := c.db.QueryRowContext(ctx, c.dialect.Rebind(`
+        SELECT value FROM cache_entries
+        WHERE cache_key = ? AND (expires_at IS NULL OR expires_at > ?)
+    `), key, c.now().UnixNano()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, miss(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get %s: %w", SigCache, key, err)
+	}
+	return value, nil
+}
+
+func (c *SQLCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := validate(key, ttl); err != nil {
+		return err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+
+	var expiresAt any
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl).UnixNano()
+	}
+
+	err := sqlhandler.Retry(ctx, func() error {
+		_, err := c.db.ExecContext(ctx, c.dialect.Rebind(`
+            INSERT INTO cache_entries (cache_key, value, expires_at) VALUES (?, ?, ?)
+            ON CONFLICT (cache_key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at
+        `), key, value, expiresAt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to set %s: %w", SigCache, key, err)
+	}
+	return nil
+}
+
+func (c *SQLCache) Delete(ctx context.Context, key string) error {
+	err := sqlhandler.Retry(ctx, func() error {
+		_, err := c.db.ExecContext(ctx, c.dialect.Rebind(`DELETE FROM cache_entries WHERE cache_key = ?`), key)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete %s: %w", SigCache, key, err)
+	}
+	return nil
+}
+
+func (c *SQLCache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	return getOrLoad(ctx, c, &c.loads, key, ttl, load)
+}
+
+// Purge borra las entradas expiradas y retorna cuántas eliminó.
+func (c *SQLCache) Purge(ctx context.Context) (int64, error) {
+	var res sql.Result
+	err := sqlhandler.Retry(ctx, func() (err error) {
+		res, err = c.db.ExecContext(ctx, c.dialect.Rebind(`DELETE FROM cache_entries WHERE expires_at <= ?`), c.now().UnixNano())
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to purge: %w", SigCache, err)
+	}
+	return res.RowsAffected()
+}
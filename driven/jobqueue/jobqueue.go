@@ -0,0 +1,250 @@
+package jobqueue
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigJobQueue string = "jobqueue"
+
+// MigrationTable lleva las versiones de jobqueue_jobs y jobqueue_dead, para que la
+// cola migre su esquema sin depender de la numeración de la aplicación.
+const MigrationTable string = "jobqueue_migrations"
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+type queueOpts struct {
+	concurrency  int
+	pollInterval time.Duration
+	visibility   time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+}
+
+type QueueOption func(options *queueOpts)
+
+// WithConcurrency establece cuántos jobs procesa Process en paralelo.
+// Panics si n no es positivo.
+func WithConcurrency(n int) QueueOption {
+	return func(options *queueOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: concurrency must be positive", SigJobQueue))
+		}
+		options.concurrency = n
+	}
+}
+
+// WithPollInterval establece cuánto espera un worker cuando no hay jobs listos.
+// Panics si d no es positivo.
+func WithPollInterval(d time.Duration) QueueOption {
+	return func(options *queueOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: poll interval must be positive", SigJobQueue))
+		}
+		options.pollInterval = d
+	}
+}
+
+// WithVisibilityTimeout establece cuánto tiempo un job tomado queda oculto para
+// otros workers. Es también el tiempo máximo que tiene el handler para terminar;
+// si el worker muere el job vuelve a estar disponible al vencer. Panics si d no es positivo.
+func WithVisibilityTimeout(d time.Duration) QueueOption {
+	return func(options *queueOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: visibility timeout must be positive", SigJobQueue))
+		}
+		options.visibility = d
+	}
+}
+
+// WithMaxAttempts establece los intentos por defecto antes de enviar un job a la
+// cola de muertos, para jobs sin MaxAttempts. Panics si n no es positivo.
+func WithMaxAttempts(n int) QueueOption {
+	return func(options *queueOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: max attempts must be positive", SigJobQueue))
+		}
+		options.maxAttempts = n
+	}
+}
+
+// WithBackoff establece la espera exponencial entre reintentos, partiendo en base
+// y sin superar max. Panics si base no es positivo o max < base.
+func WithBackoff(base, max time.Duration) QueueOption {
+	return func(options *queueOpts) {
+		if base <= 0 || max < base {
+			panic(fmt.Sprintf("%s: invalid backoff %s..%s", SigJobQueue, base, max))
+		}
+		options.backoff = base
+		options.maxBackoff = max
+	}
+}
+
+// Queue es una cola de jobs guardada en la base de datos. Funciona con
+// SQLite/libsql, donde las escrituras se serializan, y con Postgres usando
+// FOR UPDATE SKIP LOCKED para que los workers no se bloqueen entre sí.
+type Queue struct {
+	stderr  io.Writer
+	db      *sql.DB
+	dialect query.Dialect
+	options queueOpts
+	now     func() time.Time
+}
+
+var _ interfaces.JobQueue = (*Queue)(nil)
+
+// NewQueue crea una cola sobre db. driver es el mismo nombre usado con sql.Open;
+// con un driver de Postgres la cola toma jobs con FOR UPDATE SKIP LOCKED.
+func NewQueue(stderr io.Writer, db *sql.DB, driver string, opts ...QueueOption) *Queue {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigJobQueue))
+	}
+	if db == nil {
+		panic(fmt.Sprintf("%s: db cannot be nil", SigJobQueue))
+	}
+
+	q := &Queue{
+		stderr:  stderr,
+		db:      db,
+		dialect: query.DialectFor(driver),
+		options: queueOpts{
+			concurrency:  1,
+			pollInterval: time.Second,
+			visibility:   30 * time.Second,
+			maxAttempts:  5,
+			backoff:      time.Second,
+			maxBackoff:   10 * time.Minute,
+		},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(&q.options)
+	}
+
+	return q
+}
+
+// Migrate crea las tablas de jobs y de jobs muertos, o aplica las migraciones que
+// les falten. Se puede llamar en cada arranque.
+func (q *Queue) Migrate() error {
+	dir := "migrations/sqlite"
+	if q.dialect == query.Dollar {
+		dir = "migrations/postgres"
+	}
+	if err := sqlhandler.MigrateFS(q.stderr, q.db, migrationFiles, dir, MigrationTable, q.dialect == query.Dollar); err != nil {
+		return fmt.Errorf("%s: %w", SigJobQueue, err)
+	}
+	return nil
+}
+
+// querier retorna la transacción de ctx si existe, de modo que un job encolado
+// dentro de WithTx solo sea visible si la transacción hace commit.
+func (q *Queue) querier(ctx context.Context) query.Querier {
+	if tx, ok := sqlhandler.TxFromContext(ctx); ok {
+		return tx
+	}
+	return q.db
+}
+
+// Enqueue agrega un job y retorna su ID. Un RunAt vacío significa ejecutar ahora.
+func (q *Queue) Enqueue(ctx context.Context, job interfaces.Job) (int64, error) {
+	if job.Queue == "" {
+		return 0, fmt.Errorf("%s: job queue cannot be empty", SigJobQueue)
+	}
+	if job.MaxAttempts < 0 {
+		return 0, fmt.Errorf("%s: max attempts cannot be negative", SigJobQueue)
+	}
+
+	now := q.now()
+	runAt := job.RunAt
+	if runAt.IsZero() {
+		runAt = now
+	}
+	maxAttempts := job.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = q.options.maxAttempts
+	}
+
+	var id int64
+	err := q.querier(ctx).QueryRowContext(ctx, q.dialect.Rebind(`
+        INSERT INTO jobqueue_jobs (queue, payload, priority, max_attempts, run_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        RETURNING id
+    `), job.Queue, job.Payload, job.Priority, maxAttempts, runAt.UnixNano(), now.UnixNano()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to enqueue job in %s: %w", SigJobQueue, job.Queue, err)
+	}
+	return id, nil
+}
+
+type deadRow struct {
+	ID          int64          `db:"id"`
+	Queue       string         `db:"queue"`
+	Payload     []byte         `db:"payload"`
+	Priority    int            `db:"priority"`
+	Attempts    int            `db:"attempts"`
+	MaxAttempts int            `db:"max_attempts"`
+	LastError   sql.NullString `db:"last_error"`
+	FailedAt    int64          `db:"failed_at"`
+}
+
+// Dead retorna los jobs de queue que agotaron sus intentos, del más reciente al más antiguo.
+func (q *Queue) Dead(ctx context.Context, queue string) ([]interfaces.Job, error) {
+	rows, err := sqlhandler.QueryAll[deadRow](ctx, q.querier(ctx), q.dialect.Rebind(`
+        SELECT id, queue, payload, priority, attempts, max_attempts, last_error, failed_at
+        FROM jobqueue_dead
+        WHERE queue = ?
+        ORDER BY failed_at DESC, id DESC
+    `), queue)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list dead jobs: %w", SigJobQueue, err)
+	}
+
+	jobs := make([]interfaces.Job, len(rows))
+	for i, row := range rows {
+		jobs[i] = interfaces.Job{
+			ID:          row.ID,
+			Queue:       row.Queue,
+			Payload:     row.Payload,
+			Priority:    row.Priority,
+			RunAt:       time.Unix(0, row.FailedAt),
+			MaxAttempts: row.MaxAttempts,
+			Attempts:    row.Attempts,
+			LastError:   row.LastError.String,
+		}
+	}
+	return jobs, nil
+}
+
+// Requeue devuelve un job muerto a la cola con sus intentos en cero.
+func (q *Queue) Requeue(ctx context.Context, id int64) error {
+	return sqlhandler.InTx(ctx, q.db, nil, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, q.dialect.Rebind(`
+            INSERT INTO jobqueue_jobs (id, queue, payload, priority, max_attempts, run_at, last_error, created_at)
+            SELECT id, queue, payload, priority, max_attempts, ?, last_error, created_at
+            FROM jobqueue_dead
+            WHERE id = ?
+        `), q.now().UnixNano(), id)
+		if err != nil {
+			return fmt.Errorf("%s: failed to requeue job %d: %w", SigJobQueue, id, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("%s: dead job %d: %w", SigJobQueue, id, interfaces.ErrNotFound)
+		}
+
+		if _, err := tx.ExecContext(ctx, q.dialect.Rebind(`DELETE FROM jobqueue_dead WHERE id = ?`), id); err != nil {
+			return fmt.Errorf("%s: failed to remove dead job %d: %w", SigJobQueue, id, err)
+		}
+		return nil
+	})
+}
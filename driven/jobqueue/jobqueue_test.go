@@ -0,0 +1,284 @@
+package jobqueue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/interfaces"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// lockedWriter permite que varios workers escriban en el mismo stderr.
+type lockedWriter struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.Write(p)
+}
+
+func (w *lockedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.String()
+}
+
+func newTestQueue(t *testing.T, opts ...QueueOption) (*Queue, *sqlhandler.Connector, *lockedWriter) {
+	dbURL := "file:" + filepath.Join(t.TempDir(), "test.db")
+	c := sqlhandler.NewConnector(&strings.Builder{}, sqlhandler.WithURL(dbURL))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	stderr := &lockedWriter{}
+	q := NewQueue(stderr, c.DB(), "libsql", opts...)
+	if err := q.Migrate(); err != nil {
+		t.Fatalf("unexpected error migrating: %v", err)
+	}
+	return q, c, stderr
+}
+
+func enqueue(t *testing.T, q *Queue, job interfaces.Job) int64 {
+	id, err := q.Enqueue(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error enqueueing: %v", err)
+	}
+	return id
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs jobs by priority", func(t *testing.T) {
+		q, _, _ := newTestQueue(t)
+		enqueue(t, q, interfaces.Job{Queue: "mail", Payload: []byte("low")})
+		enqueue(t, q, interfaces.Job{Queue: "mail", Payload: []byte("high"), Priority: 10})
+		enqueue(t, q, interfaces.Job{Queue: "sms", Payload: []byte("other queue")})
+
+		var order []string
+		handler := func(ctx context.Context, job interfaces.Job) error {
+			order = append(order, string(job.Payload))
+			return nil
+		}
+		for {
+			processed, err := q.ProcessOne(ctx, "mail", handler)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !processed {
+				break
+			}
+		}
+
+		if strings.Join(order, ",") != "high,low" {
+			t.Fatalf("unexpected order %v", order)
+		}
+	})
+
+	t.Run("delayed jobs wait for run at", func(t *testing.T) {
+		q, _, _ := newTestQueue(t)
+		now := time.Now()
+		q.now = func() time.Time { return now }
+
+		enqueue(t, q, interfaces.Job{Queue: "mail", RunAt: now.Add(time.Hour)})
+
+		noop := func(ctx context.Context, job interfaces.Job) error { return nil }
+		if processed, _ := q.ProcessOne(ctx, "mail", noop); processed {
+			t.Fatal("expected delayed job not to run yet")
+		}
+
+		now = now.Add(time.Hour)
+		if processed, err := q.ProcessOne(ctx, "mail", noop); !processed || err != nil {
+			t.Fatalf("expected delayed job to run, got %v (%v)", processed, err)
+		}
+	})
+
+	t.Run("retries and dead letters", func(t *testing.T) {
+		q, _, stderr := newTestQueue(t, WithBackoff(time.Minute, time.Hour))
+		now := time.Now()
+		q.now = func() time.Time { return now }
+
+		id := enqueue(t, q, interfaces.Job{Queue: "mail", MaxAttempts: 2, Payload: []byte("hi")})
+
+		var attempts []int
+		failing := func(ctx context.Context, job interfaces.Job) error {
+			attempts = append(attempts, job.Attempts)
+			return errors.New("smtp down")
+		}
+
+		q.ProcessOne(ctx, "mail", failing)
+		if processed, _ := q.ProcessOne(ctx, "mail", failing); processed {
+			t.Fatal("expected job to wait for backoff")
+		}
+
+		now = now.Add(time.Minute)
+		q.ProcessOne(ctx, "mail", failing)
+
+		if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+			t.Fatalf("unexpected attempts %v", attempts)
+		}
+		if !strings.Contains(stderr.String(), "is dead after 2 attempts") {
+			t.Fatalf("expected dead job log, got %q", stderr.String())
+		}
+
+		dead, err := q.Dead(ctx, "mail")
+		if err != nil {
+			t.Fatalf("unexpected error listing dead jobs: %v", err)
+		}
+		if len(dead) != 1 || dead[0].ID != id || dead[0].LastError != "smtp down" || string(dead[0].Payload) != "hi" {
+			t.Fatalf("unexpected dead jobs %+v", dead)
+		}
+
+		if err := q.Requeue(ctx, id); err != nil {
+			t.Fatalf("unexpected error requeueing: %v", err)
+		}
+		if err := q.Requeue(ctx, id); !errors.Is(err, interfaces.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound requeueing twice, got %v", err)
+		}
+
+		var got interfaces.Job
+		q.ProcessOne(ctx, "mail", func(ctx context.Context, job interfaces.Job) error {
+			got = job
+			return nil
+		})
+		if got.ID != id || got.Attempts != 1 {
+			t.Fatalf("expected requeued job with fresh attempts, got %+v", got)
+		}
+	})
+
+	t.Run("recovers handler panics", func(t *testing.T) {
+		q, _, _ := newTestQueue(t)
+		enqueue(t, q, interfaces.Job{Queue: "mail", MaxAttempts: 1})
+
+		_, err := q.ProcessOne(ctx, "mail", func(ctx context.Context, job interfaces.Job) error {
+			panic("boom")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		dead, _ := q.Dead(ctx, "mail")
+		if len(dead) != 1 || !strings.Contains(dead[0].LastError, ErrJobPanic.Error()) {
+			t.Fatalf("expected panicking job to be dead, got %+v", dead)
+		}
+	})
+
+	t.Run("visibility timeout releases stuck jobs", func(t *testing.T) {
+		q, _, _ := newTestQueue(t, WithVisibilityTimeout(time.Minute))
+		now := time.Now()
+		q.now = func() time.Time { return now }
+		id := enqueue(t, q, interfaces.Job{Queue: "mail"})
+
+		// Simulamos un worker que tomó el job y murió
+		if _, err := q.claim(ctx, "mail", "dead-worker"); err != nil {
+			t.Fatalf("unexpected error claiming: %v", err)
+		}
+
+		noop := func(ctx context.Context, job interfaces.Job) error { return nil }
+		if processed, _ := q.ProcessOne(ctx, "mail", noop); processed {
+			t.Fatal("expected claimed job to be hidden")
+		}
+
+		now = now.Add(time.Minute)
+		var got interfaces.Job
+		processed, err := q.ProcessOne(ctx, "mail", func(ctx context.Context, job interfaces.Job) error {
+			got = job
+			return nil
+		})
+		if !processed || err != nil || got.ID != id || got.Attempts != 2 {
+			t.Fatalf("expected job to be released, got %+v (%v)", got, err)
+		}
+	})
+
+	t.Run("enqueue joins transaction from context", func(t *testing.T) {
+		q, c, _ := newTestQueue(t)
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			if _, err := q.Enqueue(sqlhandler.ContextWithTx(ctx, tx), interfaces.Job{Queue: "mail"}); err != nil {
+				t.Fatalf("unexpected error enqueueing: %v", err)
+			}
+			return errors.New("rollback")
+		})
+
+		noop := func(ctx context.Context, job interfaces.Job) error { return nil }
+		if processed, _ := q.ProcessOne(ctx, "mail", noop); processed {
+			t.Fatal("expected rolled back job not to exist")
+		}
+	})
+
+	t.Run("process runs a worker pool", func(t *testing.T) {
+		q, _, _ := newTestQueue(t, WithConcurrency(3), WithPollInterval(5*time.Millisecond))
+		const total = 12
+		for range total {
+			enqueue(t, q, interfaces.Job{Queue: "mail"})
+		}
+
+		var done, running, peak atomic.Int32
+		var mu sync.Mutex
+		seen := map[int64]bool{}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		result := make(chan error, 1)
+		go func() {
+			result <- q.Process(runCtx, "mail", func(ctx context.Context, job interfaces.Job) error {
+				current := running.Add(1)
+				defer running.Add(-1)
+				for {
+					old := peak.Load()
+					if current <= old || peak.CompareAndSwap(old, current) {
+						break
+					}
+				}
+
+				mu.Lock()
+				seen[job.ID] = true
+				mu.Unlock()
+
+				time.Sleep(2 * time.Millisecond)
+				done.Add(1)
+				return nil
+			})
+		}()
+
+		deadline := time.After(5 * time.Second)
+		for done.Load() < total {
+			select {
+			case <-deadline:
+				t.Fatalf("expected %d jobs done, got %d", total, done.Load())
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+		cancel()
+		if err := <-result; err != nil {
+			t.Fatalf("unexpected error from Process: %v", err)
+		}
+
+		if len(seen) != total {
+			t.Fatalf("expected %d distinct jobs, got %d", total, len(seen))
+		}
+		if peak.Load() > 3 {
+			t.Fatalf("expected at most 3 concurrent jobs, got %d", peak.Load())
+		}
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		q, _, _ := newTestQueue(t)
+		if _, err := q.Enqueue(ctx, interfaces.Job{}); err == nil {
+			t.Fatal("expected error with empty queue")
+		}
+		if err := q.Process(ctx, "mail", nil); err == nil {
+			t.Fatal("expected error with nil handler")
+		}
+	})
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS jobqueue_dead;
+DROP INDEX IF EXISTS jobqueue_jobs_ready;
+DROP TABLE IF EXISTS jobqueue_jobs;
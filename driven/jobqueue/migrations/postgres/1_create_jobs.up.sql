@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS jobqueue_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    queue TEXT NOT NULL,
+    payload BYTEA,
+    priority INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at BIGINT NOT NULL,
+    locked_until BIGINT,
+    locked_by TEXT,
+    last_error TEXT,
+    created_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS jobqueue_jobs_ready ON jobqueue_jobs (queue, priority DESC, run_at);
+CREATE TABLE IF NOT EXISTS jobqueue_dead (
+    id BIGINT PRIMARY KEY,
+    queue TEXT NOT NULL,
+    payload BYTEA,
+    priority INTEGER NOT NULL,
+    attempts INTEGER NOT NULL,
+    max_attempts INTEGER NOT NULL,
+    last_error TEXT,
+    created_at BIGINT NOT NULL,
+    failed_at BIGINT NOT NULL
+);
@@ -0,0 +1,233 @@
+package jobqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// ErrJobPanic se registra como error del job cuando su handler hace panic.
+var ErrJobPanic = errors.New("panic inside job handler")
+
+// Process ejecuta handler sobre los jobs de queue con tantos workers como indique
+// WithConcurrency, hasta que ctx se cancele. Al cancelar deja de tomar jobs y espera
+// a que terminen los que están en curso.
+func (q *Queue) Process(ctx context.Context, queue string, handler interfaces.JobHandler) error {
+	if queue == "" {
+		return fmt.Errorf("%s: queue cannot be empty", SigJobQueue)
+	}
+	if handler == nil {
+		return fmt.Errorf("%s: handler cannot be nil", SigJobQueue)
+	}
+
+	var wg sync.WaitGroup
+	for range q.options.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, queue, handler)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (q *Queue) work(ctx context.Context, queue string, handler interfaces.JobHandler) {
+	for ctx.Err() == nil {
+		processed, err := q.ProcessOne(ctx, queue, handler)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(q.stderr, "%s: worker failed: %v", SigJobQueue, err)
+		}
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(q.options.pollInterval):
+		}
+	}
+}
+
+type jobRow struct {
+	ID          int64          `db:"id"`
+	Queue       string         `db:"queue"`
+	Payload     []byte         `db:"payload"`
+	Priority    int            `db:"priority"`
+	Attempts    int            `db:"attempts"`
+	MaxAttempts int            `db:"max_attempts"`
+	RunAt       int64          `db:"run_at"`
+	LastError   sql.NullString `db:"last_error"`
+}
+
+// ProcessOne toma el próximo job listo de queue y lo ejecuta. Retorna false si no
+// había jobs listos. Los errores del handler no se retornan, se registran en el job.
+func (q *Queue) ProcessOne(ctx context.Context, queue string, handler interfaces.JobHandler) (bool, error) {
+	token, err := newToken()
+	if err != nil {
+		return false, err
+	}
+
+	row, err := q.claim(ctx, queue, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	job := interfaces.Job{
+		ID:          row.ID,
+		Queue:       row.Queue,
+		Payload:     row.Payload,
+		Priority:    row.Priority,
+		RunAt:       time.Unix(0, row.RunAt),
+		MaxAttempts: row.MaxAttempts,
+		Attempts:    row.Attempts,
+		LastError:   row.LastError.String,
+	}
+
+	// El job en curso termina aunque ctx se cancele, pero nunca más allá de su
+	// visibility timeout, cuando otro worker podría tomarlo
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.options.visibility)
+	defer cancel()
+
+	if runErr := runJob(jobCtx, handler, job); runErr != nil {
+		return true, q.fail(context.WithoutCancel(ctx), row, token, runErr)
+	}
+	return true, q.complete(context.WithoutCancel(ctx), row, token)
+}
+
+func runJob(ctx context.Context, handler interfaces.JobHandler, job interfaces.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanic, r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// claim marca el próximo job listo como tomado por token en una sola sentencia.
+func (q *Queue) claim(ctx context.Context, queue, token string) (jobRow, error) {
+	lock := ""
+	if q.dialect == query.Dollar {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+
+	var row jobRow
+	err := sqlhandler.Retry(ctx, func() (err error) {
+		now := q.now()
+		row, err = sqlhandler.QueryOne[jobRow](ctx, q.db, q.dialect.Rebind(`
+            UPDATE jobqueue_jobs
+            SET locked_by = ?, locked_until = ?, attempts = attempts + 1
+            WHERE id = (
+                SELECT id FROM jobqueue_jobs
+                WHERE queue = ? AND run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)
+                ORDER BY priority DESC, run_at, id
+                LIMIT 1`+lock+`
+            )
+            RETURNING id, queue, payload, priority, attempts, max_attempts, run_at, last_error
+        `), token, now.Add(q.options.visibility).UnixNano(), queue, now.UnixNano(), now.UnixNano())
+		return err
+	})
+	return row, err
+}
+
+func (q *Queue) complete(ctx context.Context, row jobRow, token string) error {
+	var res sql.Result
+	err := sqlhandler.Retry(ctx, func() (err error) {
+		res, err = q.db.ExecContext(ctx, q.dialect.Rebind(`DELETE FROM jobqueue_jobs WHERE id = ? AND locked_by = ?`), row.ID, token)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to complete job %d: %w", SigJobQueue, row.ID, err)
+	}
+	q.checkLock(res, row.ID)
+	return nil
+}
+
+func (q *Queue) fail(ctx context.Context, row jobRow, token string, cause error) error {
+	now := q.now()
+
+	if row.Attempts < row.MaxAttempts {
+		var res sql.Result
+		err := sqlhandler.Retry(ctx, func() (err error) {
+			res, err = q.db.ExecContext(ctx, q.dialect.Rebind(`
+                UPDATE jobqueue_jobs
+                SET run_at = ?, locked_by = NULL, locked_until = NULL, last_error = ?
+                WHERE id = ? AND locked_by = ?
+            `), now.Add(q.delay(row.Attempts)).UnixNano(), cause.Error(), row.ID, token)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: failed to reschedule job %d: %w", SigJobQueue, row.ID, err)
+		}
+		q.checkLock(res, row.ID)
+		return nil
+	}
+
+	fmt.Fprintf(q.stderr, "%s: job %d in %s is dead after %d attempts: %v", SigJobQueue, row.ID, row.Queue, row.Attempts, cause)
+	return sqlhandler.Retry(ctx, func() error {
+		return q.bury(ctx, row, token, cause, now)
+	})
+}
+
+// bury mueve el job a la tabla de jobs muertos.
+func (q *Queue) bury(ctx context.Context, row jobRow, token string, cause error, now time.Time) error {
+	return sqlhandler.InTx(ctx, q.db, nil, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, q.dialect.Rebind(`
+            INSERT INTO jobqueue_dead (id, queue, payload, priority, attempts, max_attempts, last_error, created_at, failed_at)
+            SELECT id, queue, payload, priority, attempts, max_attempts, ?, created_at, ?
+            FROM jobqueue_jobs
+            WHERE id = ? AND locked_by = ?
+        `), cause.Error(), now.UnixNano(), row.ID, token)
+		if err != nil {
+			return fmt.Errorf("%s: failed to move job %d to dead jobs: %w", SigJobQueue, row.ID, err)
+		}
+		if !q.checkLock(res, row.ID) {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, q.dialect.Rebind(`DELETE FROM jobqueue_jobs WHERE id = ?`), row.ID); err != nil {
+			return fmt.Errorf("%s: failed to remove dead job %d: %w", SigJobQueue, row.ID, err)
+		}
+		return nil
+	})
+}
+
+// checkLock avisa cuando el job ya no estaba tomado por este worker, lo que pasa
+// si el handler superó el visibility timeout y otro worker lo tomó.
+func (q *Queue) checkLock(res sql.Result, id int64) bool {
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		fmt.Fprintf(q.stderr, "%s: lost lock on job %d, visibility timeout expired", SigJobQueue, id)
+		return false
+	}
+	return true
+}
+
+// delay calcula el backoff exponencial para el intento dado.
+func (q *Queue) delay(attempts int) time.Duration {
+	delay := q.options.backoff << (attempts - 1)
+	if delay <= 0 || delay > q.options.maxBackoff {
+		return q.options.maxBackoff
+	}
+	return delay
+}
+
+func newToken() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("%s: failed to generate lock token: %w", SigJobQueue, err)
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,215 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+type leaderOpts struct {
+	retryInterval   *time.Duration
+	refreshInterval *time.Duration
+	safetyMargin    *time.Duration
+}
+
+type LeaderOption func(options *leaderOpts)
+
+// WithRetryInterval establece cada cuánto un seguidor intenta tomar el liderazgo.
+// Por defecto es un tercio del ttl. Panics si d no es positivo.
+func WithRetryInterval(d time.Duration) LeaderOption {
+	return func(options *leaderOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: retry interval must be positive", SigLocker))
+		}
+		options.retryInterval = &d
+	}
+}
+
+// WithRefreshInterval establece cada cuánto el líder renueva su lease. Debe ser
+// bastante menor al ttl. Por defecto es un tercio del ttl. Panics si d no es positivo.
+func WithRefreshInterval(d time.Duration) LeaderOption {
+	return func(options *leaderOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: refresh interval must be positive", SigLocker))
+		}
+		options.refreshInterval = &d
+	}
+}
+
+// WithSafetyMargin establece cuánto antes de que expire el lease el líder deja de
+// actuar como tal si no logra renovarlo, para cubrir el desfase entre relojes y lo
+// que tarda fn en notar la cancelación. Por defecto es un décimo del ttl.
+// Panics si d es negativo.
+func WithSafetyMargin(d time.Duration) LeaderOption {
+	return func(options *leaderOpts) {
+		if d < 0 {
+			panic(fmt.Sprintf("%s: safety margin cannot be negative", SigLocker))
+		}
+		options.safetyMargin = &d
+	}
+}
+
+// clocked es implementado por SQLLocker y MemoryLocker, para que LeaderElection
+// compare la expiración de un lease con el mismo reloj que la calculó.
+type clocked interface {
+	clock() time.Time
+}
+
+func (l *SQLLocker) clock() time.Time    { return l.options.now() }
+func (m *MemoryLocker) clock() time.Time { return m.options.now() }
+
+// LeaderElection elige una sola réplica entre las que compiten por key.
+type LeaderElection struct {
+	stderr  io.Writer
+	locker  interfaces.Locker
+	key     string
+	ttl     time.Duration
+	options leaderOpts
+	now     func() time.Time
+	leader  atomic.Bool
+	token   atomic.Int64
+}
+
+func NewLeaderElection(stderr io.Writer, locker interfaces.Locker, key string, ttl time.Duration, opts ...LeaderOption) *LeaderElection {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigLocker))
+	}
+	if locker == nil {
+		panic(fmt.Sprintf("%s: locker cannot be nil", SigLocker))
+	}
+	if err := validate(key, ttl); err != nil {
+		panic(err.Error())
+	}
+
+	l := &LeaderElection{stderr: stderr, locker: locker, key: key, ttl: ttl, now: time.Now}
+	if c, ok := locker.(clocked); ok {
+		l.now = c.clock
+	}
+	for _, opt := range opts {
+		opt(&l.options)
+	}
+
+	third := ttl / 3
+	if l.options.retryInterval == nil {
+		l.options.retryInterval = &third
+	}
+	if l.options.refreshInterval == nil {
+		l.options.refreshInterval = &third
+	}
+	if l.options.safetyMargin == nil {
+		margin := ttl / 10
+		l.options.safetyMargin = &margin
+	}
+	if *l.options.refreshInterval >= ttl-*l.options.safetyMargin {
+		panic(fmt.Sprintf("%s: refresh interval must be lower than ttl minus the safety margin", SigLocker))
+	}
+
+	return l
+}
+
+// IsLeader indica si esta réplica tiene el liderazgo en este momento.
+func (l *LeaderElection) IsLeader() bool {
+	return l.leader.Load()
+}
+
+// Token retorna el fencing token del liderazgo actual, o 0 si no es líder.
+func (l *LeaderElection) Token() int64 {
+	return l.token.Load()
+}
+
+// Run compite por el liderazgo hasta que ctx se cancele. Mientras esta réplica es
+// líder ejecuta fn con un contexto que se cancela si pierde el lease. Cuando fn
+// termina sin error el lease se libera y la réplica vuelve a competir; si fn
+// retorna error, Run lo retorna.
+func (l *LeaderElection) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if fn == nil {
+		return fmt.Errorf("%s: leader function cannot be nil", SigLocker)
+	}
+
+	for {
+		lease, err := l.locker.Acquire(ctx, l.key, l.ttl)
+		switch {
+		case err == nil:
+			if err := l.lead(ctx, lease, fn); err != nil {
+				return err
+			}
+		case errors.Is(err, interfaces.ErrLockHeld):
+		case ctx.Err() == nil:
+			fmt.Fprintf(l.stderr, "%s: failed to campaign for %s: %v", SigLocker, l.key, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*l.options.retryInterval):
+		}
+	}
+}
+
+func (l *LeaderElection) lead(ctx context.Context, lease interfaces.Lease, fn func(ctx context.Context) error) error {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	l.token.Store(lease.Token)
+	l.leader.Store(true)
+	defer func() {
+		l.leader.Store(false)
+		l.token.Store(0)
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(leaderCtx) }()
+
+	ticker := time.NewTicker(*l.options.refreshInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(l.untilStepDown(lease))
+	defer deadline.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			// Liberamos con un contexto propio para no retener el lock si ctx ya terminó
+			releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), l.ttl)
+			defer releaseCancel()
+			if relErr := l.locker.Release(releaseCtx, lease); relErr != nil && !errors.Is(relErr, interfaces.ErrLockLost) {
+				fmt.Fprintf(l.stderr, "%s: failed to release %s: %v", SigLocker, l.key, relErr)
+			}
+			return err
+		case <-deadline.C:
+			return l.stepDown(cancel, done, fmt.Errorf("lease not refreshed before %s", lease.ExpiresAt))
+		case <-ticker.C:
+			refreshed, err := l.locker.Refresh(leaderCtx, lease, l.ttl)
+			if err == nil {
+				lease = refreshed
+				deadline.Reset(l.untilStepDown(lease))
+				continue
+			}
+
+			// Dejamos de actuar como líder antes de que el lease expire y otra réplica lo tome
+			if !errors.Is(err, interfaces.ErrLockLost) && l.untilStepDown(lease) > 0 {
+				fmt.Fprintf(l.stderr, "%s: failed to refresh %s, retrying: %v", SigLocker, l.key, err)
+				continue
+			}
+			return l.stepDown(cancel, done, err)
+		}
+	}
+}
+
+// untilStepDown retorna cuánto falta para dejar el liderazgo si lease no se renueva.
+func (l *LeaderElection) untilStepDown(lease interfaces.Lease) time.Duration {
+	return lease.ExpiresAt.Add(-*l.options.safetyMargin).Sub(l.now())
+}
+
+// stepDown deja de reportar liderazgo, cancela fn y espera a que termine.
+func (l *LeaderElection) stepDown(cancel context.CancelFunc, done <-chan error, cause error) error {
+	fmt.Fprintf(l.stderr, "%s: lost leadership of %s: %v", SigLocker, l.key, cause)
+	l.leader.Store(false)
+	cancel()
+	<-done
+	return nil
+}
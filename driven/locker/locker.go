@@ -0,0 +1,74 @@
+package locker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+const SigLocker string = "locker"
+
+type lockerOpts struct {
+	owner *string
+	now   func() time.Time
+}
+
+type LockerOption func(options *lockerOpts)
+
+// WithOwner establece el nombre con que este proceso toma los locks. Por defecto
+// se usa el hostname, el pid y un sufijo aleatorio. Panics si owner está vacío.
+func WithOwner(owner string) LockerOption {
+	return func(options *lockerOpts) {
+		if owner == "" {
+			panic(fmt.Sprintf("%s: owner cannot be empty", SigLocker))
+		}
+		options.owner = &owner
+	}
+}
+
+// WithClock reemplaza el reloj usado para calcular expiraciones. Útil en tests.
+// Panics si now es nil.
+func WithClock(now func() time.Time) LockerOption {
+	return func(options *lockerOpts) {
+		if now == nil {
+			panic(fmt.Sprintf("%s: clock cannot be nil", SigLocker))
+		}
+		options.now = now
+	}
+}
+
+func newOpts(opts []LockerOption) lockerOpts {
+	options := lockerOpts{now: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.owner == nil {
+		owner := defaultOwner()
+		options.owner = &owner
+	}
+	return options
+}
+
+func defaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	raw := make([]byte, 4)
+	if _, err := rand.Read(raw); err != nil {
+		panic(fmt.Sprintf("%s: failed to generate owner: %v", SigLocker, err))
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(raw))
+}
+
+func validate(key string, ttl time.Duration) error {
+	if key == "" {
+		return fmt.Errorf("%s: key cannot be empty", SigLocker)
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("%s: ttl must be positive, got %s", SigLocker, ttl)
+	}
+	return nil
+}
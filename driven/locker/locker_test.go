@@ -0,0 +1,360 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/interfaces"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// clock es un reloj manual compartido por las réplicas de un test.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// lockerFactory crea dos réplicas que compiten por los mismos locks.
+type lockerFactory func(t *testing.T, c *clock) (a, b interfaces.Locker)
+
+func newSQLLockers(t *testing.T, c *clock) (interfaces.Locker, interfaces.Locker) {
+	dbURL := "file:" + filepath.Join(t.TempDir(), "test.db")
+	conn := sqlhandler.NewConnector(&strings.Builder{}, sqlhandler.WithURL(dbURL))
+	if err := conn.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	a := NewSQL(&strings.Builder{}, conn.DB(), "libsql", WithOwner("a"), WithClock(c.Now))
+	if err := a.Migrate(); err != nil {
+		t.Fatalf("unexpected error migrating: %v", err)
+	}
+	b := NewSQL(&strings.Builder{}, conn.DB(), "libsql", WithOwner("b"), WithClock(c.Now))
+	return a, b
+}
+
+func newMemoryLockers(t *testing.T, c *clock) (interfaces.Locker, interfaces.Locker) {
+	a := NewMemory(WithOwner("a"), WithClock(c.Now))
+	return a, a.Replica("b")
+}
+
+func TestLockers(t *testing.T) {
+	factories := map[string]lockerFactory{
+		"sql":    newSQLLockers,
+		"memory": newMemoryLockers,
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			testLocker(t, factory)
+		})
+	}
+}
+
+func testLocker(t *testing.T, factory lockerFactory) {
+	ctx := context.Background()
+
+	t.Run("only one owner holds a key", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a, b := factory(t, c)
+
+		lease, err := a.Acquire(ctx, "cron", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error acquiring: %v", err)
+		}
+		if lease.Owner != "a" || lease.Token != 1 || !lease.ExpiresAt.Equal(c.Now().Add(time.Minute)) {
+			t.Fatalf("unexpected lease %+v", lease)
+		}
+
+		if _, err := b.Acquire(ctx, "cron", time.Minute); !errors.Is(err, interfaces.ErrLockHeld) {
+			t.Fatalf("expected ErrLockHeld, got %v", err)
+		}
+		if _, err := a.Acquire(ctx, "cron", time.Minute); !errors.Is(err, interfaces.ErrLockHeld) {
+			t.Fatalf("expected ErrLockHeld for the same owner, got %v", err)
+		}
+		if _, err := b.Acquire(ctx, "other", time.Minute); err != nil {
+			t.Fatalf("unexpected error acquiring other key: %v", err)
+		}
+	})
+
+	t.Run("expired leases can be taken with a higher token", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a, b := factory(t, c)
+
+		first, _ := a.Acquire(ctx, "cron", time.Minute)
+		c.Advance(time.Minute)
+
+		second, err := b.Acquire(ctx, "cron", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error acquiring expired lock: %v", err)
+		}
+		if second.Token <= first.Token {
+			t.Fatalf("expected fencing token to grow, got %d after %d", second.Token, first.Token)
+		}
+
+		if _, err := a.Refresh(ctx, first, time.Minute); !errors.Is(err, interfaces.ErrLockLost) {
+			t.Fatalf("expected ErrLockLost refreshing stale lease, got %v", err)
+		}
+		if err := a.Release(ctx, first); !errors.Is(err, interfaces.ErrLockLost) {
+			t.Fatalf("expected ErrLockLost releasing stale lease, got %v", err)
+		}
+	})
+
+	t.Run("refresh extends the lease", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a, b := factory(t, c)
+
+		lease, _ := a.Acquire(ctx, "cron", time.Minute)
+		c.Advance(50 * time.Second)
+
+		lease, err := a.Refresh(ctx, lease, time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error refreshing: %v", err)
+		}
+		c.Advance(50 * time.Second)
+
+		if _, err := b.Acquire(ctx, "cron", time.Minute); !errors.Is(err, interfaces.ErrLockHeld) {
+			t.Fatalf("expected refreshed lock to be held, got %v", err)
+		}
+		if !lease.ExpiresAt.After(c.Now()) {
+			t.Fatalf("expected lease to expire in the future, got %v", lease.ExpiresAt)
+		}
+	})
+
+	t.Run("release frees the key and keeps the token", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a, b := factory(t, c)
+
+		first, _ := a.Acquire(ctx, "cron", time.Minute)
+		if err := a.Release(ctx, first); err != nil {
+			t.Fatalf("unexpected error releasing: %v", err)
+		}
+
+		second, err := b.Acquire(ctx, "cron", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error acquiring released lock: %v", err)
+		}
+		if second.Token != first.Token+1 {
+			t.Fatalf("expected token %d, got %d", first.Token+1, second.Token)
+		}
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a, _ := factory(t, c)
+
+		if _, err := a.Acquire(ctx, "", time.Minute); err == nil {
+			t.Fatal("expected error with empty key")
+		}
+		if _, err := a.Acquire(ctx, "cron", 0); err == nil {
+			t.Fatal("expected error with zero ttl")
+		}
+	})
+}
+
+func TestLeaderElection(t *testing.T) {
+	t.Run("only one replica leads", func(t *testing.T) {
+		a := NewMemory(WithOwner("a"))
+		replicas := []interfaces.Locker{a, a.Replica("b"), a.Replica("c")}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var leading, peak, runs atomic.Int32
+		var wg sync.WaitGroup
+
+		for _, locker := range replicas {
+			election := NewLeaderElection(&strings.Builder{}, locker, "cron", 100*time.Millisecond, WithRetryInterval(5*time.Millisecond))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := election.Run(ctx, func(ctx context.Context) error {
+					if n := leading.Add(1); n > peak.Load() {
+						peak.Store(n)
+					}
+					runs.Add(1)
+					time.Sleep(10 * time.Millisecond)
+					leading.Add(-1)
+					return nil
+				})
+				if err != nil {
+					t.Errorf("unexpected error from Run: %v", err)
+				}
+			}()
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		cancel()
+		wg.Wait()
+
+		if peak.Load() != 1 {
+			t.Fatalf("expected exactly one leader at a time, got %d", peak.Load())
+		}
+		if runs.Load() < 2 {
+			t.Fatalf("expected leadership to rotate after release, got %d runs", runs.Load())
+		}
+	})
+
+	t.Run("leader keeps the lease while running", func(t *testing.T) {
+		a := NewMemory(WithOwner("a"))
+		b := a.Replica("b")
+		election := NewLeaderElection(&strings.Builder{}, a, "cron", 30*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		elected := make(chan struct{})
+		result := make(chan error, 1)
+		go func() {
+			result <- election.Run(ctx, func(ctx context.Context) error {
+				close(elected)
+				<-ctx.Done()
+				return nil
+			})
+		}()
+		<-elected
+
+		// Esperamos más que el ttl, el lease debe seguir vigente gracias a Refresh
+		time.Sleep(80 * time.Millisecond)
+		if _, err := b.Acquire(context.Background(), "cron", time.Second); !errors.Is(err, interfaces.ErrLockHeld) {
+			t.Fatalf("expected lease to be refreshed, got %v", err)
+		}
+		if !election.IsLeader() || election.Token() == 0 {
+			t.Fatal("expected election to report leadership")
+		}
+
+		cancel()
+		if err := <-result; err != nil {
+			t.Fatalf("unexpected error from Run: %v", err)
+		}
+		if election.IsLeader() {
+			t.Fatal("expected leadership to end after cancel")
+		}
+		if _, err := b.Acquire(context.Background(), "cron", time.Second); err != nil {
+			t.Fatalf("expected lease released after Run, got %v", err)
+		}
+	})
+
+	t.Run("losing the lease cancels the leader", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a := NewMemory(WithOwner("a"), WithClock(c.Now))
+		b := a.Replica("b")
+		stderr := &lockedWriter{}
+		election := NewLeaderElection(stderr, a, "cron", time.Minute, WithRefreshInterval(5*time.Millisecond), WithRetryInterval(time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		elected := make(chan struct{})
+		stopped := make(chan struct{})
+		go election.Run(ctx, func(ctx context.Context) error {
+			close(elected)
+			<-ctx.Done()
+			close(stopped)
+			return nil
+		})
+		<-elected
+
+		// Otra réplica toma el lock tras la expiración, por ejemplo por una pausa larga
+		c.Advance(2 * time.Minute)
+		if _, err := b.Acquire(context.Background(), "cron", time.Hour); err != nil {
+			t.Fatalf("unexpected error stealing expired lock: %v", err)
+		}
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("expected leader function to be canceled")
+		}
+		if !strings.Contains(stderr.String(), "lost leadership of cron") {
+			t.Fatalf("expected lost leadership log, got %q", stderr.String())
+		}
+	})
+
+	t.Run("steps down before the lease expires", func(t *testing.T) {
+		c := &clock{now: time.Now()}
+		a := &unreachableLocker{NewMemory(WithOwner("a"), WithClock(c.Now))}
+		stderr := &lockedWriter{}
+		election := NewLeaderElection(stderr, a, "cron", time.Minute, WithRefreshInterval(5*time.Millisecond), WithRetryInterval(time.Hour))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		elected := make(chan struct{})
+		stopped := make(chan struct{})
+		go election.Run(ctx, func(ctx context.Context) error {
+			close(elected)
+			<-ctx.Done()
+			close(stopped)
+			return nil
+		})
+		<-elected
+
+		// El lease sigue vigente según el reloj del locker, pero dentro del margen
+		c.Advance(55 * time.Second)
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("expected leader to step down within the safety margin")
+		}
+		if election.IsLeader() {
+			t.Fatal("expected election to stop reporting leadership")
+		}
+		if !strings.Contains(stderr.String(), "lost leadership of cron") {
+			t.Fatalf("expected lost leadership log, got %q", stderr.String())
+		}
+	})
+
+	t.Run("returns leader errors", func(t *testing.T) {
+		election := NewLeaderElection(&strings.Builder{}, NewMemory(), "cron", time.Second)
+		errBoom := errors.New("boom")
+		err := election.Run(context.Background(), func(ctx context.Context) error { return errBoom })
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected boom error, got %v", err)
+		}
+	})
+}
+
+// unreachableLocker simula una base de datos caída: Refresh siempre falla con un
+// error que no indica la pérdida del lock.
+type unreachableLocker struct {
+	*MemoryLocker
+}
+
+func (l *unreachableLocker) Refresh(ctx context.Context, lease interfaces.Lease, ttl time.Duration) (interfaces.Lease, error) {
+	return interfaces.Lease{}, errors.New("connection refused")
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.Write(p)
+}
+
+func (w *lockedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.String()
+}
@@ -0,0 +1,117 @@
+package locker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+type memoryLease struct {
+	owner     string
+	token     int64
+	expiresAt time.Time
+}
+
+// memoryLeases es el estado compartido entre las réplicas de un MemoryLocker.
+type memoryLeases struct {
+	mu     sync.Mutex
+	leases map[string]*memoryLease
+}
+
+// MemoryLocker implementa interfaces.Locker en memoria con las mismas reglas que
+// SQLLocker. Útil en tests; con Replica se simulan varios procesos.
+type MemoryLocker struct {
+	state   *memoryLeases
+	options lockerOpts
+}
+
+var _ interfaces.Locker = (*MemoryLocker)(nil)
+
+func NewMemory(opts ...LockerOption) *MemoryLocker {
+	return &MemoryLocker{
+		state:   &memoryLeases{leases: map[string]*memoryLease{}},
+		options: newOpts(opts),
+	}
+}
+
+// Replica retorna un Locker que comparte los leases de m pero toma los locks como owner.
+// Panics si owner está vacío.
+func (m *MemoryLocker) Replica(owner string) *MemoryLocker {
+	options := m.options
+	WithOwner(owner)(&options)
+	return &MemoryLocker{state: m.state, options: options}
+}
+
+// Owner retorna el nombre con que este Locker toma los locks.
+func (m *MemoryLocker) Owner() string {
+	return *m.options.owner
+}
+
+func (m *MemoryLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (interfaces.Lease, error) {
+	if err := validate(key, ttl); err != nil {
+		return interfaces.Lease{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return interfaces.Lease{}, err
+	}
+
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	now := m.options.now()
+	owner := *m.options.owner
+
+	lease, ok := m.state.leases[key]
+	if !ok {
+		lease = &memoryLease{}
+		m.state.leases[key] = lease
+	}
+	if lease.expiresAt.After(now) {
+		return interfaces.Lease{}, fmt.Errorf("%s: %s: %w", SigLocker, key, interfaces.ErrLockHeld)
+	}
+
+	lease.owner = owner
+	lease.token++
+	lease.expiresAt = now.Add(ttl)
+
+	return interfaces.Lease{Key: key, Owner: owner, Token: lease.token, ExpiresAt: lease.expiresAt}, nil
+}
+
+func (m *MemoryLocker) Refresh(ctx context.Context, lease interfaces.Lease, ttl time.Duration) (interfaces.Lease, error) {
+	if err := validate(lease.Key, ttl); err != nil {
+		return interfaces.Lease{}, err
+	}
+
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	now := m.options.now()
+	current, ok := m.state.leases[lease.Key]
+	if !ok || !current.matches(lease) || !current.expiresAt.After(now) {
+		return interfaces.Lease{}, fmt.Errorf("%s: %s: %w", SigLocker, lease.Key, interfaces.ErrLockLost)
+	}
+
+	current.expiresAt = now.Add(ttl)
+	lease.ExpiresAt = current.expiresAt
+	return lease, nil
+}
+
+func (m *MemoryLocker) Release(ctx context.Context, lease interfaces.Lease) error {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	current, ok := m.state.leases[lease.Key]
+	if !ok || !current.matches(lease) {
+		return fmt.Errorf("%s: %s: %w", SigLocker, lease.Key, interfaces.ErrLockLost)
+	}
+
+	current.expiresAt = time.Time{}
+	return nil
+}
+
+func (l *memoryLease) matches(lease interfaces.Lease) bool {
+	return l.owner == lease.Owner && l.token == lease.Token
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS locker_leases (
+    lock_key TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    token BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL
+);
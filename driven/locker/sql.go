@@ -0,0 +1,149 @@
+package locker
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// MigrationTable registra las versiones aplicadas del esquema de locker_leases.
+const MigrationTable string = "locker_migrations"
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// SQLLocker guarda los leases en la tabla locker_leases. La fila de cada llave se
+// mantiene al liberar el lock para que su fencing token nunca retroceda. Las
+// expiraciones se calculan con el reloj de cada proceso, por lo que los relojes de
+// las réplicas deben estar sincronizados con una precisión menor al ttl.
+type SQLLocker struct {
+	stderr  io.Writer
+	db      *sql.DB
+	dialect query.Dialect
+	options lockerOpts
+}
+
+var _ interfaces.Locker = (*SQLLocker)(nil)
+
+// NewSQL crea un Locker sobre db. driver es el nombre registrado en database/sql,
+// por ejemplo "libsql" o "pgx", y decide los placeholders de las consultas.
+func NewSQL(stderr io.Writer, db *sql.DB, driver string, opts ...LockerOption) *SQLLocker {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigLocker))
+	}
+	if db == nil {
+		panic(fmt.Sprintf("%s: db cannot be nil", SigLocker))
+	}
+
+	return &SQLLocker{stderr: stderr, db: db, dialect: query.DialectFor(driver), options: newOpts(opts)}
+}
+
+// Owner retorna el nombre con que este Locker toma los locks.
+func (l *SQLLocker) Owner() string {
+	return *l.options.owner
+}
+
+// Migrate crea locker_leases si no existe. El esquema es el mismo en SQLite/libsql
+// y Postgres.
+func (l *SQLLocker) Migrate() error {
+	if err := sqlhandler.MigrateFS(l.stderr, l.db, migrationFiles, "migrations", MigrationTable, l.dialect == query.Dollar); err != nil {
+		return fmt.Errorf("%s: %w", SigLocker, err)
+	}
+	return nil
+}
+
+// Acquire toma key por ttl si está libre o expirada. Retorna
+// interfaces.ErrLockHeld si tiene un lease vigente, aunque sea de este dueño.
+func (l *SQLLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (interfaces.Lease, error) {
+	if err := validate(key, ttl); err != nil {
+		return interfaces.Lease{}, err
+	}
+
+	now := l.options.now()
+	expiresAt := now.Add(ttl)
+	owner := *l.options.owner
+
+	var token int64
+	err := sqlhandler.Retry(ctx, func() error {
+		return l.db.QueryRowContext(ctx, l.dialect.Rebind(`
+            INSERT INTO locker_leases (lock_key, owner, token, expires_at)
+            VALUES (?, ?, 1, ?)
+            ON CONFLICT (lock_key) DO UPDATE
+            SET owner = excluded.owner, token = locker_leases.token + 1, expires_at = excluded.expires_at
+            WHERE locker_leases.expires_at <= ?
+            RETURNING token
+        `), key, owner, expiresAt.UnixNano(), now.UnixNano()).Scan(&token)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return interfaces.Lease{}, fmt.Errorf("%s: %s: %w", SigLocker, key, interfaces.ErrLockHeld)
+	}
+	if err != nil {
+		return interfaces.Lease{}, fmt.Errorf("%s: failed to acquire %s: %w", SigLocker, key, err)
+	}
+
+	return interfaces.Lease{Key: key, Owner: owner, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Refresh extiende lease por ttl desde ahora. Retorna interfaces.ErrLockLost si el
+// lease ya expiró o la llave fue tomada de nuevo.
+func (l *SQLLocker) Refresh(ctx context.Context, lease interfaces.Lease, ttl time.Duration) (interfaces.Lease, error) {
+	if err := validate(lease.Key, ttl); err != nil {
+		return interfaces.Lease{}, err
+	}
+
+	now := l.options.now()
+	expiresAt := now.Add(ttl)
+
+	var res sql.Result
+	err := sqlhandler.Retry(ctx, func() (err error) {
+		res, err = l.db.ExecContext(ctx, l.dialect.Rebind(`
+            UPDATE locker_leases SET expires_at = ?
+            WHERE lock_key = ? AND owner = ? AND token = ? AND expires_at > ?
+        `), expiresAt.UnixNano(), lease.Key, lease.Owner, lease.Token, now.UnixNano())
+		return err
+	})
+	if err != nil {
+		return interfaces.Lease{}, fmt.Errorf("%s: failed to refresh %s: %w", SigLocker, lease.Key, err)
+	}
+	if err := checkAffected(res, lease.Key); err != nil {
+		return interfaces.Lease{}, err
+	}
+
+	lease.ExpiresAt = expiresAt
+	return lease, nil
+}
+
+// Release libera lease. Retorna interfaces.ErrLockLost si la llave ya tenía otro lease.
+func (l *SQLLocker) Release(ctx context.Context, lease interfaces.Lease) error {
+	var res sql.Result
+	err := sqlhandler.Retry(ctx, func() (err error) {
+		res, err = l.db.ExecContext(ctx, l.dialect.Rebind(`
+            UPDATE locker_leases SET expires_at = 0
+            WHERE lock_key = ? AND owner = ? AND token = ?
+        `), lease.Key, lease.Owner, lease.Token)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to release %s: %w", SigLocker, lease.Key, err)
+	}
+	return checkAffected(res, lease.Key)
+}
+
+func checkAffected(res sql.Result, key string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check lease %s: %w", SigLocker, key, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %s: %w", SigLocker, key, interfaces.ErrLockLost)
+	}
+	return nil
+}
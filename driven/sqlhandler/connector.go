@@ -1,19 +1,50 @@
 package sqlhandler
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
+	"sync"
 )
 
 type connOpts struct {
-	url *string
+	url     *string
+	dsn     *DSN
+	txRetry *txPolicy
+	instr   *Instrumentation
+	driver  *string
+}
+
+func (o *connOpts) txPolicy() txPolicy {
+	if o.txRetry == nil {
+		return defaultTxPolicy
+	}
+	return *o.txRetry
+}
+
+// source retorna la DSN configurada, priorizando la entregada con WithDSN.
+func (o *connOpts) source() (*DSN, error) {
+	if o.dsn != nil {
+		return o.dsn, nil
+	}
+	if o.url == nil {
+		return nil, fmt.Errorf("%s: database url is not configured", SigConn)
+	}
+	return newRawDSN(*o.url), nil
 }
 
 type Connector struct {
 	stderr  io.Writer
 	db      *sql.DB
 	options connOpts
+
+	lifeMu         sync.Mutex
+	shuttingDown   bool
+	active         map[uint64]context.CancelCauseFunc
+	nextTxID       uint64
+	drained        chan struct{}
+	shutdownReport *ShutdownReport
 }
 
 const SigConn string = "sqlhandler connector"
@@ -39,29 +70,50 @@ func (c *Connector) Connect(driver string) error {
 		return fmt.Errorf("%s: cannot create new connection: database connection already exists", SigConn)
 	}
 
-	fmt.Fprintf(c.stderr, "%s: connecting to url", SigConn)
+	dsn, err := c.options.source()
+	if err != nil {
+		return err
+	}
+
+	url, err := dsn.Resolve()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stderr, "%s: connecting to url %s", SigConn, dsn)
 
-	db, err := sql.Open(driver, *c.options.url)
+	db, err := sql.Open(driver, url)
 	if err != nil {
-		return fmt.Errorf("%s: failed to open connection with driver %s: %v", SigConn, driver, err)
+		return fmt.Errorf("%s: failed to open connection with driver %s: %v", SigConn, driver, dsn.Redact(err.Error()))
+	}
+
+	if c.options.instr != nil {
+		// sql.Open no abre conexiones, solo la usamos para obtener el driver registrado
+		connector, err := c.options.instr.wrap(db.Driver(), url)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s: failed to instrument driver %s: %v", SigConn, driver, dsn.Redact(err.Error()))
+		}
+		db = sql.OpenDB(connector)
 	}
 
 	fmt.Fprintf(c.stderr, "%s: ping to db connection", SigConn)
 	if err = db.Ping(); err != nil {
 		db.Close() // Cerramos la conexión si el ping falla
-		return fmt.Errorf("%s: failed to ping database with driver %s: %v", SigConn, driver, err)
+		return fmt.Errorf("%s: failed to ping database with driver %s: %v", SigConn, driver, dsn.Redact(err.Error()))
 	}
 	fmt.Fprintf(c.stderr, "%s: connected succesfully", SigConn)
 
 	c.db = db
+	c.resetLifecycle()
 	return nil
 }
 
-// Close cierra la conexión a la base de datos.(
-// Panic si se intenta cerrar una conexión nil, ya que esto representa un error de programación.
+// Close cierra la conexión a la base de datos de inmediato, sin esperar las
+// transacciones en curso. No hace nada si la conexión ya está cerrada.
 func (c *Connector) Close() error {
 	if c.db == nil {
-		panic(fmt.Sprintf("%s: database connection is nil", SigConn))
+		return nil
 	}
 	fmt.Fprintf(c.stderr, "%s: closing connection to current connection", SigConn)
 	err := c.db.Close()
@@ -0,0 +1,295 @@
+package sqlhandler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type DSNOption func(options *dsnOpts)
+
+type dsnOpts struct {
+	secretFile *string
+	secretFunc func() (string, error)
+}
+
+// secretPlace indica en qué parte de la URL vive el secreto de cada driver.
+type secretPlace int
+
+const (
+	secretNone secretPlace = iota
+	secretPassword
+	secretAuthToken
+)
+
+// dsnSchemes son los esquemas soportados y dónde se inyecta su secreto.
+var dsnSchemes = map[string]secretPlace{
+	"file":       secretNone,
+	"libsql":     secretAuthToken,
+	"http":       secretAuthToken,
+	"https":      secretAuthToken,
+	"ws":         secretAuthToken,
+	"wss":        secretAuthToken,
+	"postgres":   secretPassword,
+	"postgresql": secretPassword,
+}
+
+// sensitiveParams son los parámetros que nunca deben aparecer en logs. Se
+// comparan sin distinguir mayúsculas.
+var sensitiveParams = []string{"authtoken", "auth_token", "password", "token"}
+
+var (
+	// keywordDSNRX reconoce las DSN key=value, como "host=db password=x" de libpq.
+	keywordDSNRX = regexp.MustCompile(`^\s*[A-Za-z_][A-Za-z0-9_]*\s*=`)
+	// keywordSecretRX captura el valor de un parámetro sensible en una DSN
+	// key=value, entre comillas simples o hasta el siguiente espacio.
+	keywordSecretRX = regexp.MustCompile(`(?i)((?:^|\s)(?:` + strings.Join(sensitiveParams, "|") + `)\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+	schemeRX        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+)
+
+const redactedSecret = "xxxxx"
+
+// DSN representa la URL de conexión a una base de datos. Su método String
+// nunca expone credenciales, por lo que es seguro usarlo en logs y errores.
+type DSN struct {
+	raw      string
+	url      *url.URL
+	keywords bool
+	place    secretPlace
+	options  dsnOpts
+
+	mu      sync.Mutex
+	secrets []string
+}
+
+// WithSecretFile lee el secreto (password o authToken según el driver) desde un archivo
+// en cada conexión, útil para secrets de Docker o Kubernetes.
+// Panics si el path está vacío.
+func WithSecretFile(path string) DSNOption {
+	return func(options *dsnOpts) {
+		if path == "" {
+			panic(fmt.Sprintf("%s: secret file path cannot be empty", SigConn))
+		}
+		options.secretFile = &path
+	}
+}
+
+// WithSecretFunc obtiene el secreto desde un callback en cada conexión,
+// permitiendo rotar tokens sin reiniciar la aplicación.
+// Panics si fn es nil.
+func WithSecretFunc(fn func() (string, error)) DSNOption {
+	return func(options *dsnOpts) {
+		if fn == nil {
+			panic(fmt.Sprintf("%s: secret func cannot be nil", SigConn))
+		}
+		options.secretFunc = fn
+	}
+}
+
+// ParseDSN parsea una URL de conexión para alguno de los drivers soportados.
+// Los errores retornados nunca contienen la URL original.
+func ParseDSN(raw string, opts ...DSNOption) (*DSN, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("%s: dsn cannot be empty", SigConn)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url.Error incluye la URL completa, solo exponemos la causa
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("%s: failed to parse dsn: %v", SigConn, err)
+	}
+
+	place, ok := dsnSchemes[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported dsn scheme %q", SigConn, u.Scheme)
+	}
+
+	d := &DSN{raw: raw, url: u, place: place}
+	for _, opt := range opts {
+		opt(&d.options)
+	}
+
+	if d.options.secretFile != nil && d.options.secretFunc != nil {
+		return nil, fmt.Errorf("%s: secret file and secret func are mutually exclusive", SigConn)
+	}
+	if d.hasSecretSource() && place == secretNone {
+		return nil, fmt.Errorf("%s: dsn scheme %q does not accept secrets", SigConn, u.Scheme)
+	}
+
+	d.rememberURLSecrets()
+	return d, nil
+}
+
+// newRawDSN envuelve una URL sin validar su esquema, se usa para las URLs
+// entregadas con WithURL que pueden tener formatos propios de cada driver.
+// Reconoce URLs y DSN key=value; cualquier otro formato se trata entero como
+// secreto.
+func newRawDSN(raw string) *DSN {
+	d := &DSN{raw: raw}
+	switch {
+	case keywordDSNRX.MatchString(raw):
+		d.keywords = true
+		for _, m := range keywordSecretRX.FindAllStringSubmatch(raw, -1) {
+			d.remember(strings.Trim(m[2], "'"))
+		}
+		return d
+	case strings.Contains(raw, "://") || strings.HasPrefix(strings.ToLower(raw), "file:"):
+		if u, err := url.Parse(raw); err == nil {
+			d.url = u
+			d.rememberURLSecrets()
+			return d
+		}
+	}
+	d.remember(raw)
+	return d
+}
+
+func (d *DSN) hasSecretSource() bool {
+	return d.options.secretFile != nil || d.options.secretFunc != nil
+}
+
+// Resolve retorna la URL completa, con el secreto inyectado si existe una fuente externa.
+// El secreto se vuelve a leer en cada llamada.
+func (d *DSN) Resolve() (string, error) {
+	if !d.hasSecretSource() {
+		return d.raw, nil
+	}
+
+	secret, err := d.readSecret()
+	if err != nil {
+		return "", err
+	}
+	if secret == "" {
+		return "", fmt.Errorf("%s: dsn secret is empty", SigConn)
+	}
+	d.remember(secret)
+
+	u := *d.url
+	switch d.place {
+	case secretPassword:
+		username := ""
+		if u.User != nil {
+			username = u.User.Username()
+		}
+		u.User = url.UserPassword(username, secret)
+	case secretAuthToken:
+		q := u.Query()
+		q.Set("authToken", secret)
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
+
+func (d *DSN) readSecret() (string, error) {
+	if d.options.secretFunc != nil {
+		secret, err := d.options.secretFunc()
+		if err != nil {
+			return "", fmt.Errorf("%s: failed to get dsn secret: %w", SigConn, err)
+		}
+		return strings.TrimSpace(secret), nil
+	}
+
+	content, err := os.ReadFile(*d.options.secretFile)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to read dsn secret file: %w", SigConn, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// String retorna la URL con password y tokens reemplazados, segura para logs.
+// Si la URL no se pudo parsear solo muestra su esquema.
+func (d *DSN) String() string {
+	if d.keywords {
+		return keywordSecretRX.ReplaceAllString(d.raw, "${1}"+redactedSecret)
+	}
+	if d.url == nil {
+		if scheme, _, ok := strings.Cut(d.raw, "://"); ok && schemeRX.MatchString(scheme) {
+			return scheme + "://" + redactedSecret
+		}
+		return redactedSecret
+	}
+
+	u := *d.url
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redactedSecret)
+		}
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for param := range q {
+			if isSensitiveParam(param) {
+				q.Set(param, redactedSecret)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
+
+// Redact reemplaza en s cualquier secreto conocido de la DSN, útil para limpiar
+// mensajes de error de los drivers antes de retornarlos.
+func (d *DSN) Redact(s string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, secret := range d.secrets {
+		s = strings.ReplaceAll(s, secret, redactedSecret)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			s = strings.ReplaceAll(s, escaped, redactedSecret)
+		}
+	}
+	return s
+}
+
+func (d *DSN) rememberURLSecrets() {
+	if d.url.User != nil {
+		if password, ok := d.url.User.Password(); ok {
+			d.remember(password)
+		}
+	}
+
+	for param, values := range d.url.Query() {
+		if !isSensitiveParam(param) {
+			continue
+		}
+		for _, value := range values {
+			d.remember(value)
+		}
+	}
+}
+
+func isSensitiveParam(param string) bool {
+	for _, p := range sensitiveParams {
+		if strings.EqualFold(p, param) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DSN) remember(secret string) {
+	if secret == "" {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, s := range d.secrets {
+		if s == secret {
+			return
+		}
+	}
+	d.secrets = append(d.secrets, secret)
+}
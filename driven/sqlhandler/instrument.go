@@ -0,0 +1,454 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigInstr string = "sqlhandler instrumentation"
+
+// Operaciones reportadas en QueryEvent.Op.
+const (
+	OpQuery    = "query"
+	OpExec     = "exec"
+	OpBegin    = "begin"
+	OpCommit   = "commit"
+	OpRollback = "rollback"
+)
+
+// QueryEvent describe una operación sobre la base de datos. Args contiene solo
+// la descripción redactada de cada argumento, nunca su valor.
+type QueryEvent struct {
+	Op       string
+	Query    string
+	Args     []string
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// QueryHook permite integrar trazas (por ejemplo spans de OpenTelemetry) sin que
+// el módulo dependa de ellas. BeforeQuery puede retornar un contexto derivado que
+// luego recibe AfterQuery.
+type QueryHook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// histogramBounds son los límites superiores de cada bucket de StatementStats.Buckets,
+// el último bucket acumula todo lo que supere el último límite.
+var histogramBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+type StatementStats struct {
+	Count   uint64
+	Errors  uint64
+	Total   time.Duration
+	Max     time.Duration
+	Buckets []uint64
+}
+
+type InstrOption func(options *instrOpts)
+
+type instrOpts struct {
+	logger    interfaces.Logger
+	threshold time.Duration
+	hooks     []QueryHook
+}
+
+// Instrumentation mide cada operación de la conexión, registra las consultas
+// lentas y acumula estadísticas por statement.
+type Instrumentation struct {
+	options instrOpts
+
+	mu    sync.Mutex
+	stats map[string]*StatementStats
+}
+
+// WithSlowQueryLog registra en logger las operaciones que tarden threshold o más.
+// Panics si logger es nil o threshold es negativo.
+func WithSlowQueryLog(logger interfaces.Logger, threshold time.Duration) InstrOption {
+	return func(options *instrOpts) {
+		if logger == nil {
+			panic(fmt.Sprintf("%s: logger cannot be nil", SigInstr))
+		}
+		if threshold < 0 {
+			panic(fmt.Sprintf("%s: slow query threshold cannot be negative", SigInstr))
+		}
+		options.logger = logger
+		options.threshold = threshold
+	}
+}
+
+// WithQueryHook agrega un hook que se ejecuta antes y después de cada operación.
+// Panics si hook es nil.
+func WithQueryHook(hook QueryHook) InstrOption {
+	return func(options *instrOpts) {
+		if hook == nil {
+			panic(fmt.Sprintf("%s: query hook cannot be nil", SigInstr))
+		}
+		options.hooks = append(options.hooks, hook)
+	}
+}
+
+func NewInstrumentation(opts ...InstrOption) *Instrumentation {
+	ins := &Instrumentation{stats: map[string]*StatementStats{}}
+	for _, opt := range opts {
+		opt(&ins.options)
+	}
+	return ins
+}
+
+// Stats retorna una copia de las estadísticas acumuladas, indexadas por
+// operación y statement normalizado.
+func (ins *Instrumentation) Stats() map[string]StatementStats {
+	ins.mu.Lock()
+	defer ins.mu.Unlock()
+
+	stats := make(map[string]StatementStats, len(ins.stats))
+	for key, s := range ins.stats {
+		copied := *s
+		copied.Buckets = append([]uint64{}, s.Buckets...)
+		stats[key] = copied
+	}
+	return stats
+}
+
+// begin ejecuta los hooks previos a una operación.
+func (ins *Instrumentation) begin(ctx context.Context, op, query string, args []driver.NamedValue) (context.Context, *QueryEvent) {
+	event := &QueryEvent{Op: op, Query: normalizeQuery(query), Args: redactArgs(args), Start: time.Now()}
+	for _, hook := range ins.options.hooks {
+		ctx = hook.BeforeQuery(ctx, event)
+	}
+	return ctx, event
+}
+
+// end registra el resultado de una operación. driver.ErrSkip no es un error real,
+// solo indica que database/sql usará otro camino que también es instrumentado.
+func (ins *Instrumentation) end(ctx context.Context, event *QueryEvent, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+
+	event.Duration = time.Since(event.Start)
+	event.Err = err
+
+	for i := len(ins.options.hooks) - 1; i >= 0; i-- {
+		ins.options.hooks[i].AfterQuery(ctx, event)
+	}
+
+	ins.record(event)
+
+	if ins.options.logger != nil && event.Duration >= ins.options.threshold {
+		args := []any{"op", event.Op, "query", event.Query, "args", event.Args, "duration", event.Duration}
+		if err != nil {
+			args = append(args, "error", err.Error())
+		}
+		ins.options.logger.WarnContext(ctx, fmt.Sprintf("%s: slow query", SigInstr), args...)
+	}
+}
+
+func (ins *Instrumentation) record(event *QueryEvent) {
+	key := event.Op
+	if event.Query != "" {
+		key += " " + event.Query
+	}
+
+	ins.mu.Lock()
+	defer ins.mu.Unlock()
+
+	s, ok := ins.stats[key]
+	if !ok {
+		s = &StatementStats{Buckets: make([]uint64, len(histogramBounds)+1)}
+		ins.stats[key] = s
+	}
+
+	s.Count++
+	if event.Err != nil {
+		s.Errors++
+	}
+	s.Total += event.Duration
+	s.Max = max(s.Max, event.Duration)
+
+	bucket := len(histogramBounds)
+	for i, bound := range histogramBounds {
+		if event.Duration <= bound {
+			bucket = i
+			break
+		}
+	}
+	s.Buckets[bucket]++
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// redactArgs describe cada argumento por su tipo y largo, sin exponer su valor.
+func redactArgs(args []driver.NamedValue) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.Value.(type) {
+		case nil:
+			redacted[i] = "nil"
+		case string:
+			redacted[i] = fmt.Sprintf("string(len=%d)", len(v))
+		case []byte:
+			redacted[i] = fmt.Sprintf("[]byte(len=%d)", len(v))
+		default:
+			redacted[i] = reflect.TypeOf(v).String()
+		}
+	}
+	return redacted
+}
+
+// instrConnector envuelve el driver original para que cada conexión quede instrumentada.
+type instrConnector struct {
+	drv   driver.Driver
+	inner driver.Connector
+	dsn   string
+	ins   *Instrumentation
+}
+
+func (ins *Instrumentation) wrap(drv driver.Driver, dsn string) (driver.Connector, error) {
+	c := &instrConnector{drv: drv, dsn: dsn, ins: ins}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		inner, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		c.inner = inner
+	}
+	return c, nil
+}
+
+func (c *instrConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if c.inner != nil {
+		conn, err = c.inner.Connect(ctx)
+	} else {
+		conn, err = c.drv.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrConn{Conn: conn, ins: c.ins}, nil
+}
+
+func (c *instrConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+// Close libera el connector original, database/sql lo llama al cerrar la conexión.
+func (c *instrConnector) Close() error {
+	if closer, ok := c.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+type instrConn struct {
+	driver.Conn
+	ins *Instrumentation
+}
+
+func (c *instrConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrStmt{Stmt: stmt, conn: c.Conn, query: query, ins: c.ins}, nil
+}
+
+func (c *instrConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *instrConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	ctx, event := c.ins.begin(ctx, OpBegin, "", nil)
+
+	var tx driver.Tx
+	var err error
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	c.ins.end(ctx, event, err)
+	if err != nil {
+		return nil, err
+	}
+	return &instrTx{Tx: tx, ctx: ctx, start: event.Start, ins: c.ins}, nil
+}
+
+func (c *instrConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, event := c.ins.begin(ctx, OpExec, query, args)
+	res, err := ec.ExecContext(ctx, query, args)
+	c.ins.end(ctx, event, err)
+	return res, err
+}
+
+func (c *instrConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, event := c.ins.begin(ctx, OpQuery, query, args)
+	rows, err := qc.QueryContext(ctx, query, args)
+	c.ins.end(ctx, event, err)
+	return rows, err
+}
+
+func (c *instrConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *instrConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type instrStmt struct {
+	driver.Stmt
+	conn  driver.Conn
+	query string
+	ins   *Instrumentation
+}
+
+func (s *instrStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), toNamedValues(args))
+}
+
+func (s *instrStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), toNamedValues(args))
+}
+
+func (s *instrStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, event := s.ins.begin(ctx, OpExec, s.query, args)
+
+	var res driver.Result
+	var err error
+	if sc, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = sc.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(toValues(args))
+	}
+	s.ins.end(ctx, event, err)
+	return res, err
+}
+
+func (s *instrStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, event := s.ins.begin(ctx, OpQuery, s.query, args)
+
+	var rows driver.Rows
+	var err error
+	if sc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = sc.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(toValues(args))
+	}
+	s.ins.end(ctx, event, err)
+	return rows, err
+}
+
+func (s *instrStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	if nvc, ok := s.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type instrTx struct {
+	driver.Tx
+	ctx   context.Context
+	start time.Time
+	ins   *Instrumentation
+}
+
+func (t *instrTx) Commit() error {
+	return t.finish(OpCommit, t.Tx.Commit)
+}
+
+func (t *instrTx) Rollback() error {
+	return t.finish(OpRollback, t.Tx.Rollback)
+}
+
+// finish mide la transacción completa, desde el begin hasta el commit o rollback.
+func (t *instrTx) finish(op string, fn func() error) error {
+	ctx, event := t.ins.begin(t.ctx, op, "", nil)
+	event.Start = t.start
+	err := fn()
+	t.ins.end(ctx, event, err)
+	return err
+}
+
+func toNamedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func toValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, nv := range args {
+		values[i] = nv.Value
+	}
+	return values
+}
@@ -0,0 +1,131 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-on-bike/bike/tester/logtest"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+type ctxKey string
+
+// recordHook simula un hook de trazas que abre un span en BeforeQuery.
+type recordHook struct {
+	mu     sync.Mutex
+	events []QueryEvent
+	spans  int
+}
+
+func (h *recordHook) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	return context.WithValue(ctx, ctxKey("span"), event.Op)
+}
+
+func (h *recordHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Value(ctxKey("span")) == event.Op {
+		h.spans++
+	}
+	h.events = append(h.events, *event)
+}
+
+func TestInstrumentation(t *testing.T) {
+	logger := &logtest.Logger{}
+	hook := &recordHook{}
+	ins := NewInstrumentation(WithSlowQueryLog(logger, 0), WithQueryHook(hook))
+
+	dbURL, _ := GenTestLibsqlDBPath(t)
+	c := NewConnector(&strings.Builder{}, WithURL(dbURL), WithInstrumentation(ins))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.DB().Exec(`CREATE TABLE riders (id INTEGER PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	err := c.WithTx(context.Background(), nil, func(tx *sql.Tx) error {
+		for _, name := range []string{"topsecret-alice", "topsecret-bob"} {
+			if _, err := tx.Exec(`INSERT INTO riders (name)   VALUES (?)`, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error in tx: %v", err)
+	}
+
+	var count int
+	ctx := ContextWithTenant(context.Background(), "acme")
+	if err := c.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM riders`).Scan(&count); err != nil {
+		t.Fatalf("failed to count: %v", err)
+	}
+
+	stats := ins.Stats()
+	insert, ok := stats["exec INSERT INTO riders (name) VALUES (?)"]
+	if !ok {
+		t.Fatalf("expected stats for insert, got %v", stats)
+	}
+	if insert.Count != 2 || insert.Errors != 0 {
+		t.Fatalf("expected 2 inserts without errors, got %+v", insert)
+	}
+	var buckets uint64
+	for _, b := range insert.Buckets {
+		buckets += b
+	}
+	if buckets != insert.Count {
+		t.Fatalf("expected histogram to count %d, got %d", insert.Count, buckets)
+	}
+	if stats["commit"].Count != 1 {
+		t.Fatalf("expected one commit, got %+v", stats["commit"])
+	}
+	if stats["query SELECT COUNT(*) FROM riders"].Count != 1 {
+		t.Fatalf("expected one select, got %v", stats)
+	}
+
+	logs := logger.String()
+	if !strings.Contains(logs, "slow query") || !strings.Contains(logs, "string(len=15)") {
+		t.Fatalf("expected slow query logs with redacted args, got %s", logs)
+	}
+	if strings.Contains(logs, "topsecret") {
+		t.Fatalf("logs leaked args: %s", logs)
+	}
+	if !strings.Contains(logs, "tenant acme") {
+		t.Fatalf("expected context fields in slow query logs, got %s", logs)
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if len(hook.events) == 0 || hook.spans != len(hook.events) {
+		t.Fatalf("expected every event to keep its span context, got %d spans for %d events", hook.spans, len(hook.events))
+	}
+}
+
+func TestInstrumentationRecordsErrors(t *testing.T) {
+	ins := NewInstrumentation()
+
+	dbURL, _ := GenTestLibsqlDBPath(t)
+	c := NewConnector(&strings.Builder{}, WithURL(dbURL), WithInstrumentation(ins))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.DB().Exec(`SELECT * FROM missing_table`); err == nil {
+		t.Fatal("expected error querying missing table")
+	}
+
+	var errors uint64
+	for _, s := range ins.Stats() {
+		errors += s.Errors
+	}
+	if errors == 0 {
+		t.Fatal("expected failed statement to be counted")
+	}
+}
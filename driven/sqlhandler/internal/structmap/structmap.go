@@ -0,0 +1,107 @@
+// Package structmap recorre los campos de un struct que se mapean a columnas
+// con el tag `db`. Lo comparten los helpers de scan de sqlhandler y el
+// repositorio SQL, para que ambos mapeen los structs de la misma forma.
+package structmap
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// Field es un campo de struct mapeado a una columna.
+type Field struct {
+	// Column es el nombre de la columna, del tag o del campo en snake_case.
+	Column string
+	// Flags es lo que sigue a la coma en el tag, como "pk" en `db:"id,pk"`.
+	Flags string
+	// Name es el nombre del campo en Go.
+	Name string
+	// Index es el camino hasta el campo, incluyendo structs embebidos.
+	Index []int
+}
+
+// IsScalar indica si typ se lee desde una única columna: los tipos que no son
+// structs, time.Time y los que implementan sql.Scanner.
+func IsScalar(typ reflect.Type) bool {
+	return typ.Kind() != reflect.Struct || typ == timeType || reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// Fields retorna los campos exportados de typ en orden de declaración. Se mapean
+// con el tag `db:"column"` o `db:"column,flags"`, o con el nombre del campo en
+// snake_case si no tienen tag; `db:"-"` ignora el campo.
+func Fields(typ reflect.Type) []Field {
+	return collect(typ, nil, nil)
+}
+
+func collect(typ reflect.Type, parent []int, fields []Field) []Field {
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		column, flags, _ := strings.Cut(f.Tag.Get("db"), ",")
+		if column == "-" {
+			continue
+		}
+
+		// Los structs embebidos sin tag aportan sus columnas al padre
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && column == "" && !IsScalar(ft) {
+			fields = collect(ft, index, fields)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if column == "" {
+			column = SnakeCase(f.Name)
+		}
+		fields = append(fields, Field{Column: column, Flags: flags, Name: f.Name, Index: index})
+	}
+	return fields
+}
+
+// SnakeCase convierte un nombre de campo a snake_case, respetando siglas:
+// UserID queda como user_id y URLPath como url_path.
+func SnakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Insertamos "_" al inicio de cada palabra, respetando siglas como ID o URL
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FieldByIndex es como reflect.Value.FieldByIndex, pero asigna los structs
+// embebidos por puntero que estén en nil.
+func FieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
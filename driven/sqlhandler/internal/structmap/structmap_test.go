@@ -0,0 +1,67 @@
+package structmap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type audit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type Owner struct {
+	OwnerID int64
+}
+
+type rider struct {
+	time.Time
+	audit
+	*Owner
+	ID      int64  `db:"id,pk"`
+	URLPath string `db:""`
+	Ignored string `db:"-"`
+	private string
+}
+
+func TestFields(t *testing.T) {
+	got := Fields(reflect.TypeFor[rider]())
+	want := []Field{
+		{Column: "time", Name: "Time", Index: []int{0}},
+		{Column: "created_by", Name: "CreatedBy", Index: []int{1, 0}},
+		{Column: "owner_id", Name: "OwnerID", Index: []int{2, 0}},
+		{Column: "id", Flags: "pk", Name: "ID", Index: []int{3}},
+		{Column: "url_path", Name: "URLPath", Index: []int{4}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected fields\n got: %+v\nwant: %+v", got, want)
+	}
+
+	var r rider
+	FieldByIndex(reflect.ValueOf(&r).Elem(), []int{2, 0}).SetInt(7)
+	if r.Owner == nil || r.OwnerID != 7 {
+		t.Fatalf("expected embedded pointer allocated, got %+v", r.Owner)
+	}
+}
+
+func TestIsScalar(t *testing.T) {
+	cases := map[reflect.Type]bool{
+		reflect.TypeFor[int]():       true,
+		reflect.TypeFor[time.Time](): true,
+		reflect.TypeFor[audit]():     false,
+	}
+	for typ, want := range cases {
+		if got := IsScalar(typ); got != want {
+			t.Errorf("IsScalar(%s) = %v, want %v", typ, got, want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{"ID": "id", "UserID": "user_id", "URLPath": "url_path", "Gears": "gears"}
+	for in, want := range cases {
+		if got := SnakeCase(in); got != want {
+			t.Fatalf("SnakeCase(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -1,18 +1,117 @@
 package sqlhandler
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
 )
 
 type migrOpts struct {
-	path *string
+	path   *string
+	fsys   fs.FS
+	table  *string
+	dollar bool
+}
+
+// source retorna el sistema de archivos y el directorio donde buscar migraciones.
+// Con WithFS el path es relativo al fs.FS y es opcional.
+func (o *migrOpts) source() (fs.FS, string, error) {
+	if o.fsys != nil {
+		if o.path == nil {
+			return o.fsys, ".", nil
+		}
+		return o.fsys, *o.path, nil
+	}
+	if o.path == nil {
+		return nil, "", fmt.Errorf("%s: migration path is not configured", SigMigr)
+	}
+	return os.DirFS(*o.path), ".", nil
+}
+
+// hasSource indica si el Migrator tiene de dónde cargar migraciones.
+func (o *migrOpts) hasSource() bool {
+	return o.fsys != nil || o.path != nil
+}
+
+// bind adapta los placeholders "?" de query al estilo "$n" cuando el motor lo requiere.
+func (o *migrOpts) bind(query string) string {
+	if !o.dollar {
+		return query
+	}
+	return RebindDollar(query)
+}
+
+// RebindDollar reescribe los placeholders "?" de stmt como "$1", "$2", ..., el
+// estilo de Postgres. Los "?" dentro de texto entre comillas simples, dobles o
+// backticks, o de comentarios "--", no se tocan.
+func RebindDollar(stmt string) string {
+	var b strings.Builder
+	n := 0
+	var quote rune
+	comment := false
+	prev := rune(0)
+	for _, r := range stmt {
+		switch {
+		case comment:
+			if r == '\n' {
+				comment = false
+			}
+		case quote != 0:
+			// Una comilla escapada duplicándola cierra y vuelve a abrir, lo que da igual
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '-' && prev == '-':
+			comment = true
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			prev = r
+			continue
+		}
+		prev = r
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// MigrateFS aplica las migraciones pendientes de dir dentro de fsys, registrándolas
+// en table. Es el Migrate de los paquetes que traen su esquema embebido, como
+// jobqueue o outbox. dollar indica que la base de datos usa placeholders "$n".
+// Que no haya migraciones pendientes no es un error.
+func MigrateFS(stderr io.Writer, db *sql.DB, fsys fs.FS, dir, table string, dollar bool) error {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("%s: migrations %s are missing: %w", SigMigr, dir, err)
+	}
+
+	opts := []MigrOption{WithFS(sub), WithTable(table)}
+	if dollar {
+		opts = append(opts, WithDollarPlaceholders())
+	}
+
+	m := NewMigrator(stderr, db, opts...)
+	if err := m.Move(0, false); err != nil && !errors.Is(err, ErrNoMigrations) {
+		return fmt.Errorf("%s: failed to migrate %s: %w", SigMigr, table, err)
+	}
+	return nil
+}
+
+func (o *migrOpts) tableName() string {
+	if o.table == nil {
+		return defaultMigrTable
+	}
+	return *o.table
 }
 
 type Migrator struct {
@@ -23,6 +122,11 @@ type Migrator struct {
 
 const SigMigr string = "sqlhandler migrator"
 
+// ErrNoMigrations se retorna cuando Move no encuentra migraciones pendientes.
+var ErrNoMigrations = errors.New("no migrations to run")
+
+const defaultMigrTable string = "migrations"
+
 func NewMigrator(stderr io.Writer, db *sql.DB, opts ...MigrOption) *Migrator {
 	if stderr == nil {
 		panic(fmt.Sprintf("%s: stderr cannot be nil", SigMigr))
@@ -51,13 +155,13 @@ func (m *Migrator) SetDB(db *sql.DB) {
 
 func (m *Migrator) init() error {
 	fmt.Fprintf(m.stderr, "%s: executing a query in init", SigMigr)
-	_, err := m.db.Exec(`
-        CREATE TABLE IF NOT EXISTS migrations (
+	_, err := m.db.Exec(fmt.Sprintf(`
+        CREATE TABLE IF NOT EXISTS %s (
             id INTEGER PRIMARY KEY,
             name TEXT NOT NULL,
-            executed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+            executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
         )
-    `)
+    `, m.options.tableName()))
 	if err != nil {
 		return fmt.Errorf("%s: failed to initialize migrations table: %w", SigMigr, err)
 	}
@@ -67,12 +171,12 @@ func (m *Migrator) init() error {
 func (m *Migrator) findLastID() (int, error) {
 	var lastID int
 	fmt.Fprintf(m.stderr, "%s: executing query row in find last id", SigMigr)
-	err := m.db.QueryRow(`
+	err := m.db.QueryRow(fmt.Sprintf(`
         SELECT id 
-        FROM migrations 
+        FROM %s 
         ORDER BY id DESC 
         LIMIT 1
-    `).Scan(&lastID)
+    `, m.options.tableName())).Scan(&lastID)
 
 	if err == sql.ErrNoRows {
 		return 0, nil
@@ -89,7 +193,7 @@ type Migration struct {
 	SQL  string
 }
 
-func (m *Migrator) load(path string, inverse bool, steps int) ([]Migration, error) {
+func (m *Migrator) load(fsys fs.FS, dir string, inverse bool, steps int) ([]Migration, error) {
 	direction := map[bool]string{true: "down", false: "up"}
 
 	if steps < 0 {
@@ -101,7 +205,7 @@ func (m *Migrator) load(path string, inverse bool, steps int) ([]Migration, erro
 		return nil, fmt.Errorf("%s: failed to find last migration ID: %w", SigMigr, err)
 	}
 
-	filenames, err := filepath.Glob(filepath.Join(path, fmt.Sprintf("*.%s.sql", direction[inverse])))
+	filenames, err := fs.Glob(fsys, path.Join(dir, fmt.Sprintf("*.%s.sql", direction[inverse])))
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to get migration files: %w", SigMigr, err)
 	}
@@ -146,7 +250,7 @@ func (m *Migrator) load(path string, inverse bool, steps int) ([]Migration, erro
 
 	migrations := make([]Migration, toID-fromID+1)
 	for _, filename := range filenames {
-		_, name := filepath.Split(filename)
+		_, name := path.Split(filename)
 		noSuffix := strings.TrimSuffix(name, fmt.Sprintf(".%s.sql", direction[inverse]))
 		nameParts := strings.Split(noSuffix, "_")
 
@@ -167,7 +271,7 @@ func (m *Migrator) load(path string, inverse bool, steps int) ([]Migration, erro
 		}
 
 		// Leer contenido del archivo SQL
-		content, err := os.ReadFile(filename)
+		content, err := fs.ReadFile(fsys, filename)
 		if err != nil {
 			return nil, fmt.Errorf("%s: failed to read migration file %s: %w", SigMigr, filename, err)
 		}
@@ -176,8 +280,8 @@ func (m *Migrator) load(path string, inverse bool, steps int) ([]Migration, erro
 		}
 
 		// Verificar que existe el archivo opuesto
-		counterpartPath := filepath.Join(path, fmt.Sprintf("%s.%s.sql", noSuffix, direction[!inverse]))
-		counterpartContent, err := os.ReadFile(counterpartPath)
+		counterpartPath := path.Join(dir, fmt.Sprintf("%s.%s.sql", noSuffix, direction[!inverse]))
+		counterpartContent, err := fs.ReadFile(fsys, counterpartPath)
 		if err != nil {
 			return nil, fmt.Errorf("%s: failed to read counterpart file for %s: %w", SigMigr, filename, err)
 		}
@@ -208,41 +312,36 @@ func isConnected(db *sql.DB) bool {
 	return db != nil && db.Ping() == nil
 }
 
-func (m *Migrator) up(migrations []Migration) error {
-	for _, mig := range migrations {
-		tx, err := m.db.Begin()
-		if err != nil {
-			return fmt.Errorf("%s: failed to start transaction: %w", SigMigr, err)
+// execStatements ejecuta cada statement separado por ";" de una migración.
+func execStatements(tx *sql.Tx, migrationSQL string) error {
+	stmts := strings.Split(migrationSQL, ";")
+	for _, s := range stmts[:len(stmts)-1] {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
 		}
 
-		// Ejecutar statements
-		stmts := strings.Split(mig.SQL, ";")
-		for _, s := range stmts[:len(stmts)-1] {
-			if s = strings.TrimSpace(s); s == "" {
-				continue
-			}
+		if _, err := tx.Exec(fmt.Sprintf("%s;", s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			if _, err := tx.Exec(fmt.Sprintf("%s;", s)); err != nil {
-				rollErr := tx.Rollback()
-				if rollErr != nil {
-					// Aquí retornamos ambos errores ya que es crítico saber si falló tanto la migración como el rollback
-					return fmt.Errorf("%s: migration %d failed: %v, additionally rollback failed: %v", SigMigr, mig.ID, err, rollErr)
-				}
+func (m *Migrator) up(migrations []Migration) error {
+	for _, mig := range migrations {
+		err := withTx(context.Background(), m.db, nil, defaultTxPolicy, func(tx *sql.Tx) error {
+			if err := execStatements(tx, mig.SQL); err != nil {
 				return fmt.Errorf("%s: migration %d failed: %w", SigMigr, mig.ID, err)
 			}
-		}
 
-		// Registrar migración
-		if _, err := tx.Exec(`INSERT INTO migrations (id, name) VALUES (?, ?)`, mig.ID, mig.Name); err != nil {
-			rollErr := tx.Rollback()
-			if rollErr != nil {
-				return fmt.Errorf("%s: failed to register migration %d: %v,SigMigr, additionally rollback failed: %v", SigMigr, mig.ID, err, rollErr)
+			// Registrar migración
+			if _, err := tx.Exec(m.options.bind(fmt.Sprintf(`INSERT INTO %s (id, name) VALUES (?, ?)`, m.options.tableName())), mig.ID, mig.Name); err != nil {
+				return fmt.Errorf("%s: failed to register migration %d: %w", SigMigr, mig.ID, err)
 			}
-			return fmt.Errorf("%s: failed to register migration %d: %w", SigMigr, mig.ID, err)
-		}
-
-		if err = tx.Commit(); err != nil {
-			return fmt.Errorf("%s: failed to commit migration %d: %w", SigMigr, mig.ID, err)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
@@ -251,38 +350,19 @@ func (m *Migrator) up(migrations []Migration) error {
 
 func (m *Migrator) down(migrations []Migration) error {
 	for _, mig := range migrations {
-		tx, err := m.db.Begin()
-		if err != nil {
-			return fmt.Errorf("%s: failed to start transaction: %w", SigMigr, err)
-		}
-
-		// Ejecutar statements
-		stmts := strings.Split(mig.SQL, ";")
-		for _, s := range stmts[:len(stmts)-1] {
-			if s = strings.TrimSpace(s); s == "" {
-				continue
-			}
-
-			if _, err := tx.Exec(fmt.Sprintf("%s;", s)); err != nil {
-				rollErr := tx.Rollback()
-				if rollErr != nil {
-					return fmt.Errorf("%s: migration %d rollback failed: %v,SigMigr, additionally transaction rollback failed: %v", SigMigr, mig.ID, err, rollErr)
-				}
+		err := withTx(context.Background(), m.db, nil, defaultTxPolicy, func(tx *sql.Tx) error {
+			if err := execStatements(tx, mig.SQL); err != nil {
 				return fmt.Errorf("%s: migration %d rollback failed: %w", SigMigr, mig.ID, err)
 			}
-		}
 
-		// Eliminar registro de migración
-		if _, err := tx.Exec(`DELETE from MIGRATIONS WHERE id = ?`, mig.ID); err != nil {
-			rollErr := tx.Rollback()
-			if rollErr != nil {
-				return fmt.Errorf("%s: failed to remove migration %d record: %v,SigMigr, additionally rollback failed: %v", SigMigr, mig.ID, err, rollErr)
+			// Eliminar registro de migración
+			if _, err := tx.Exec(m.options.bind(fmt.Sprintf(`DELETE from %s WHERE id = ?`, m.options.tableName())), mig.ID); err != nil {
+				return fmt.Errorf("%s: failed to remove migration %d record: %w", SigMigr, mig.ID, err)
 			}
-			return fmt.Errorf("%s: failed to remove migration %d record: %w", SigMigr, mig.ID, err)
-		}
-
-		if err = tx.Commit(); err != nil {
-			return fmt.Errorf("%s: failed to commit migration %d rollback: %w", SigMigr, mig.ID, err)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
@@ -307,8 +387,13 @@ func (m *Migrator) Move(steps int, inverse bool) error {
 		return fmt.Errorf("%s: failed to initialize migrations: %w", SigMigr, err)
 	}
 
+	fsys, dir, err := m.options.source()
+	if err != nil {
+		return err
+	}
+
 	// Cargar migraciones
-	migrations, err := m.load(*m.options.path, inverse, steps)
+	migrations, err := m.load(fsys, dir, inverse, steps)
 
 	if err != nil {
 		return err
@@ -316,7 +401,7 @@ func (m *Migrator) Move(steps int, inverse bool) error {
 
 	// Verificar si hay migraciones para ejecutar
 	if len(migrations) == 0 {
-		return fmt.Errorf("%s: no migrations to run", SigMigr)
+		return fmt.Errorf("%s: %w", SigMigr, ErrNoMigrations)
 	}
 
 	// Ejecutar migraciones según la dirección
@@ -3,6 +3,7 @@ package sqlhandler
 import (
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/go-on-bike/bike/interfaces"
 	_ "github.com/tursodatabase/go-libsql"
@@ -115,3 +116,85 @@ func TestMigratorInterface(t *testing.T) {
 		AssertDBState(t, dbPath)
 	})
 }
+
+func TestMigratorFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/1_wheels.up.sql":   {Data: []byte("CREATE TABLE wheels (id INTEGER PRIMARY KEY);")},
+		"sql/1_wheels.down.sql": {Data: []byte("DROP TABLE wheels;")},
+		"sql/2_gears.up.sql":    {Data: []byte("CREATE TABLE gears (id INTEGER PRIMARY KEY);")},
+		"sql/2_gears.down.sql":  {Data: []byte("DROP TABLE gears;")},
+	}
+
+	c := newTxTestConnector(t)
+	m := NewMigrator(&strings.Builder{}, c.db, WithFS(fsys), WithPATH("sql"), WithTable("parts_migrations"))
+
+	if err := m.Move(0, false); err != nil {
+		t.Fatalf("unexpected error migrating: %v", err)
+	}
+	if version, err := m.Version(); err != nil || version != 2 {
+		t.Fatalf("expected version 2, got %d (%v)", version, err)
+	}
+
+	var count int
+	if err := c.db.QueryRow(`SELECT COUNT(*) FROM parts_migrations`).Scan(&count); err != nil || count != 2 {
+		t.Fatalf("expected 2 rows in parts_migrations, got %d (%v)", count, err)
+	}
+	if err := c.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'migrations'`).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("expected default migrations table to be untouched, got %d (%v)", count, err)
+	}
+
+	if err := m.Move(0, true); err != nil {
+		t.Fatalf("unexpected error rolling back: %v", err)
+	}
+	if version, err := m.Version(); err != nil || version != 0 {
+		t.Fatalf("expected version 0, got %d (%v)", version, err)
+	}
+
+	t.Run("invalid table panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected panic with invalid table name")
+			}
+		}()
+		NewMigrator(&strings.Builder{}, nil, WithTable("parts; DROP TABLE wheels"))
+	})
+
+	t.Run("without source fails", func(t *testing.T) {
+		m := NewMigrator(&strings.Builder{}, c.db)
+		if err := m.Move(0, false); err == nil {
+			t.Fatal("expected error without migration source")
+		}
+	})
+
+	t.Run("migrate fs", func(t *testing.T) {
+		c := newTxTestConnector(t)
+		for range 2 {
+			if err := MigrateFS(&strings.Builder{}, c.db, fsys, "sql", "parts_migrations", false); err != nil {
+				t.Fatalf("unexpected error migrating: %v", err)
+			}
+		}
+		var count int
+		if err := c.db.QueryRow(`SELECT COUNT(*) FROM parts_migrations`).Scan(&count); err != nil || count != 2 {
+			t.Fatalf("expected 2 rows in parts_migrations, got %d (%v)", count, err)
+		}
+
+		if err := MigrateFS(&strings.Builder{}, c.db, fsys, "../sql", "parts_migrations", false); err == nil {
+			t.Fatal("expected error with invalid dir")
+		}
+	})
+}
+
+func TestMigratorPlaceholders(t *testing.T) {
+	m := NewMigrator(&strings.Builder{}, nil)
+	if got := m.options.bind("DELETE FROM t WHERE id = ?"); got != "DELETE FROM t WHERE id = ?" {
+		t.Fatalf("expected query unchanged, got %q", got)
+	}
+
+	m = NewMigrator(&strings.Builder{}, nil, WithDollarPlaceholders())
+	if got := m.options.bind("INSERT INTO t (id, name) VALUES (?, ?)"); got != "INSERT INTO t (id, name) VALUES ($1, $2)" {
+		t.Fatalf("unexpected dollar query %q", got)
+	}
+	if got := m.options.bind("SELECT '?' FROM t WHERE id = ? -- or ?"); got != "SELECT '?' FROM t WHERE id = $1 -- or ?" {
+		t.Fatalf("expected quoted text and comments untouched, got %q", got)
+	}
+}
@@ -1,6 +1,19 @@
 package sqlhandler
 
-import "fmt"
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"time"
+)
+
+var identifierRX = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidIdentifier indica si name es un nombre simple de tabla o columna que se
+// puede interpolar en SQL sin comillas.
+func ValidIdentifier(name string) bool {
+	return identifierRX.MatchString(name)
+}
 
 type ConnOption func(options *connOpts)
 
@@ -15,6 +28,55 @@ func WithURL(url string) ConnOption {
 	}
 }
 
+// WithDSN establece la DSN de conexión, con soporte para secretos externos.
+// Tiene prioridad sobre WithURL.
+// Panics si la DSN es nil.
+func WithDSN(dsn *DSN) ConnOption {
+	return func(options *connOpts) {
+		if dsn == nil {
+			panic(fmt.Sprintf("%s: database DSN cannot be nil", SigConn))
+		}
+		options.dsn = dsn
+	}
+}
+
+// WithTxRetry configura cuántas veces WithTx intenta una transacción que falla por
+// bloqueo o serialización, y el backoff inicial entre intentos.
+// Panics si attempts es menor a 1 o backoff es negativo.
+func WithTxRetry(attempts int, backoff time.Duration) ConnOption {
+	return func(options *connOpts) {
+		if attempts < 1 {
+			panic(fmt.Sprintf("%s: tx attempts must be at least 1, got %d", SigConn, attempts))
+		}
+		if backoff < 0 {
+			panic(fmt.Sprintf("%s: tx backoff cannot be negative", SigConn))
+		}
+		options.txRetry = &txPolicy{attempts: attempts, backoff: backoff, maxBackoff: defaultTxPolicy.maxBackoff}
+	}
+}
+
+// WithInstrumentation envuelve el driver para medir cada query, exec y transacción.
+// Panics si ins es nil.
+func WithInstrumentation(ins *Instrumentation) ConnOption {
+	return func(options *connOpts) {
+		if ins == nil {
+			panic(fmt.Sprintf("%s: instrumentation cannot be nil", SigConn))
+		}
+		options.instr = ins
+	}
+}
+
+// WithDriver establece el driver que usa Start para conectarse.
+// Panics si driver está vacío.
+func WithDriver(driver string) ConnOption {
+	return func(options *connOpts) {
+		if driver == "" {
+			panic(fmt.Sprintf("%s: driver cannot be empty", SigConn))
+		}
+		options.driver = &driver
+	}
+}
+
 type MigrOption func(options *migrOpts)
 
 // WithPATH establece el path donde se encuentran las migraciones.
@@ -27,3 +89,34 @@ func WithPATH(path string) MigrOption {
 		options.path = &path
 	}
 }
+
+// WithFS carga las migraciones desde fsys, por ejemplo un embed.FS, en vez del disco.
+// Con WithPATH el path se interpreta dentro de fsys. Panics si fsys es nil.
+func WithFS(fsys fs.FS) MigrOption {
+	return func(options *migrOpts) {
+		if fsys == nil {
+			panic(fmt.Sprintf("%s: migration fs cannot be nil", SigMigr))
+		}
+		options.fsys = fsys
+	}
+}
+
+// WithTable cambia la tabla donde se registran las migraciones ejecutadas, de modo
+// que un paquete pueda versionar sus propias tablas sin chocar con las de la app.
+// Panics si name no es un identificador válido.
+func WithTable(name string) MigrOption {
+	return func(options *migrOpts) {
+		if !ValidIdentifier(name) {
+			panic(fmt.Sprintf("%s: invalid migration table name %q", SigMigr, name))
+		}
+		options.table = &name
+	}
+}
+
+// WithDollarPlaceholders hace que el Migrator use placeholders "$1", "$2", ... en
+// su tabla de registro, como requiere Postgres.
+func WithDollarPlaceholders() MigrOption {
+	return func(options *migrOpts) {
+		options.dollar = true
+	}
+}
@@ -0,0 +1,332 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+type dispatchOpts struct {
+	pollInterval time.Duration
+	batchSize    int
+	lockTimeout  time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+}
+
+type DispatchOption func(options *dispatchOpts)
+
+// WithPollInterval establece cada cuánto se revisa el outbox. Panics si d no es positivo.
+func WithPollInterval(d time.Duration) DispatchOption {
+	return func(options *dispatchOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: poll interval must be positive", SigOutbox))
+		}
+		options.pollInterval = d
+	}
+}
+
+// WithBatchSize establece cuántos eventos se leen por ronda. Panics si n no es positivo.
+func WithBatchSize(n int) DispatchOption {
+	return func(options *dispatchOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: batch size must be positive", SigOutbox))
+		}
+		options.batchSize = n
+	}
+}
+
+// WithLockTimeout establece cuánto tiempo un batch tomado queda oculto para otros
+// dispatchers. Es también el tiempo máximo para publicar el batch; lo que no alcance
+// a publicarse se libera para la próxima ronda. Panics si d no es positivo.
+func WithLockTimeout(d time.Duration) DispatchOption {
+	return func(options *dispatchOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: lock timeout must be positive", SigOutbox))
+		}
+		options.lockTimeout = d
+	}
+}
+
+// WithMaxAttempts establece cuántas veces se intenta entregar un evento antes de
+// marcarlo como fallido. 0 reintenta para siempre. Panics si n es negativo.
+func WithMaxAttempts(n int) DispatchOption {
+	return func(options *dispatchOpts) {
+		if n < 0 {
+			panic(fmt.Sprintf("%s: max attempts cannot be negative", SigOutbox))
+		}
+		options.maxAttempts = n
+	}
+}
+
+// WithBackoff establece la espera exponencial entre reintentos de un evento,
+// partiendo en base y sin superar max. Panics si base no es positivo o max < base.
+func WithBackoff(base, max time.Duration) DispatchOption {
+	return func(options *dispatchOpts) {
+		if base <= 0 || max < base {
+			panic(fmt.Sprintf("%s: invalid backoff %s..%s", SigOutbox, base, max))
+		}
+		options.backoff = base
+		options.maxBackoff = max
+	}
+}
+
+// Dispatcher lee los eventos pendientes del outbox y los entrega a un Publisher.
+// Cada ronda toma su batch con locked_by y locked_until antes de publicarlo, en
+// Postgres con FOR UPDATE SKIP LOCKED, así varias réplicas pueden leer el mismo
+// outbox sin publicar dos veces un evento. La entrega sigue siendo at-least-once:
+// un evento puede publicarse de nuevo si el proceso cae entre Publish y el
+// registro de la entrega.
+type Dispatcher struct {
+	stderr    io.Writer
+	db        *sql.DB
+	dialect   query.Dialect
+	publisher interfaces.Publisher
+	options   dispatchOpts
+	now       func() time.Time
+}
+
+// NewDispatcher crea un Dispatcher que publica los eventos del outbox de db en
+// publisher. driver debe coincidir con el de Migrate y Enqueue.
+func NewDispatcher(stderr io.Writer, db *sql.DB, driver string, publisher interfaces.Publisher, opts ...DispatchOption) *Dispatcher {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigOutbox))
+	}
+	if db == nil {
+		panic(fmt.Sprintf("%s: db cannot be nil", SigOutbox))
+	}
+	if publisher == nil {
+		panic(fmt.Sprintf("%s: publisher cannot be nil", SigOutbox))
+	}
+
+	d := &Dispatcher{
+		stderr:    stderr,
+		db:        db,
+		dialect:   query.DialectFor(driver),
+		publisher: publisher,
+		options: dispatchOpts{
+			pollInterval: time.Second,
+			batchSize:    100,
+			lockTimeout:  time.Minute,
+			maxAttempts:  10,
+			backoff:      time.Second,
+			maxBackoff:   5 * time.Minute,
+		},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(&d.options)
+	}
+
+	return d
+}
+
+// Start revisa el outbox cada poll interval hasta que ctx se cancele. Cuando una
+// ronda llena el batch se revisa de inmediato otra vez.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.options.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		delivered, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(d.stderr, "%s: dispatch failed: %v", SigOutbox, err)
+		}
+
+		if err == nil && delivered == d.options.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type pendingRow struct {
+	ID         int64  `db:"id"`
+	Topic      string `db:"topic"`
+	Key        string `db:"event_key"`
+	Payload    []byte `db:"payload"`
+	Headers    string `db:"headers"`
+	OccurredAt int64  `db:"occurred_at"`
+	Attempts   int    `db:"attempts"`
+}
+
+// DispatchOnce toma un batch de eventos pendientes, lo entrega y retorna cuántos
+// eventos procesó. Los errores del Publisher, o de un evento que no se puede leer,
+// no se retornan: se registran en el evento para reintentarlo. Si ctx se cancela o
+// vence el lock timeout, los eventos que faltan se liberan para la próxima ronda.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	token, err := newToken()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := d.claim(ctx, token)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to claim pending events: %w", SigOutbox, err)
+	}
+
+	batchCtx, cancel := context.WithTimeout(ctx, d.options.lockTimeout)
+	defer cancel()
+
+	for i, row := range rows {
+		if err := batchCtx.Err(); err != nil {
+			return i, d.release(ctx, token, err)
+		}
+		if err := d.dispatch(batchCtx, row, token); err != nil {
+			return i, d.release(ctx, token, err)
+		}
+	}
+
+	return len(rows), nil
+}
+
+// claim marca hasta batchSize eventos listos como tomados por token en una sola
+// sentencia y los retorna en orden.
+func (d *Dispatcher) claim(ctx context.Context, token string) ([]pendingRow, error) {
+	lock := ""
+	if d.dialect == query.Dollar {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+
+	var rows []pendingRow
+	err := sqlhandler.Retry(ctx, func() (err error) {
+		now := d.now()
+		rows, err = sqlhandler.QueryAll[pendingRow](ctx, d.db, d.dialect.Rebind(`
+            UPDATE outbox
+            SET locked_by = ?, locked_until = ?
+            WHERE id IN (
+                SELECT id FROM outbox
+                WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+                    AND (locked_until IS NULL OR locked_until <= ?)
+                ORDER BY id
+                LIMIT ?`+lock+`
+            )
+            RETURNING id, topic, event_key, payload, headers, occurred_at, attempts
+        `), token, now.Add(d.options.lockTimeout).UnixNano(), now.UnixNano(), now.UnixNano(), d.options.batchSize)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING no garantiza el orden de las filas
+	slices.SortFunc(rows, func(a, b pendingRow) int { return cmp.Compare(a.ID, b.ID) })
+	return rows, nil
+}
+
+// dispatch publica un evento tomado y registra el resultado. Solo retorna error si
+// no se pudo registrar, o si ctx terminó durante Publish.
+func (d *Dispatcher) dispatch(ctx context.Context, row pendingRow, token string) error {
+	// El resultado se registra aunque ctx termine, para no volver a publicar lo entregado
+	markCtx := context.WithoutCancel(ctx)
+
+	headers, err := decodeHeaders(row.Headers)
+	if err != nil {
+		return d.markFailed(markCtx, row, token, err)
+	}
+
+	event := interfaces.Event{
+		ID:         row.ID,
+		Topic:      row.Topic,
+		Key:        row.Key,
+		Payload:    row.Payload,
+		Headers:    headers,
+		OccurredAt: time.Unix(0, row.OccurredAt),
+	}
+
+	if pubErr := d.publisher.Publish(ctx, event); pubErr != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return d.markFailed(markCtx, row, token, pubErr)
+	}
+	return d.markDelivered(markCtx, row, token)
+}
+
+// release devuelve los eventos que token no alcanzó a procesar y retorna cause,
+// junto al error de la liberación si lo hubo.
+func (d *Dispatcher) release(ctx context.Context, token string, cause error) error {
+	_, err := d.db.ExecContext(context.WithoutCancel(ctx), d.dialect.Rebind(
+		`UPDATE outbox SET locked_by = NULL, locked_until = NULL WHERE locked_by = ?`,
+	), token)
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("%s: failed to release claimed events: %w", SigOutbox, err))
+	}
+	return cause
+}
+
+func (d *Dispatcher) markDelivered(ctx context.Context, row pendingRow, token string) error {
+	res, err := d.db.ExecContext(ctx, d.dialect.Rebind(`
+        UPDATE outbox SET delivered_at = ?, attempts = ?, last_error = NULL, locked_by = NULL, locked_until = NULL
+        WHERE id = ? AND locked_by = ?
+    `), d.now().UnixNano(), row.Attempts+1, row.ID, token)
+	if err != nil {
+		return fmt.Errorf("%s: failed to mark event %d as delivered: %w", SigOutbox, row.ID, err)
+	}
+	d.checkLock(res, row.ID)
+	return nil
+}
+
+func (d *Dispatcher) markFailed(ctx context.Context, row pendingRow, token string, cause error) error {
+	attempts := row.Attempts + 1
+	now := d.now()
+
+	var failedAt any
+	if d.options.maxAttempts > 0 && attempts >= d.options.maxAttempts {
+		failedAt = now.UnixNano()
+		fmt.Fprintf(d.stderr, "%s: giving up on event %d after %d attempts: %v", SigOutbox, row.ID, attempts, cause)
+	}
+
+	res, err := d.db.ExecContext(ctx, d.dialect.Rebind(`
+        UPDATE outbox
+        SET attempts = ?, next_attempt_at = ?, failed_at = ?, last_error = ?, locked_by = NULL, locked_until = NULL
+        WHERE id = ? AND locked_by = ?
+    `), attempts, now.Add(d.delay(attempts)).UnixNano(), failedAt, cause.Error(), row.ID, token)
+	if err != nil {
+		return fmt.Errorf("%s: failed to record error for event %d: %w", SigOutbox, row.ID, err)
+	}
+	d.checkLock(res, row.ID)
+	return nil
+}
+
+// checkLock avisa cuando el evento ya no estaba tomado por este dispatcher, lo que
+// pasa si el batch superó el lock timeout y otro dispatcher lo tomó.
+func (d *Dispatcher) checkLock(res sql.Result, id int64) {
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		fmt.Fprintf(d.stderr, "%s: lost lock on event %d, lock timeout expired", SigOutbox, id)
+	}
+}
+
+// delay calcula el backoff exponencial para el intento dado.
+func (d *Dispatcher) delay(attempts int) time.Duration {
+	delay := d.options.backoff << (attempts - 1)
+	if delay <= 0 || delay > d.options.maxBackoff {
+		return d.options.maxBackoff
+	}
+	return delay
+}
+
+func newToken() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("%s: failed to generate lock token: %w", SigOutbox, err)
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic TEXT NOT NULL,
+    event_key TEXT NOT NULL DEFAULT '',
+    payload BYTEA,
+    headers TEXT NOT NULL DEFAULT '{}',
+    occurred_at BIGINT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    locked_until BIGINT,
+    locked_by TEXT,
+    delivered_at BIGINT,
+    failed_at BIGINT,
+    last_error TEXT
+);
+CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (delivered_at, failed_at, next_attempt_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS outbox_pending;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    topic TEXT NOT NULL,
+    event_key TEXT NOT NULL DEFAULT '',
+    payload BLOB,
+    headers TEXT NOT NULL DEFAULT '{}',
+    occurred_at INTEGER NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    locked_until INTEGER,
+    locked_by TEXT,
+    delivered_at INTEGER,
+    failed_at INTEGER,
+    last_error TEXT
+);
+CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (delivered_at, failed_at, next_attempt_at);
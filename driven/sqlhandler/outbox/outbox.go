@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigOutbox string = "sqlhandler outbox"
+
+// MigrationTable registra las versiones del esquema del outbox. Es propia del
+// paquete para que Migrate no toque la tabla de migraciones de la aplicación.
+const MigrationTable string = "outbox_migrations"
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migrate crea o actualiza la tabla outbox en db, con tipos de Postgres si driver
+// es uno de sus drivers y de SQLite/libsql en otro caso.
+func Migrate(stderr io.Writer, db *sql.DB, driver string) error {
+	dialect := query.DialectFor(driver)
+	dir := "migrations/sqlite"
+	if dialect == query.Dollar {
+		dir = "migrations/postgres"
+	}
+	if err := sqlhandler.MigrateFS(stderr, db, migrationFiles, dir, MigrationTable, dialect == query.Dollar); err != nil {
+		return fmt.Errorf("%s: %w", SigOutbox, err)
+	}
+	return nil
+}
+
+// Enqueue guarda los eventos en el outbox dentro de tx. Los eventos solo serán
+// visibles para el Dispatcher si tx hace commit. driver es el mismo que recibe
+// NewDispatcher y define el estilo de placeholders.
+func Enqueue(ctx context.Context, tx *sql.Tx, driver string, events ...interfaces.Event) error {
+	if tx == nil {
+		return fmt.Errorf("%s: transaction cannot be nil", SigOutbox)
+	}
+
+	dialect := query.DialectFor(driver)
+	now := time.Now()
+	for _, event := range events {
+		if event.Topic == "" {
+			return fmt.Errorf("%s: event topic cannot be empty", SigOutbox)
+		}
+
+		headers, err := encodeHeaders(event.Headers)
+		if err != nil {
+			return err
+		}
+
+		occurredAt := event.OccurredAt
+		if occurredAt.IsZero() {
+			occurredAt = now
+		}
+
+		_, err = tx.ExecContext(ctx,
+			dialect.Rebind(`INSERT INTO outbox (topic, event_key, payload, headers, occurred_at, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?)`),
+			event.Topic, event.Key, event.Payload, headers, occurredAt.UnixNano(), now.UnixNano(),
+		)
+		if err != nil {
+			return fmt.Errorf("%s: failed to enqueue event %s: %w", SigOutbox, event.Topic, err)
+		}
+	}
+	return nil
+}
+
+func encodeHeaders(headers map[string]string) (string, error) {
+	if len(headers) == 0 {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(headers)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to encode headers: %w", SigOutbox, err)
+	}
+	return string(raw), nil
+}
+
+func decodeHeaders(raw string) (map[string]string, error) {
+	headers := map[string]string{}
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode headers: %w", SigOutbox, err)
+	}
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return headers, nil
+}
@@ -0,0 +1,380 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/interfaces"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+func newTestDB(t *testing.T) (*sqlhandler.Connector, *sql.DB) {
+	dbURL := "file:" + filepath.Join(t.TempDir(), "test.db")
+	c := sqlhandler.NewConnector(&strings.Builder{}, sqlhandler.WithURL(dbURL))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	db := c.DB()
+	if err := Migrate(&strings.Builder{}, db, "libsql"); err != nil {
+		t.Fatalf("unexpected error migrating: %v", err)
+	}
+	// Migrate es idempotente
+	if err := Migrate(&strings.Builder{}, db, "libsql"); err != nil {
+		t.Fatalf("unexpected error migrating twice: %v", err)
+	}
+	return c, db
+}
+
+// flakyPublisher falla las primeras fails publicaciones de cada evento.
+type flakyPublisher struct {
+	mu       sync.Mutex
+	fails    int
+	attempts map[int64]int
+	inner    *MemoryPublisher
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, event interfaces.Event) error {
+	p.mu.Lock()
+	p.attempts[event.ID]++
+	attempt := p.attempts[event.ID]
+	p.mu.Unlock()
+
+	if attempt <= p.fails {
+		return errors.New("broker unavailable")
+	}
+	return p.inner.Publish(ctx, event)
+}
+
+// blockingPublisher avisa en entered al recibir el primer evento y espera release.
+type blockingPublisher struct {
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+	inner   *MemoryPublisher
+}
+
+func (p *blockingPublisher) Publish(ctx context.Context, event interfaces.Event) error {
+	p.once.Do(func() { close(p.entered) })
+	<-p.release
+	return p.inner.Publish(ctx, event)
+}
+
+// cancelingPublisher cancela el contexto del dispatcher después de publicar.
+type cancelingPublisher struct {
+	cancel context.CancelFunc
+	inner  *MemoryPublisher
+}
+
+func (p *cancelingPublisher) Publish(ctx context.Context, event interfaces.Event) error {
+	defer p.cancel()
+	return p.inner.Publish(ctx, event)
+}
+
+func pendingCount(t *testing.T, db *sql.DB) int {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE delivered_at IS NULL`).Scan(&count); err != nil {
+		t.Fatalf("failed to count pending events: %v", err)
+	}
+	return count
+}
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delivers only committed events", func(t *testing.T) {
+		c, db := newTestDB(t)
+
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{
+				Topic:   "bike.created",
+				Key:     "42",
+				Payload: []byte(`{"model":"road"}`),
+				Headers: map[string]string{"trace": "abc"},
+			})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error enqueueing: %v", err)
+		}
+
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			if err := Enqueue(ctx, tx, "libsql", interfaces.Event{Topic: "bike.deleted"}); err != nil {
+				return err
+			}
+			return errors.New("rollback")
+		})
+
+		pub := NewMemoryPublisher()
+		d := NewDispatcher(&strings.Builder{}, db, "libsql", pub)
+		n, err := d.DispatchOnce(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error dispatching: %v", err)
+		}
+		if n != 1 {
+			t.Fatalf("expected 1 event read, got %d", n)
+		}
+
+		events := pub.Events()
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event published, got %d", len(events))
+		}
+		event := events[0]
+		if event.Topic != "bike.created" || event.Key != "42" || string(event.Payload) != `{"model":"road"}` || event.Headers["trace"] != "abc" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		if event.OccurredAt.IsZero() {
+			t.Fatal("expected occurred at to be set")
+		}
+		if got := pendingCount(t, db); got != 0 {
+			t.Fatalf("expected no pending events, got %d", got)
+		}
+
+		// Un evento entregado no se vuelve a publicar
+		if _, err := d.DispatchOnce(ctx); err != nil {
+			t.Fatalf("unexpected error dispatching: %v", err)
+		}
+		if len(pub.Events()) != 1 {
+			t.Fatalf("expected delivered event not to be republished")
+		}
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		c, db := newTestDB(t)
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{Topic: "bike.created"})
+		})
+
+		pub := &flakyPublisher{fails: 2, attempts: map[int64]int{}, inner: NewMemoryPublisher()}
+		d := NewDispatcher(&strings.Builder{}, db, "libsql", pub, WithBackoff(time.Minute, time.Hour))
+
+		now := time.Now()
+		d.now = func() time.Time { return now }
+
+		if _, err := d.DispatchOnce(ctx); err != nil {
+			t.Fatalf("unexpected error dispatching: %v", err)
+		}
+		// El evento está en backoff, así que no se intenta de nuevo todavía
+		if _, err := d.DispatchOnce(ctx); err != nil {
+			t.Fatalf("unexpected error dispatching: %v", err)
+		}
+		if pub.attempts[1] != 1 {
+			t.Fatalf("expected 1 attempt while in backoff, got %d", pub.attempts[1])
+		}
+
+		var lastError string
+		if err := db.QueryRow(`SELECT last_error FROM outbox WHERE id = 1`).Scan(&lastError); err != nil || lastError != "broker unavailable" {
+			t.Fatalf("expected last error recorded, got %q (%v)", lastError, err)
+		}
+
+		now = now.Add(time.Minute)
+		d.DispatchOnce(ctx)
+		now = now.Add(2 * time.Minute)
+		d.DispatchOnce(ctx)
+
+		if pub.attempts[1] != 3 || len(pub.inner.Events()) != 1 {
+			t.Fatalf("expected delivery on third attempt, got %d attempts", pub.attempts[1])
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		c, db := newTestDB(t)
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{Topic: "bike.created"})
+		})
+
+		stderr := &strings.Builder{}
+		pub := &flakyPublisher{fails: 10, attempts: map[int64]int{}, inner: NewMemoryPublisher()}
+		d := NewDispatcher(stderr, db, "libsql", pub, WithMaxAttempts(2), WithBackoff(time.Nanosecond, time.Nanosecond))
+
+		for range 4 {
+			d.DispatchOnce(ctx)
+			time.Sleep(time.Millisecond)
+		}
+		if pub.attempts[1] != 2 {
+			t.Fatalf("expected 2 attempts, got %d", pub.attempts[1])
+		}
+		if !strings.Contains(stderr.String(), "giving up on event 1") {
+			t.Fatalf("expected give up log, got %q", stderr.String())
+		}
+	})
+
+	t.Run("start delivers until canceled", func(t *testing.T) {
+		c, db := newTestDB(t)
+		pub := NewMemoryPublisher()
+		d := NewDispatcher(&strings.Builder{}, db, "libsql", pub, WithPollInterval(5*time.Millisecond), WithBatchSize(2))
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- d.Start(runCtx) }()
+
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql",
+				interfaces.Event{Topic: "a"},
+				interfaces.Event{Topic: "b"},
+				interfaces.Event{Topic: "c"},
+			)
+		})
+
+		deadline := time.After(2 * time.Second)
+		for len(pub.Events()) < 3 {
+			select {
+			case <-deadline:
+				t.Fatalf("expected 3 events delivered, got %d", len(pub.Events()))
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("unexpected error from Start: %v", err)
+		}
+	})
+
+	t.Run("claims the batch before publishing", func(t *testing.T) {
+		c, db := newTestDB(t)
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{Topic: "a"}, interfaces.Event{Topic: "b"})
+		})
+
+		blocked := &blockingPublisher{entered: make(chan struct{}), release: make(chan struct{}), inner: NewMemoryPublisher()}
+		first := NewDispatcher(&strings.Builder{}, db, "libsql", blocked)
+		done := make(chan int, 1)
+		go func() {
+			n, _ := first.DispatchOnce(ctx)
+			done <- n
+		}()
+		<-blocked.entered
+
+		// Otra réplica no ve los eventos tomados por la primera
+		pub := NewMemoryPublisher()
+		n, err := NewDispatcher(&strings.Builder{}, db, "libsql", pub).DispatchOnce(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error dispatching: %v", err)
+		}
+		if n != 0 || len(pub.Events()) != 0 {
+			t.Fatalf("expected claimed events to be skipped, got %d", n)
+		}
+
+		close(blocked.release)
+		if n := <-done; n != 2 {
+			t.Fatalf("expected 2 events processed, got %d", n)
+		}
+		if len(blocked.inner.Events()) != 2 {
+			t.Fatalf("expected 2 events published once, got %d", len(blocked.inner.Events()))
+		}
+	})
+
+	t.Run("records corrupt events and keeps going", func(t *testing.T) {
+		c, db := newTestDB(t)
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{Topic: "a"}, interfaces.Event{Topic: "b"})
+		})
+		if _, err := db.Exec(`UPDATE outbox SET headers = 'not json' WHERE id = 1`); err != nil {
+			t.Fatalf("failed to corrupt event: %v", err)
+		}
+
+		pub := NewMemoryPublisher()
+		n, err := NewDispatcher(&strings.Builder{}, db, "libsql", pub).DispatchOnce(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error dispatching: %v", err)
+		}
+		if n != 2 {
+			t.Fatalf("expected 2 events processed, got %d", n)
+		}
+		if events := pub.Events(); len(events) != 1 || events[0].Topic != "b" {
+			t.Fatalf("expected only the valid event published, got %+v", events)
+		}
+
+		var attempts int
+		var lastError string
+		if err := db.QueryRow(`SELECT attempts, last_error FROM outbox WHERE id = 1`).Scan(&attempts, &lastError); err != nil {
+			t.Fatalf("failed to read corrupt event: %v", err)
+		}
+		if attempts != 1 || !strings.Contains(lastError, "failed to decode headers") {
+			t.Fatalf("expected decode error recorded, got %d attempts and %q", attempts, lastError)
+		}
+	})
+
+	t.Run("cancel releases the rest of the batch", func(t *testing.T) {
+		c, db := newTestDB(t)
+		_ = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{Topic: "a"}, interfaces.Event{Topic: "b"}, interfaces.Event{Topic: "c"})
+		})
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		pub := &cancelingPublisher{cancel: cancel, inner: NewMemoryPublisher()}
+		n, err := NewDispatcher(&strings.Builder{}, db, "libsql", pub).DispatchOnce(runCtx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled error, got %v", err)
+		}
+		if n != 1 {
+			t.Fatalf("expected 1 event processed, got %d", n)
+		}
+
+		var locked int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE locked_by IS NOT NULL`).Scan(&locked); err != nil {
+			t.Fatalf("failed to count locked events: %v", err)
+		}
+		if locked != 0 {
+			t.Fatalf("expected pending events released, got %d locked", locked)
+		}
+
+		n, err = NewDispatcher(&strings.Builder{}, db, "libsql", pub.inner).DispatchOnce(ctx)
+		if err != nil || n != 2 {
+			t.Fatalf("expected released events delivered next round, got %d (%v)", n, err)
+		}
+	})
+
+	t.Run("enqueue validates events", func(t *testing.T) {
+		c, _ := newTestDB(t)
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return Enqueue(ctx, tx, "libsql", interfaces.Event{})
+		})
+		if err == nil {
+			t.Fatal("expected error with empty topic")
+		}
+		if err := Enqueue(ctx, nil, "libsql", interfaces.Event{Topic: "a"}); err == nil {
+			t.Fatal("expected error with nil transaction")
+		}
+	})
+}
+
+func TestWriterPublisher(t *testing.T) {
+	out := &strings.Builder{}
+	pub := NewWriterPublisher(out)
+
+	err := pub.Publish(context.Background(), interfaces.Event{ID: 1, Topic: "bike.created", Payload: []byte(`{"model":"road"}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = pub.Publish(context.Background(), interfaces.Event{ID: 2, Topic: "bike.note", Payload: []byte("plain text")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out.String())
+	}
+
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid json line: %v", err)
+	}
+	if first["topic"] != "bike.created" || first["payload"].(map[string]any)["model"] != "road" {
+		t.Fatalf("unexpected first line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `"payload":"plain text"`) {
+		t.Fatalf("expected plain payload as string, got %q", lines[1])
+	}
+}
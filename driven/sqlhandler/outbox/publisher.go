@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// MemoryPublisher guarda los eventos publicados en memoria. Útil en tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []interfaces.Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event interfaces.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events retorna una copia de los eventos publicados, en orden de publicación.
+func (p *MemoryPublisher) Events() []interfaces.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.events)
+}
+
+// WriterPublisher escribe cada evento como una línea JSON en un io.Writer,
+// por ejemplo os.Stdout o un archivo.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	if w == nil {
+		panic(fmt.Sprintf("%s: writer cannot be nil", SigOutbox))
+	}
+	return &WriterPublisher{w: w}
+}
+
+type writtenEvent struct {
+	ID         int64             `json:"id"`
+	Topic      string            `json:"topic"`
+	Key        string            `json:"key,omitempty"`
+	Payload    json.RawMessage   `json:"payload,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, event interfaces.Event) error {
+	out := writtenEvent{
+		ID:         event.ID,
+		Topic:      event.Topic,
+		Key:        event.Key,
+		Headers:    event.Headers,
+		OccurredAt: event.OccurredAt,
+	}
+
+	// Los payloads JSON se escriben tal cual, el resto como string JSON
+	if len(event.Payload) > 0 {
+		if json.Valid(event.Payload) {
+			out.Payload = event.Payload
+		} else {
+			raw, err := json.Marshal(string(event.Payload))
+			if err != nil {
+				return fmt.Errorf("%s: failed to encode payload: %w", SigOutbox, err)
+			}
+			out.Payload = raw
+		}
+	}
+
+	line, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode event %d: %w", SigOutbox, event.ID, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("%s: failed to write event %d: %w", SigOutbox, event.ID, err)
+	}
+	return nil
+}
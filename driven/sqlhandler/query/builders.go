@@ -0,0 +1,271 @@
+package query
+
+type join struct {
+	kind  string
+	table string
+	left  string
+	right string
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+type SelectBuilder struct {
+	columns []string
+	count   bool
+	table   string
+	joins   []join
+	conds   []Cond
+	orders  []order
+	limit   int
+	offset  int
+}
+
+// Select inicia un SELECT de las columnas dadas, o de todas si no se entrega ninguna.
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// Count reemplaza las columnas por COUNT(*), para contar las filas que cumplen
+// las condiciones.
+func (s *SelectBuilder) Count() *SelectBuilder {
+	s.count = true
+	return s
+}
+
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.table = table
+	return s
+}
+
+// Join agrega un INNER JOIN de table donde left = right.
+func (s *SelectBuilder) Join(table, left, right string) *SelectBuilder {
+	s.joins = append(s.joins, join{"JOIN", table, left, right})
+	return s
+}
+
+// LeftJoin agrega un LEFT JOIN de table donde left = right.
+func (s *SelectBuilder) LeftJoin(table, left, right string) *SelectBuilder {
+	s.joins = append(s.joins, join{"LEFT JOIN", table, left, right})
+	return s
+}
+
+// Where agrega condiciones que se unen con AND a las anteriores.
+func (s *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	s.conds = append(s.conds, conds...)
+	return s
+}
+
+func (s *SelectBuilder) OrderBy(column string) *SelectBuilder {
+	s.orders = append(s.orders, order{column, false})
+	return s
+}
+
+func (s *SelectBuilder) OrderByDesc(column string) *SelectBuilder {
+	s.orders = append(s.orders, order{column, true})
+	return s
+}
+
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+func (s *SelectBuilder) Build(d Dialect) (string, []any, error) {
+	b := &buffer{dialect: d}
+	if s.table == "" {
+		b.fail("select needs a table")
+	}
+
+	b.write("SELECT ")
+	if s.count {
+		b.write("COUNT(*)")
+	} else if len(s.columns) == 0 {
+		b.write("*")
+	} else {
+		b.columns(s.columns)
+	}
+	b.write(" FROM ")
+	b.ident(s.table)
+
+	for _, j := range s.joins {
+		b.write(" ", j.kind, " ")
+		b.ident(j.table)
+		b.write(" ON ")
+		b.ident(j.left)
+		b.write(" = ")
+		b.ident(j.right)
+	}
+
+	where(b, s.conds)
+
+	for i, o := range s.orders {
+		if i == 0 {
+			b.write(" ORDER BY ")
+		} else {
+			b.write(", ")
+		}
+		b.ident(o.column)
+		if o.desc {
+			b.write(" DESC")
+		}
+	}
+
+	if s.limit < 0 || s.offset < 0 {
+		b.fail("limit and offset cannot be negative")
+	}
+	// Los valores de limit y offset son enteros validados, no necesitan placeholder
+	b.write(d.LimitOffset(s.limit, s.offset))
+
+	return b.result()
+}
+
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]any
+	returning []string
+}
+
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+func (i *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	i.columns = columns
+	return i
+}
+
+// Values agrega una fila, en el mismo orden de Columns.
+func (i *InsertBuilder) Values(values ...any) *InsertBuilder {
+	i.rows = append(i.rows, values)
+	return i
+}
+
+func (i *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	i.returning = columns
+	return i
+}
+
+func (i *InsertBuilder) Build(d Dialect) (string, []any, error) {
+	b := &buffer{dialect: d}
+	if len(i.columns) == 0 || len(i.rows) == 0 {
+		b.fail("insert into %q needs columns and values", i.table)
+	}
+
+	b.write("INSERT INTO ")
+	b.ident(i.table)
+	b.write(" (")
+	b.idents(i.columns)
+	b.write(") VALUES ")
+
+	for r, row := range i.rows {
+		if len(row) != len(i.columns) {
+			b.fail("insert row %d has %d values for %d columns", r, len(row), len(i.columns))
+		}
+		if r > 0 {
+			b.write(", ")
+		}
+		b.write("(")
+		for c, v := range row {
+			if c > 0 {
+				b.write(", ")
+			}
+			b.arg(v)
+		}
+		b.write(")")
+	}
+
+	returning(b, i.returning)
+	return b.result()
+}
+
+type set struct {
+	column string
+	value  any
+}
+
+type UpdateBuilder struct {
+	table     string
+	sets      []set
+	conds     []Cond
+	returning []string
+}
+
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+func (u *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	u.sets = append(u.sets, set{column, value})
+	return u
+}
+
+func (u *UpdateBuilder) Where(conds ...Cond) *UpdateBuilder {
+	u.conds = append(u.conds, conds...)
+	return u
+}
+
+func (u *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	u.returning = columns
+	return u
+}
+
+func (u *UpdateBuilder) Build(d Dialect) (string, []any, error) {
+	b := &buffer{dialect: d}
+	if len(u.sets) == 0 {
+		b.fail("update of %q needs at least one column", u.table)
+	}
+
+	b.write("UPDATE ")
+	b.ident(u.table)
+	b.write(" SET ")
+	for i, s := range u.sets {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.ident(s.column)
+		b.write(" = ")
+		b.arg(s.value)
+	}
+
+	where(b, u.conds)
+	returning(b, u.returning)
+	return b.result()
+}
+
+type DeleteBuilder struct {
+	table     string
+	conds     []Cond
+	returning []string
+}
+
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+func (del *DeleteBuilder) Where(conds ...Cond) *DeleteBuilder {
+	del.conds = append(del.conds, conds...)
+	return del
+}
+
+func (del *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	del.returning = columns
+	return del
+}
+
+func (del *DeleteBuilder) Build(d Dialect) (string, []any, error) {
+	b := &buffer{dialect: d}
+	b.write("DELETE FROM ")
+	b.ident(del.table)
+	where(b, del.conds)
+	returning(b, del.returning)
+	return b.result()
+}
@@ -0,0 +1,141 @@
+package query
+
+import "reflect"
+
+// Cond es una condición de un WHERE.
+type Cond interface {
+	build(b *buffer)
+}
+
+type compare struct {
+	column string
+	op     string
+	value  any
+}
+
+func (c compare) build(b *buffer) {
+	b.ident(c.column)
+	b.write(" ", c.op, " ")
+	b.arg(c.value)
+}
+
+func Eq(column string, value any) Cond   { return compare{column, "=", value} }
+func Ne(column string, value any) Cond   { return compare{column, "<>", value} }
+func Lt(column string, value any) Cond   { return compare{column, "<", value} }
+func Lte(column string, value any) Cond  { return compare{column, "<=", value} }
+func Gt(column string, value any) Cond   { return compare{column, ">", value} }
+func Gte(column string, value any) Cond  { return compare{column, ">=", value} }
+func Like(column string, value any) Cond { return compare{column, "LIKE", value} }
+
+type in struct {
+	column string
+	values any
+}
+
+// In compara column con cada elemento de values, que debe ser un slice no vacío.
+func In(column string, values any) Cond {
+	return in{column, values}
+}
+
+func (c in) build(b *buffer) {
+	values := reflect.ValueOf(c.values)
+	if values.Kind() != reflect.Slice || values.Len() == 0 {
+		b.fail("IN on %q needs a non empty slice", c.column)
+		return
+	}
+
+	b.ident(c.column)
+	b.write(" IN (")
+	for i := range values.Len() {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.arg(values.Index(i).Interface())
+	}
+	b.write(")")
+}
+
+type null struct {
+	column string
+	not    bool
+}
+
+func IsNull(column string) Cond  { return null{column, false} }
+func NotNull(column string) Cond { return null{column, true} }
+
+func (c null) build(b *buffer) {
+	b.ident(c.column)
+	if c.not {
+		b.write(" IS NOT NULL")
+		return
+	}
+	b.write(" IS NULL")
+}
+
+type group struct {
+	op    string
+	conds []Cond
+}
+
+func And(conds ...Cond) Cond { return group{"AND", conds} }
+func Or(conds ...Cond) Cond  { return group{"OR", conds} }
+
+func (g group) build(b *buffer) {
+	if len(g.conds) == 0 {
+		b.fail("%s needs at least one condition", g.op)
+		return
+	}
+
+	b.write("(")
+	for i, c := range g.conds {
+		if i > 0 {
+			b.write(" ", g.op, " ")
+		}
+		build(b, c)
+	}
+	b.write(")")
+}
+
+type not struct {
+	cond Cond
+}
+
+func Not(cond Cond) Cond { return not{cond} }
+
+func (n not) build(b *buffer) {
+	b.write("NOT (")
+	build(b, n.cond)
+	b.write(")")
+}
+
+// build escribe c, o registra un error si es nil en vez de entrar en pánico.
+func build(b *buffer, c Cond) {
+	if c == nil {
+		b.fail("condition cannot be nil")
+		return
+	}
+	c.build(b)
+}
+
+// where escribe las condiciones unidas con AND, si existen.
+func where(b *buffer, conds []Cond) {
+	if len(conds) == 0 {
+		return
+	}
+
+	b.write(" WHERE ")
+	for i, c := range conds {
+		if i > 0 {
+			b.write(" AND ")
+		}
+		build(b, c)
+	}
+}
+
+func returning(b *buffer, columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	b.write(" RETURNING ")
+	b.columns(columns)
+}
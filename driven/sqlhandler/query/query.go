@@ -0,0 +1,202 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+)
+
+const SigQuery string = "sqlhandler query"
+
+// Dialect define el estilo de placeholders de cada motor de base de datos.
+type Dialect int
+
+const (
+	// Question usa "?" como placeholder (SQLite, libsql, MySQL).
+	Question Dialect = iota
+	// Dollar usa "$1", "$2", ... como placeholder (Postgres).
+	Dollar
+)
+
+// DialectFor retorna el dialecto correspondiente al nombre de un driver de database/sql.
+func DialectFor(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case "postgres", "postgresql", "pgx", "pq":
+		return Dollar
+	}
+	return Question
+}
+
+// Rebind adapta una sentencia escrita con placeholders "?" al dialecto. Sirve para
+// SQL escrito a mano que no cabe en los builders. Los "?" dentro de texto entre
+// comillas simples, dobles o backticks, o de comentarios "--", no se tocan.
+func (d Dialect) Rebind(stmt string) string {
+	if d != Dollar {
+		return stmt
+	}
+	return sqlhandler.RebindDollar(stmt)
+}
+
+// LimitOffset retorna la cláusula LIMIT y OFFSET para el dialecto, o vacío si
+// ambos son cero. SQLite y MySQL no aceptan OFFSET sin LIMIT ni tienen una forma
+// común de pedir todas las filas, así que un offset sin limit usa el mayor LIMIT
+// que ambos aceptan.
+func (d Dialect) LimitOffset(limit, offset int) string {
+	var s string
+	if limit > 0 {
+		s = " LIMIT " + strconv.Itoa(limit)
+	}
+	if offset > 0 {
+		if limit <= 0 && d == Question {
+			s = " LIMIT " + strconv.FormatInt(math.MaxInt64, 10)
+		}
+		s += " OFFSET " + strconv.Itoa(offset)
+	}
+	return s
+}
+
+// Builder es implementado por todos los builders de este paquete.
+type Builder interface {
+	Build(d Dialect) (string, []any, error)
+}
+
+// validIdentifier acepta columnas o tablas simples, o calificadas con su tabla.
+func validIdentifier(name string) error {
+	table, column, qualified := strings.Cut(name, ".")
+	if !sqlhandler.ValidIdentifier(table) || (qualified && !sqlhandler.ValidIdentifier(column)) {
+		return fmt.Errorf("%s: unsafe identifier %q", SigQuery, name)
+	}
+	return nil
+}
+
+// buffer acumula el SQL, los argumentos y el primer error de validación.
+type buffer struct {
+	dialect Dialect
+	sb      strings.Builder
+	args    []any
+	err     error
+}
+
+func (b *buffer) write(parts ...string) {
+	for _, p := range parts {
+		b.sb.WriteString(p)
+	}
+}
+
+func (b *buffer) ident(name string) {
+	if err := validIdentifier(name); err != nil && b.err == nil {
+		b.err = err
+	}
+	b.sb.WriteString(name)
+}
+
+func (b *buffer) idents(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.ident(name)
+	}
+}
+
+// columns es como idents pero acepta "*" y "tabla.*", válidos solo en las listas
+// de columnas de SELECT y RETURNING.
+func (b *buffer) columns(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			b.write(", ")
+		}
+		if table, ok := strings.CutSuffix(name, "*"); ok && (table == "" || strings.HasSuffix(table, ".")) {
+			if table != "" {
+				b.ident(strings.TrimSuffix(table, "."))
+				b.write(".")
+			}
+			b.write("*")
+			continue
+		}
+		b.ident(name)
+	}
+}
+
+func (b *buffer) arg(v any) {
+	b.args = append(b.args, v)
+	if b.dialect == Dollar {
+		b.write("$", strconv.Itoa(len(b.args)))
+		return
+	}
+	b.write("?")
+}
+
+func (b *buffer) fail(format string, args ...any) {
+	if b.err == nil {
+		b.err = fmt.Errorf("%s: "+format, append([]any{SigQuery}, args...)...)
+	}
+}
+
+func (b *buffer) result() (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.sb.String(), b.args, nil
+}
+
+// Querier es la parte común de *sql.DB y *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Runner ejecuta builders sobre una conexión, usando la transacción del contexto
+// si fue creada con sqlhandler.WithTx y sqlhandler.ContextWithTx.
+type Runner struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewRunner crea un Runner para db con el dialecto dado.
+// Panics si db es nil.
+func NewRunner(db *sql.DB, dialect Dialect) *Runner {
+	if db == nil {
+		panic(fmt.Sprintf("%s: db cannot be nil", SigQuery))
+	}
+	return &Runner{db: db, dialect: dialect}
+}
+
+func (r *Runner) querier(ctx context.Context) Querier {
+	if tx, ok := sqlhandler.TxFromContext(ctx); ok {
+		return tx
+	}
+	return r.db
+}
+
+func (r *Runner) Exec(ctx context.Context, b Builder) (sql.Result, error) {
+	query, args, err := b.Build(r.dialect)
+	if err != nil {
+		return nil, err
+	}
+	return r.querier(ctx).ExecContext(ctx, query, args...)
+}
+
+func (r *Runner) Query(ctx context.Context, b Builder) (*sql.Rows, error) {
+	query, args, err := b.Build(r.dialect)
+	if err != nil {
+		return nil, err
+	}
+	return r.querier(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRow retorna un error en vez de *sql.Row si el builder es inválido,
+// ya que *sql.Row no permite construir un error propio.
+func (r *Runner) QueryRow(ctx context.Context, b Builder) (*sql.Row, error) {
+	query, args, err := b.Build(r.dialect)
+	if err != nil {
+		return nil, err
+	}
+	return r.querier(ctx).QueryRowContext(ctx, query, args...), nil
+}
@@ -0,0 +1,208 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+func TestBuild(t *testing.T) {
+	cases := []struct {
+		name     string
+		builder  Builder
+		dialect  Dialect
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name: "select with join, where, order and limit",
+			builder: Select("bikes.id", "riders.name").
+				From("bikes").
+				Join("riders", "riders.id", "bikes.rider_id").
+				Where(Gte("bikes.gears", 12), Or(Eq("riders.name", "ana"), Like("riders.name", "b%"))).
+				OrderByDesc("bikes.gears").
+				OrderBy("riders.name").
+				Limit(10).
+				Offset(20),
+			dialect:  Question,
+			wantSQL:  "SELECT bikes.id, riders.name FROM bikes JOIN riders ON riders.id = bikes.rider_id WHERE bikes.gears >= ? AND (riders.name = ? OR riders.name LIKE ?) ORDER BY bikes.gears DESC, riders.name LIMIT 10 OFFSET 20",
+			wantArgs: []any{12, "ana", "b%"},
+		},
+		{
+			name:     "select with dollar placeholders",
+			builder:  Select().From("bikes").Where(In("id", []int{1, 2, 3}), NotNull("model"), Not(Eq("gears", 1))),
+			dialect:  Dollar,
+			wantSQL:  "SELECT * FROM bikes WHERE id IN ($1, $2, $3) AND model IS NOT NULL AND NOT (gears = $4)",
+			wantArgs: []any{1, 2, 3, 1},
+		},
+		{
+			name:     "offset without limit on sqlite and mysql",
+			builder:  Select("id").From("bikes").Offset(5),
+			dialect:  Question,
+			wantSQL:  "SELECT id FROM bikes LIMIT 9223372036854775807 OFFSET 5",
+			wantArgs: nil,
+		},
+		{
+			name:     "offset without limit on postgres",
+			builder:  Select("bikes.*").From("bikes").Offset(5),
+			dialect:  Dollar,
+			wantSQL:  "SELECT bikes.* FROM bikes OFFSET 5",
+			wantArgs: nil,
+		},
+		{
+			name:     "count",
+			builder:  Select().Count().From("bikes").Where(Gt("gears", 1)),
+			dialect:  Dollar,
+			wantSQL:  "SELECT COUNT(*) FROM bikes WHERE gears > $1",
+			wantArgs: []any{1},
+		},
+		{
+			name:     "insert many rows returning",
+			builder:  Insert("bikes").Columns("model", "gears").Values("road", 22).Values("bmx", 1).Returning("id"),
+			dialect:  Dollar,
+			wantSQL:  "INSERT INTO bikes (model, gears) VALUES ($1, $2), ($3, $4) RETURNING id",
+			wantArgs: []any{"road", 22, "bmx", 1},
+		},
+		{
+			name:     "update",
+			builder:  Update("bikes").Set("gears", 11).Set("model", "gravel").Where(Eq("id", 7)),
+			dialect:  Question,
+			wantSQL:  "UPDATE bikes SET gears = ?, model = ? WHERE id = ?",
+			wantArgs: []any{11, "gravel", 7},
+		},
+		{
+			name:     "delete returning",
+			builder:  Delete("bikes").Where(Lt("gears", 2)).Returning("id", "model"),
+			dialect:  Dollar,
+			wantSQL:  "DELETE FROM bikes WHERE gears < $1 RETURNING id, model",
+			wantArgs: []any{2},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args, err := tc.builder.Build(tc.dialect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if query != tc.wantSQL {
+				t.Fatalf("unexpected sql\n got: %s\nwant: %s", query, tc.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tc.wantArgs) {
+				t.Fatalf("unexpected args %v, want %v", args, tc.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildRejectsUnsafeInput(t *testing.T) {
+	cases := map[string]Builder{
+		"table injection":  Select().From("bikes; DROP TABLE bikes"),
+		"column injection": Select("id, (SELECT password FROM users)").From("bikes"),
+		"where column":     Select().From("bikes").Where(Eq("1=1 OR id", 1)),
+		"order column":     Select().From("bikes").OrderBy("gears DESC; --"),
+		"join column":      Select().From("bikes").Join("riders", "riders.id", "1 OR 1"),
+		"empty in":         Select().From("bikes").Where(In("id", []int{})),
+		"insert mismatch":  Insert("bikes").Columns("model", "gears").Values("road"),
+		"update no sets":   Update("bikes").Where(Eq("id", 1)),
+		"returning column": Delete("bikes").Returning("id; --"),
+		"negative limit":   Select().From("bikes").Limit(-1),
+		"star table":       Select().From("*"),
+		"star where":       Select().From("bikes").Where(Eq("*", 1)),
+		"star insert":      Insert("bikes").Columns("*").Values(1),
+		"star order":       Select().From("bikes").OrderBy("bikes.*"),
+		"nil not":          Select().From("bikes").Where(Not(nil)),
+		"nil or":           Select().From("bikes").Where(Or(Eq("id", 1), nil)),
+		"nil where":        Delete("bikes").Where(nil),
+	}
+
+	for name, builder := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := builder.Build(Question); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	if DialectFor("libsql") != Question || DialectFor("pgx") != Dollar || DialectFor("postgres") != Dollar {
+		t.Fatal("unexpected dialect mapping")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	if got := Question.Rebind("a = ? AND b = ?"); got != "a = ? AND b = ?" {
+		t.Fatalf("unexpected question query %q", got)
+	}
+	if got := Dollar.Rebind("a = ? AND b = ?"); got != "a = $1 AND b = $2" {
+		t.Fatalf("unexpected dollar query %q", got)
+	}
+
+	stmt := `SELECT '?', 'it''s ?', "odd?col" FROM t WHERE a = ? AND b LIKE '%?' AND c = ?`
+	want := `SELECT '?', 'it''s ?', "odd?col" FROM t WHERE a = $1 AND b LIKE '%?' AND c = $2`
+	if got := Dollar.Rebind(stmt); got != want {
+		t.Fatalf("expected quoted text untouched\n got: %s\nwant: %s", got, want)
+	}
+
+	stmt = "SELECT a -- why?\nFROM t WHERE a = ? -- and b = ?\nAND c - ? > 0"
+	want = "SELECT a -- why?\nFROM t WHERE a = $1 -- and b = ?\nAND c - $2 > 0"
+	if got := Dollar.Rebind(stmt); got != want {
+		t.Fatalf("expected comments untouched\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestRunnerUsesTransaction(t *testing.T) {
+	dbURL := "file:" + filepath.Join(t.TempDir(), "test.db")
+	c := sqlhandler.NewConnector(&strings.Builder{}, sqlhandler.WithURL(dbURL))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.DB().Exec(`CREATE TABLE bikes (id INTEGER PRIMARY KEY, model TEXT NOT NULL)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	r := NewRunner(c.DB(), DialectFor("libsql"))
+	ctx := context.Background()
+
+	errAbort := errors.New("abort")
+	err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		txCtx := sqlhandler.ContextWithTx(ctx, tx)
+		if _, err := r.Exec(txCtx, Insert("bikes").Columns("model").Values("road")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+
+	if _, err := r.Exec(ctx, Insert("bikes").Columns("model").Values("gravel")); err != nil {
+		t.Fatalf("unexpected error inserting: %v", err)
+	}
+
+	row, err := r.QueryRow(ctx, Select("model").From("bikes").Where(Eq("id", 1)))
+	if err != nil {
+		t.Fatalf("unexpected error building: %v", err)
+	}
+	var model string
+	if err := row.Scan(&model); err != nil {
+		t.Fatalf("unexpected error scanning: %v", err)
+	}
+	if model != "gravel" {
+		t.Fatalf("expected rolled back insert to be discarded, got %s", model)
+	}
+
+	if _, err := r.Query(ctx, Select().From("bikes;")); err == nil {
+		t.Fatal("expected error with unsafe table")
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/internal/structmap"
+)
+
+type column struct {
+	name  string
+	field string
+	index []int
+}
+
+// entityMeta describe cómo se mapea un struct a las columnas de una tabla.
+type entityMeta struct {
+	typ     reflect.Type
+	columns []column
+	pk      int
+}
+
+var metaCache sync.Map
+
+// metaFor retorna la metadata de T, calculándola una sola vez por tipo.
+// Los structs se mapean con el tag `db:"column"`, `db:"column,pk"` o `db:"-"`;
+// los campos sin tag usan su nombre en snake_case.
+func metaFor[T any]() (*entityMeta, error) {
+	typ := reflect.TypeFor[T]()
+	if cached, ok := metaCache.Load(typ); ok {
+		return cached.(*entityMeta), nil
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s: entity must be a struct, got %s", SigRepo, typ)
+	}
+
+	meta := &entityMeta{typ: typ, pk: -1}
+	for _, f := range structmap.Fields(typ) {
+		if !sqlhandler.ValidIdentifier(f.Column) {
+			return nil, fmt.Errorf("%s: invalid column name %q on field %s", SigRepo, f.Column, f.Name)
+		}
+		if f.Flags == "pk" {
+			if meta.pk >= 0 {
+				return nil, fmt.Errorf("%s: entity %s has more than one primary key", SigRepo, typ)
+			}
+			meta.pk = len(meta.columns)
+		}
+		meta.columns = append(meta.columns, column{name: f.Column, field: f.Name, index: f.Index})
+	}
+	if meta.pk < 0 {
+		return nil, fmt.Errorf("%s: entity %s has no primary key, tag one field with `db:\"name,pk\"`", SigRepo, typ)
+	}
+
+	cached, _ := metaCache.LoadOrStore(typ, meta)
+	return cached.(*entityMeta), nil
+}
+
+// lookup busca una columna por nombre de columna o de campo.
+func (m *entityMeta) lookup(name string) (column, bool) {
+	for _, c := range m.columns {
+		if c.name == name || c.field == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+func (m *entityMeta) pkColumn() column {
+	return m.columns[m.pk]
+}
+
+func (m *entityMeta) value(entity reflect.Value, c column) reflect.Value {
+	return structmap.FieldByIndex(entity, c.index)
+}
+
+// isAutoID indica si la pk es un entero en cero, en cuyo caso la genera la base de datos.
+func isAutoID(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.IsZero()
+	}
+	return false
+}
+
+func setAutoID(v reflect.Value, id int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(id))
+	}
+}
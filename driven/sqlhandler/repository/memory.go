@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// Snapshotter es implementado por los repositorios en memoria para que
+// MemoryUnitOfWork pueda deshacer sus cambios.
+type Snapshotter interface {
+	Snapshot() (restore func())
+}
+
+type memoryUoWKey struct{}
+
+// MemoryUnitOfWork deshace los cambios de los repositorios registrados si fn falla.
+// Las unidades de trabajo se ejecutan de a una, igual que una base de datos serializable.
+type MemoryUnitOfWork struct {
+	mu     sync.Mutex
+	stores []Snapshotter
+}
+
+func NewMemoryUnitOfWork(stores ...Snapshotter) *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{stores: stores}
+}
+
+func (u *MemoryUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	// Las llamadas anidadas ya tienen el lock, solo necesitan su propio snapshot
+	if ctx.Value(memoryUoWKey{}) != u {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		ctx = context.WithValue(ctx, memoryUoWKey{}, u)
+	}
+
+	restores := make([]func(), len(u.stores))
+	for i, s := range u.stores {
+		restores[i] = s.Snapshot()
+	}
+	rollback := func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			rollback()
+			err = fmt.Errorf("%s: panic inside unit of work: %v", SigRepo, r)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+
+// MemoryRepository implementa interfaces.Repository en memoria, pensado para tests.
+// Usa el mismo mapeo de tags que SQLRepository.
+type MemoryRepository[T any, K comparable] struct {
+	mu     sync.RWMutex
+	meta   *entityMeta
+	items  map[K]T
+	nextID int64
+}
+
+// NewMemory crea un repositorio en memoria para T.
+// Panics si T no se puede mapear.
+func NewMemory[T any, K comparable]() *MemoryRepository[T, K] {
+	meta, err := metaFor[T]()
+	if err != nil {
+		panic(err.Error())
+	}
+	return &MemoryRepository[T, K]{meta: meta, items: map[K]T{}}
+}
+
+func (r *MemoryRepository[T, K]) Snapshot() func() {
+	r.mu.RLock()
+	items := maps.Clone(r.items)
+	nextID := r.nextID
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.items = items
+		r.nextID = nextID
+	}
+}
+
+func (r *MemoryRepository[T, K]) id(entity *T) (reflect.Value, K) {
+	pk := r.meta.value(reflect.ValueOf(entity).Elem(), r.meta.pkColumn())
+	return pk, pk.Interface().(K)
+}
+
+func (r *MemoryRepository[T, K]) Create(ctx context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pk, id := r.id(entity)
+	if isAutoID(pk) {
+		r.nextID++
+		setAutoID(pk, r.nextID)
+		id = pk.Interface().(K)
+	}
+
+	if _, ok := r.items[id]; ok {
+		return fmt.Errorf("%s: entity %v already exists", SigRepo, id)
+	}
+	r.items[id] = *entity
+	return nil
+}
+
+func (r *MemoryRepository[T, K]) Get(ctx context.Context, id K) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entity, ok := r.items[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: entity %v: %w", SigRepo, id, interfaces.ErrNotFound)
+	}
+	return &entity, nil
+}
+
+func (r *MemoryRepository[T, K]) Update(ctx context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, id := r.id(entity)
+	if _, ok := r.items[id]; !ok {
+		return fmt.Errorf("%s: entity %v: %w", SigRepo, id, interfaces.ErrNotFound)
+	}
+	r.items[id] = *entity
+	return nil
+}
+
+func (r *MemoryRepository[T, K]) Delete(ctx context.Context, id K) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[id]; !ok {
+		return fmt.Errorf("%s: entity %v: %w", SigRepo, id, interfaces.ErrNotFound)
+	}
+	delete(r.items, id)
+	return nil
+}
+
+func (r *MemoryRepository[T, K]) List(ctx context.Context, q interfaces.ListQuery) (interfaces.Page[T], error) {
+	page := interfaces.Page[T]{Items: []T{}, Limit: q.Limit, Offset: q.Offset}
+
+	for _, f := range q.Filters {
+		if _, ok := r.meta.lookup(f.Field); !ok {
+			return page, fmt.Errorf("%s: unknown filter field %q", SigRepo, f.Field)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []T
+	for _, entity := range r.items {
+		ok, err := r.matches(&entity, q.Filters)
+		if err != nil {
+			return page, err
+		}
+		if ok {
+			matched = append(matched, entity)
+		}
+	}
+
+	// Sin OrderBy ordenamos por pk para que la paginación sea estable
+	order := r.meta.pkColumn()
+	if q.OrderBy != "" {
+		c, ok := r.meta.lookup(q.OrderBy)
+		if !ok {
+			return page, fmt.Errorf("%s: unknown order field %q", SigRepo, q.OrderBy)
+		}
+		order = c
+	}
+	slices.SortStableFunc(matched, func(a, b T) int {
+		c := compareValues(r.meta.value(reflect.ValueOf(&a).Elem(), order), r.meta.value(reflect.ValueOf(&b).Elem(), order))
+		if q.Desc {
+			return -c
+		}
+		return c
+	})
+
+	page.Total = len(matched)
+	start := min(q.Offset, len(matched))
+	end := len(matched)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, end)
+	}
+	page.Items = append(page.Items, matched[start:end]...)
+	return page, nil
+}
+
+func (r *MemoryRepository[T, K]) matches(entity *T, filters []interfaces.Filter) (bool, error) {
+	v := reflect.ValueOf(entity).Elem()
+	for _, f := range filters {
+		c, ok := r.meta.lookup(f.Field)
+		if !ok {
+			return false, fmt.Errorf("%s: unknown filter field %q", SigRepo, f.Field)
+		}
+		field := r.meta.value(v, c)
+
+		var match bool
+		switch f.Op {
+		case interfaces.OpEq:
+			match = compareValues(field, reflect.ValueOf(f.Value)) == 0
+		case interfaces.OpNe:
+			match = compareValues(field, reflect.ValueOf(f.Value)) != 0
+		case interfaces.OpLt:
+			match = compareValues(field, reflect.ValueOf(f.Value)) < 0
+		case interfaces.OpLte:
+			match = compareValues(field, reflect.ValueOf(f.Value)) <= 0
+		case interfaces.OpGt:
+			match = compareValues(field, reflect.ValueOf(f.Value)) > 0
+		case interfaces.OpGte:
+			match = compareValues(field, reflect.ValueOf(f.Value)) >= 0
+		case interfaces.OpLike:
+			pattern, ok := f.Value.(string)
+			if !ok {
+				return false, fmt.Errorf("%s: LIKE filter on %q needs a string", SigRepo, f.Field)
+			}
+			match = likeRX(pattern).MatchString(fmt.Sprint(field.Interface()))
+		case interfaces.OpIn:
+			values := reflect.ValueOf(f.Value)
+			if values.Kind() != reflect.Slice || values.Len() == 0 {
+				return false, fmt.Errorf("%s: IN filter on %q needs a non empty slice", SigRepo, f.Field)
+			}
+			for i := range values.Len() {
+				if compareValues(field, values.Index(i)) == 0 {
+					match = true
+					break
+				}
+			}
+		default:
+			return false, fmt.Errorf("%s: unsupported filter operator %q", SigRepo, f.Op)
+		}
+
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareValues compara números, strings y bools de distintos tipos concretos,
+// de la misma forma en que lo haría la base de datos.
+func compareValues(a, b reflect.Value) int {
+	for a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Pointer || b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+
+	switch {
+	case isNumber(a) && isNumber(b):
+		return cmp.Compare(toFloat(a), toFloat(b))
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case a.Kind() == reflect.Bool && b.Kind() == reflect.Bool:
+		return cmp.Compare(toFloat(a), toFloat(b))
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		if v.Bool() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// likeRX traduce un patrón LIKE de SQL (% y _) a una expresión regular.
+func likeRX(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/interfaces"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+type Paint struct {
+	Color string `db:"color"`
+}
+
+type Bike struct {
+	ID    int64  `db:"id,pk"`
+	Model string `db:"model"`
+	Gears int
+	Notes string `db:"-"`
+	Paint
+}
+
+type repoFactory func(t *testing.T) (interfaces.Repository[Bike, int64], interfaces.UnitOfWork)
+
+func newSQLRepo(t *testing.T) (interfaces.Repository[Bike, int64], interfaces.UnitOfWork) {
+	dbURL := "file:" + filepath.Join(t.TempDir(), "test.db")
+	c := sqlhandler.NewConnector(&strings.Builder{}, sqlhandler.WithURL(dbURL))
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	_, err := c.DB().Exec(`
+        CREATE TABLE bikes (
+            id INTEGER PRIMARY KEY,
+            model TEXT NOT NULL,
+            gears INTEGER NOT NULL,
+            color TEXT NOT NULL
+        )
+    `)
+	if err != nil {
+		t.Fatalf("failed to create bikes table: %v", err)
+	}
+
+	return NewSQL[Bike, int64](c.DB(), "libsql", "bikes"), NewSQLUnitOfWork(c)
+}
+
+func newMemoryRepo(t *testing.T) (interfaces.Repository[Bike, int64], interfaces.UnitOfWork) {
+	repo := NewMemory[Bike, int64]()
+	return repo, NewMemoryUnitOfWork(repo)
+}
+
+func TestRepositories(t *testing.T) {
+	factories := map[string]repoFactory{
+		"sql":    newSQLRepo,
+		"memory": newMemoryRepo,
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			runRepositoryContract(t, factory)
+		})
+	}
+}
+
+func seedBikes(t *testing.T, repo interfaces.Repository[Bike, int64]) {
+	ctx := context.Background()
+	for i, model := range []string{"road", "gravel", "mountain", "bmx"} {
+		b := &Bike{Model: model, Gears: (i + 1) * 6, Paint: Paint{Color: "red"}}
+		if err := repo.Create(ctx, b); err != nil {
+			t.Fatalf("failed to create bike: %v", err)
+		}
+		if b.ID == 0 {
+			t.Fatal("expected generated id")
+		}
+	}
+}
+
+func runRepositoryContract(t *testing.T, factory repoFactory) {
+	ctx := context.Background()
+
+	t.Run("crud", func(t *testing.T) {
+		repo, _ := factory(t)
+		b := &Bike{Model: "road", Gears: 22, Paint: Paint{Color: "red"}}
+		if err := repo.Create(ctx, b); err != nil {
+			t.Fatalf("unexpected error creating: %v", err)
+		}
+
+		got, err := repo.Get(ctx, b.ID)
+		if err != nil {
+			t.Fatalf("unexpected error getting: %v", err)
+		}
+		if got.Model != "road" || got.Gears != 22 || got.Color != "red" {
+			t.Fatalf("unexpected bike %+v", got)
+		}
+
+		got.Gears = 11
+		if err := repo.Update(ctx, got); err != nil {
+			t.Fatalf("unexpected error updating: %v", err)
+		}
+		got, _ = repo.Get(ctx, b.ID)
+		if got.Gears != 11 {
+			t.Fatalf("expected 11 gears, got %d", got.Gears)
+		}
+
+		if err := repo.Delete(ctx, b.ID); err != nil {
+			t.Fatalf("unexpected error deleting: %v", err)
+		}
+		if _, err := repo.Get(ctx, b.ID); !errors.Is(err, interfaces.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		if err := repo.Delete(ctx, b.ID); !errors.Is(err, interfaces.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound on second delete, got %v", err)
+		}
+	})
+
+	t.Run("list with filters and pagination", func(t *testing.T) {
+		repo, _ := factory(t)
+		seedBikes(t, repo)
+
+		page, err := repo.List(ctx, interfaces.ListQuery{
+			Filters: []interfaces.Filter{{Field: "gears", Op: interfaces.OpGte, Value: 12}},
+			OrderBy: "Gears",
+			Desc:    true,
+			Limit:   2,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error listing: %v", err)
+		}
+		if page.Total != 3 {
+			t.Fatalf("expected total 3, got %d", page.Total)
+		}
+		if len(page.Items) != 2 || page.Items[0].Model != "bmx" || page.Items[1].Model != "mountain" {
+			t.Fatalf("unexpected page %+v", page.Items)
+		}
+
+		page, err = repo.List(ctx, interfaces.ListQuery{
+			Filters: []interfaces.Filter{
+				{Field: "model", Op: interfaces.OpIn, Value: []string{"road", "gravel", "bmx"}},
+				{Field: "model", Op: interfaces.OpLike, Value: "%r%"},
+			},
+			OrderBy: "id",
+			Offset:  1,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error listing: %v", err)
+		}
+		if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Model != "gravel" {
+			t.Fatalf("unexpected page total %d items %+v", page.Total, page.Items)
+		}
+	})
+
+	t.Run("list rejects unknown fields", func(t *testing.T) {
+		repo, _ := factory(t)
+		_, err := repo.List(ctx, interfaces.ListQuery{
+			Filters: []interfaces.Filter{{Field: "model; DROP TABLE bikes", Op: interfaces.OpEq, Value: 1}},
+		})
+		if err == nil {
+			t.Fatal("expected error with unknown field")
+		}
+		if _, err := repo.List(ctx, interfaces.ListQuery{OrderBy: "notes"}); err == nil {
+			t.Fatal("expected error with ignored order field")
+		}
+	})
+
+	t.Run("unit of work rolls back", func(t *testing.T) {
+		repo, uow := factory(t)
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, &Bike{Model: "tandem", Gears: 8}); err != nil {
+				return err
+			}
+			return errors.New("abort")
+		})
+		if err == nil {
+			t.Fatal("expected error from unit of work")
+		}
+
+		page, err := repo.List(ctx, interfaces.ListQuery{})
+		if err != nil {
+			t.Fatalf("unexpected error listing: %v", err)
+		}
+		if page.Total != 0 {
+			t.Fatalf("expected rollback, got %d bikes", page.Total)
+		}
+	})
+
+	t.Run("nested unit of work", func(t *testing.T) {
+		repo, uow := factory(t)
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, &Bike{Model: "tandem", Gears: 8}); err != nil {
+				return err
+			}
+			nested := uow.Do(ctx, func(ctx context.Context) error {
+				if err := repo.Create(ctx, &Bike{Model: "unicycle", Gears: 1}); err != nil {
+					return err
+				}
+				return errors.New("abort nested")
+			})
+			if nested == nil {
+				t.Error("expected nested error")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		page, _ := repo.List(ctx, interfaces.ListQuery{})
+		if page.Total != 1 || page.Items[0].Model != "tandem" {
+			t.Fatalf("expected only tandem, got %+v", page.Items)
+		}
+	})
+}
+
+func TestNewSQLPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic with nil db")
+		}
+	}()
+	NewSQL[Bike, int64](nil, "libsql", "bikes")
+}
+
+// recordingDriver guarda las sentencias que recibe y falla al prepararlas, para
+// revisar el SQL generado para un dialecto sin tener esa base de datos.
+type recordingDriver struct {
+	stmts *[]string
+}
+
+func (d recordingDriver) Open(name string) (driver.Conn, error) {
+	return recordingConn(d), nil
+}
+
+type recordingConn recordingDriver
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	*c.stmts = append(*c.stmts, query)
+	return nil, errors.New("recording driver")
+}
+
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return nil, errors.New("recording driver") }
+
+var recorded []string
+
+func init() {
+	sql.Register("repository-recording", recordingDriver{stmts: &recorded})
+}
+
+func TestSQLUsesDialect(t *testing.T) {
+	db, err := sql.Open("repository-recording", "")
+	if err != nil {
+		t.Fatalf("unexpected error opening: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := NewSQL[Bike, int64](db, "postgres", "bikes")
+	recorded = nil
+
+	repo.Get(ctx, 1)
+	repo.Create(ctx, &Bike{Model: "road"})
+	repo.List(ctx, interfaces.ListQuery{Filters: []interfaces.Filter{
+		{Field: "gears", Op: interfaces.OpGte, Value: 6},
+		{Field: "model", Op: interfaces.OpIn, Value: []string{"road", "bmx"}},
+	}})
+
+	want := []string{
+		"SELECT id, model, gears, color FROM bikes WHERE id = $1",
+		"INSERT INTO bikes (model, gears, color) VALUES ($1, $2, $3) RETURNING id",
+		"SELECT COUNT(*) FROM bikes WHERE gears >= $1 AND model IN ($2, $3)",
+	}
+	if strings.Join(recorded, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected statements\n got: %q\nwant: %q", recorded, want)
+	}
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler"
+	"github.com/go-on-bike/bike/driven/sqlhandler/query"
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigRepo string = "sqlhandler repository"
+
+// TxRunner es implementado por sqlhandler.Connector y sqlhandler.SQLHandler.
+type TxRunner interface {
+	WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error
+}
+
+// SQLUnitOfWork comparte una transacción entre los repositorios SQL usados dentro de Do.
+type SQLUnitOfWork struct {
+	runner TxRunner
+}
+
+// NewSQLUnitOfWork crea una unidad de trabajo sobre un Connector o SQLHandler.
+// Panics si runner es nil.
+func NewSQLUnitOfWork(runner TxRunner) *SQLUnitOfWork {
+	if runner == nil {
+		panic(fmt.Sprintf("%s: tx runner cannot be nil", SigRepo))
+	}
+	return &SQLUnitOfWork{runner: runner}
+}
+
+// Do ejecuta fn en una transacción. Las llamadas anidadas usan savepoints.
+func (u *SQLUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.runner.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		return fn(sqlhandler.ContextWithTx(ctx, tx))
+	})
+}
+
+// SQLRepository implementa interfaces.Repository sobre una tabla SQL.
+type SQLRepository[T any, K comparable] struct {
+	runner  *query.Runner
+	dialect query.Dialect
+	table   string
+	meta    *entityMeta
+}
+
+// NewSQL crea un repositorio para T sobre table. driver es el nombre del driver
+// de database/sql y define el estilo de placeholders.
+// Panics si db es nil, el nombre de la tabla es inválido o T no se puede mapear,
+// ya que esto representa un error de programación.
+func NewSQL[T any, K comparable](db *sql.DB, driver, table string) *SQLRepository[T, K] {
+	if db == nil {
+		panic(fmt.Sprintf("%s: db cannot be nil", SigRepo))
+	}
+	if !sqlhandler.ValidIdentifier(table) {
+		panic(fmt.Sprintf("%s: invalid table name %q", SigRepo, table))
+	}
+
+	meta, err := metaFor[T]()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	dialect := query.DialectFor(driver)
+	return &SQLRepository[T, K]{runner: query.NewRunner(db, dialect), dialect: dialect, table: table, meta: meta}
+}
+
+func (r *SQLRepository[T, K]) Create(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+	pk := r.meta.value(v, r.meta.pkColumn())
+	auto := isAutoID(pk)
+
+	var cols []string
+	var args []any
+	for i, c := range r.meta.columns {
+		if i == r.meta.pk && auto {
+			continue
+		}
+		cols = append(cols, c.name)
+		args = append(args, r.meta.value(v, c).Interface())
+	}
+	insert := query.Insert(r.table).Columns(cols...).Values(args...)
+
+	// Postgres no implementa LastInsertId, el id generado se pide con RETURNING
+	if auto && r.dialect == query.Dollar {
+		row, err := r.runner.QueryRow(ctx, insert.Returning(r.meta.pkColumn().name))
+		if err == nil {
+			err = row.Scan(pk.Addr().Interface())
+		}
+		if err != nil {
+			return fmt.Errorf("%s: failed to insert into %s: %w", SigRepo, r.table, err)
+		}
+		return nil
+	}
+
+	res, err := r.runner.Exec(ctx, insert)
+	if err != nil {
+		return fmt.Errorf("%s: failed to insert into %s: %w", SigRepo, r.table, err)
+	}
+
+	if auto {
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("%s: failed to get inserted id on %s: %w", SigRepo, r.table, err)
+		}
+		setAutoID(pk, id)
+	}
+	return nil
+}
+
+func (r *SQLRepository[T, K]) Get(ctx context.Context, id K) (*T, error) {
+	entity := new(T)
+	row, err := r.runner.QueryRow(ctx, query.Select(r.columnNames()...).From(r.table).Where(query.Eq(r.meta.pkColumn().name, id)))
+	if err == nil {
+		err = row.Scan(r.targets(entity)...)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %s %v: %w", SigRepo, r.table, id, interfaces.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get from %s: %w", SigRepo, r.table, err)
+	}
+	return entity, nil
+}
+
+func (r *SQLRepository[T, K]) Update(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+
+	pk := r.meta.pkColumn()
+	id := r.meta.value(v, pk).Interface()
+	update := query.Update(r.table).Where(query.Eq(pk.name, id))
+	for i, c := range r.meta.columns {
+		if i == r.meta.pk {
+			continue
+		}
+		update.Set(c.name, r.meta.value(v, c).Interface())
+	}
+
+	res, err := r.runner.Exec(ctx, update)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update %s: %w", SigRepo, r.table, err)
+	}
+	return r.checkAffected(res, id)
+}
+
+func (r *SQLRepository[T, K]) Delete(ctx context.Context, id K) error {
+	res, err := r.runner.Exec(ctx, query.Delete(r.table).Where(query.Eq(r.meta.pkColumn().name, id)))
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete from %s: %w", SigRepo, r.table, err)
+	}
+	return r.checkAffected(res, id)
+}
+
+func (r *SQLRepository[T, K]) checkAffected(res sql.Result, id any) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows on %s: %w", SigRepo, r.table, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %s %v: %w", SigRepo, r.table, id, interfaces.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *SQLRepository[T, K]) List(ctx context.Context, q interfaces.ListQuery) (interfaces.Page[T], error) {
+	page := interfaces.Page[T]{Items: []T{}, Limit: q.Limit, Offset: q.Offset}
+
+	conds, err := r.conds(q.Filters)
+	if err != nil {
+		return page, err
+	}
+
+	row, err := r.runner.QueryRow(ctx, query.Select().Count().From(r.table).Where(conds...))
+	if err == nil {
+		err = row.Scan(&page.Total)
+	}
+	if err != nil {
+		return page, fmt.Errorf("%s: failed to count %s: %w", SigRepo, r.table, err)
+	}
+
+	sel := query.Select(r.columnNames()...).From(r.table).Where(conds...).Limit(q.Limit).Offset(q.Offset)
+	if q.OrderBy != "" {
+		c, ok := r.meta.lookup(q.OrderBy)
+		if !ok {
+			return page, fmt.Errorf("%s: unknown order field %q", SigRepo, q.OrderBy)
+		}
+		if q.Desc {
+			sel.OrderByDesc(c.name)
+		} else {
+			sel.OrderBy(c.name)
+		}
+	}
+
+	rows, err := r.runner.Query(ctx, sel)
+	if err != nil {
+		return page, fmt.Errorf("%s: failed to list %s: %w", SigRepo, r.table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entity T
+		if err := rows.Scan(r.targets(&entity)...); err != nil {
+			return page, fmt.Errorf("%s: failed to scan %s: %w", SigRepo, r.table, err)
+		}
+		page.Items = append(page.Items, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return page, fmt.Errorf("%s: failed to iterate %s: %w", SigRepo, r.table, err)
+	}
+	return page, nil
+}
+
+// conds traduce los filtros a condiciones de query, validando que cada campo sea
+// una columna conocida.
+func (r *SQLRepository[T, K]) conds(filters []interfaces.Filter) ([]query.Cond, error) {
+	conds := make([]query.Cond, 0, len(filters))
+	for _, f := range filters {
+		c, ok := r.meta.lookup(f.Field)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown filter field %q", SigRepo, f.Field)
+		}
+
+		switch f.Op {
+		case interfaces.OpEq:
+			conds = append(conds, query.Eq(c.name, f.Value))
+		case interfaces.OpNe:
+			conds = append(conds, query.Ne(c.name, f.Value))
+		case interfaces.OpLt:
+			conds = append(conds, query.Lt(c.name, f.Value))
+		case interfaces.OpLte:
+			conds = append(conds, query.Lte(c.name, f.Value))
+		case interfaces.OpGt:
+			conds = append(conds, query.Gt(c.name, f.Value))
+		case interfaces.OpGte:
+			conds = append(conds, query.Gte(c.name, f.Value))
+		case interfaces.OpLike:
+			conds = append(conds, query.Like(c.name, f.Value))
+		case interfaces.OpIn:
+			conds = append(conds, query.In(c.name, f.Value))
+		default:
+			return nil, fmt.Errorf("%s: unsupported filter operator %q", SigRepo, f.Op)
+		}
+	}
+	return conds, nil
+}
+
+func (r *SQLRepository[T, K]) columnNames() []string {
+	names := make([]string, len(r.meta.columns))
+	for i, c := range r.meta.columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func (r *SQLRepository[T, K]) targets(entity *T) []any {
+	v := reflect.ValueOf(entity).Elem()
+	targets := make([]any, len(r.meta.columns))
+	for i, c := range r.meta.columns {
+		targets[i] = r.meta.value(v, c).Addr().Interface()
+	}
+	return targets
+}
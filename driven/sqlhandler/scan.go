@@ -0,0 +1,169 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+	"sync"
+
+	"github.com/go-on-bike/bike/driven/sqlhandler/internal/structmap"
+)
+
+const SigScan string = "sqlhandler scan"
+
+// Querier es implementado por *sql.DB, *sql.Conn y *sql.Tx.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// QueryContext permite usar el Connector como Querier. Si ctx contiene una
+// transacción (ver ContextWithTx) la consulta se hace dentro de ella.
+func (c *Connector) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	if c.db == nil {
+		return nil, fmt.Errorf("%s: database connection is nil", SigScan)
+	}
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+// scanMeta es la metadata de reflexión de un tipo, calculada una vez por tipo.
+type scanMeta struct {
+	scalar bool
+	fields map[string][]int
+}
+
+var scanCache sync.Map
+
+// scanMetaFor analiza T. Los tipos que no son structs, time.Time y los que
+// implementan sql.Scanner se escanean directamente desde una única columna.
+func scanMetaFor(typ reflect.Type) *scanMeta {
+	if cached, ok := scanCache.Load(typ); ok {
+		return cached.(*scanMeta)
+	}
+
+	meta := &scanMeta{scalar: structmap.IsScalar(typ), fields: map[string][]int{}}
+	if !meta.scalar {
+		for _, f := range structmap.Fields(typ) {
+			// Los campos más cercanos a la raíz tienen prioridad sobre los embebidos
+			if existing, ok := meta.fields[f.Column]; ok && len(existing) <= len(f.Index) {
+				continue
+			}
+			meta.fields[f.Column] = f.Index
+		}
+	}
+
+	cached, _ := scanCache.LoadOrStore(typ, meta)
+	return cached.(*scanMeta)
+}
+
+// scanner escanea filas hacia T usando las columnas del resultado.
+type scanner[T any] struct {
+	meta    *scanMeta
+	columns []string
+}
+
+func newScanner[T any](rows *sql.Rows) (*scanner[T], error) {
+	s := &scanner[T]{meta: scanMetaFor(reflect.TypeFor[T]())}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get columns: %w", SigScan, err)
+	}
+	s.columns = columns
+
+	if s.meta.scalar {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("%s: cannot scan %d columns into %s", SigScan, len(columns), reflect.TypeFor[T]())
+		}
+		return s, nil
+	}
+
+	// Una columna sin campo casi siempre es un error de tipeo, fallamos antes de escanear
+	for _, column := range columns {
+		if _, ok := s.meta.fields[column]; !ok {
+			return nil, fmt.Errorf("%s: column %q has no field in %s", SigScan, column, reflect.TypeFor[T]())
+		}
+	}
+	return s, nil
+}
+
+func (s *scanner[T]) scan(rows *sql.Rows) (T, error) {
+	var out T
+	v := reflect.ValueOf(&out).Elem()
+
+	if s.meta.scalar {
+		err := rows.Scan(v.Addr().Interface())
+		if err != nil {
+			return out, fmt.Errorf("%s: failed to scan row: %w", SigScan, err)
+		}
+		return out, nil
+	}
+
+	targets := make([]any, len(s.columns))
+	for i, column := range s.columns {
+		targets[i] = structmap.FieldByIndex(v, s.meta.fields[column]).Addr().Interface()
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return out, fmt.Errorf("%s: failed to scan row: %w", SigScan, err)
+	}
+	return out, nil
+}
+
+// QueryOne retorna la primera fila del resultado como T.
+// Retorna un error que envuelve sql.ErrNoRows si no hay filas.
+func QueryOne[T any](ctx context.Context, q Querier, query string, args ...any) (T, error) {
+	var zero T
+	for row, err := range QueryIter[T](ctx, q, query, args...) {
+		return row, err
+	}
+	return zero, fmt.Errorf("%s: %w", SigScan, sql.ErrNoRows)
+}
+
+// QueryAll retorna todas las filas del resultado como []T.
+func QueryAll[T any](ctx context.Context, q Querier, query string, args ...any) ([]T, error) {
+	out := []T{}
+	for row, err := range QueryIter[T](ctx, q, query, args...) {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// QueryIter recorre las filas del resultado como T sin cargarlas todas en memoria.
+// Las filas se cierran al terminar o interrumpir el ciclo; después de un error
+// no se entregan más filas.
+func QueryIter[T any](ctx context.Context, q Querier, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			yield(zero, fmt.Errorf("%s: failed to query: %w", SigScan, err))
+			return
+		}
+		defer rows.Close()
+
+		s, err := newScanner[T](rows)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		for rows.Next() {
+			row, err := s.scan(rows)
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, fmt.Errorf("%s: failed to iterate rows: %w", SigScan, err))
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+type upperName string
+
+func (u *upperName) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", src)
+	}
+	*u = upperName(strings.ToUpper(s))
+	return nil
+}
+
+type audit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type scannedItem struct {
+	audit
+	ID      int64          `db:"id"`
+	Name    upperName      `db:"name"`
+	Color   *string        `db:"color"`
+	Wheels  sql.NullInt64  `db:"wheels"`
+	Note    sql.NullString `db:"note"`
+	Ignored string         `db:"-"`
+}
+
+func newScanTestConnector(t *testing.T) *Connector {
+	c := newTxTestConnector(t)
+	_, err := c.db.Exec(`CREATE TABLE bikes (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		color TEXT,
+		wheels INTEGER,
+		note TEXT,
+		created_by TEXT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create bikes table: %v", err)
+	}
+	_, err = c.db.Exec(`INSERT INTO bikes (name, color, wheels, note, created_by) VALUES
+		('road', 'red', 2, NULL, 'ana'),
+		('trike', NULL, 3, 'kids', 'beto')`)
+	if err != nil {
+		t.Fatalf("failed to insert bikes: %v", err)
+	}
+	return c
+}
+
+func TestQueryHelpers(t *testing.T) {
+	ctx := context.Background()
+	c := newScanTestConnector(t)
+
+	t.Run("query all maps columns", func(t *testing.T) {
+		items, err := QueryAll[scannedItem](ctx, c, `SELECT * FROM bikes ORDER BY id`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+
+		road, trike := items[0], items[1]
+		if road.Name != "ROAD" || road.CreatedBy != "ana" {
+			t.Fatalf("unexpected road %+v", road)
+		}
+		if road.Color == nil || *road.Color != "red" || road.Note.Valid {
+			t.Fatalf("unexpected nullable values in road %+v", road)
+		}
+		if trike.Color != nil || !trike.Wheels.Valid || trike.Wheels.Int64 != 3 || trike.Note.String != "kids" {
+			t.Fatalf("unexpected nullable values in trike %+v", trike)
+		}
+	})
+
+	t.Run("query one", func(t *testing.T) {
+		item, err := QueryOne[scannedItem](ctx, c, `SELECT id, name FROM bikes WHERE name = ?`, "trike")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if item.Name != "TRIKE" {
+			t.Fatalf("unexpected item %+v", item)
+		}
+
+		_, err = QueryOne[scannedItem](ctx, c, `SELECT id FROM bikes WHERE name = ?`, "tandem")
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("expected sql.ErrNoRows, got %v", err)
+		}
+	})
+
+	t.Run("scalar values", func(t *testing.T) {
+		names, err := QueryAll[string](ctx, c, `SELECT name FROM bikes ORDER BY id`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(names, ",") != "road,trike" {
+			t.Fatalf("unexpected names %v", names)
+		}
+
+		count, err := QueryOne[int](ctx, c, `SELECT COUNT(*) FROM bikes`)
+		if err != nil || count != 2 {
+			t.Fatalf("expected count 2, got %d (%v)", count, err)
+		}
+
+		if _, err := QueryOne[int](ctx, c, `SELECT id, name FROM bikes`); err == nil {
+			t.Fatal("expected error scanning two columns into scalar")
+		}
+	})
+
+	t.Run("time values are scalars", func(t *testing.T) {
+		want := time.Date(2026, 10, 19, 15, 4, 5, 0, time.UTC)
+		got, err := QueryOne[time.Time](ctx, c, `SELECT ?`, want)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("expected %s, got %s (%v)", want, got, err)
+		}
+	})
+
+	t.Run("unknown column fails", func(t *testing.T) {
+		_, err := QueryAll[scannedItem](ctx, c, `SELECT id, name AS nickname FROM bikes`)
+		if err == nil || !strings.Contains(err.Error(), "nickname") {
+			t.Fatalf("expected unknown column error, got %v", err)
+		}
+	})
+
+	t.Run("iter stops early", func(t *testing.T) {
+		seen := 0
+		for item, err := range QueryIter[scannedItem](ctx, c, `SELECT id, name FROM bikes ORDER BY id`) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if item.Name != "ROAD" {
+				t.Fatalf("unexpected item %+v", item)
+			}
+			seen++
+			break
+		}
+		if seen != 1 {
+			t.Fatalf("expected 1 item, got %d", seen)
+		}
+
+		// Las filas se cerraron, así que la conexión puede volver a usarse
+		if _, err := QueryOne[int](ctx, c, `SELECT COUNT(*) FROM bikes`); err != nil {
+			t.Fatalf("unexpected error after break: %v", err)
+		}
+	})
+
+	t.Run("cancellation is an error", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var lastErr error
+		rows := 0
+		for _, err := range QueryIter[int](canceled, c, `
+			WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1000000)
+			SELECT i FROM n`) {
+			if rows++; rows == 1 {
+				cancel()
+			}
+			lastErr = err
+		}
+		if !errors.Is(lastErr, context.Canceled) {
+			t.Fatalf("expected context canceled after %d rows, got %v", rows, lastErr)
+		}
+	})
+
+	t.Run("uses transaction from context", func(t *testing.T) {
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`INSERT INTO bikes (name, created_by) VALUES ('bmx', 'caro')`); err != nil {
+				return err
+			}
+			count, err := QueryOne[int](ContextWithTx(ctx, tx), c, `SELECT COUNT(*) FROM bikes`)
+			if err != nil {
+				return err
+			}
+			if count != 3 {
+				t.Errorf("expected 3 bikes inside tx, got %d", count)
+			}
+			return errors.New("rollback")
+		})
+		if err == nil {
+			t.Fatal("expected rollback error")
+		}
+	})
+}
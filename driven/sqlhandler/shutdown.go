@@ -0,0 +1,111 @@
+package sqlhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrShuttingDown se retorna al intentar iniciar una transacción durante o después de Shutdown.
+var ErrShuttingDown = errors.New("connector is shutting down")
+
+// ShutdownReport resume el estado de las transacciones al momento del shutdown.
+type ShutdownReport struct {
+	InFlight  int
+	Completed int
+	Aborted   int
+	Waited    time.Duration
+}
+
+// track registra una transacción en curso. El contexto retornado se cancela si
+// Shutdown alcanza su deadline antes de que la transacción termine.
+func (c *Connector) track(ctx context.Context) (context.Context, func(), error) {
+	c.lifeMu.Lock()
+	defer c.lifeMu.Unlock()
+
+	if c.shuttingDown {
+		return nil, nil, fmt.Errorf("%s: %w", SigConn, ErrShuttingDown)
+	}
+
+	if c.active == nil {
+		c.active = map[uint64]context.CancelCauseFunc{}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	c.nextTxID++
+	id := c.nextTxID
+	c.active[id] = cancel
+
+	done := func() {
+		c.lifeMu.Lock()
+		defer c.lifeMu.Unlock()
+
+		delete(c.active, id)
+		cancel(nil)
+		if c.drained != nil && len(c.active) == 0 {
+			close(c.drained)
+			c.drained = nil
+		}
+	}
+	return ctx, done, nil
+}
+
+// Shutdown deja de aceptar nuevas transacciones, espera las que están en curso
+// hasta el deadline de ctx, aborta las restantes y cierra la conexión.
+// Solo conoce el trabajo hecho con WithTx: las consultas hechas directo sobre
+// DB() no se esperan ni se cuentan en el reporte, y fallan al cerrarse la
+// conexión. Es idempotente: las llamadas siguientes retornan el reporte del
+// primer shutdown.
+func (c *Connector) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	c.lifeMu.Lock()
+	if c.shutdownReport != nil {
+		report := *c.shutdownReport
+		c.lifeMu.Unlock()
+		return report, nil
+	}
+
+	fmt.Fprintf(c.stderr, "%s: shutting down with %d in-flight transactions", SigConn, len(c.active))
+	c.shuttingDown = true
+	report := ShutdownReport{InFlight: len(c.active)}
+	var drained chan struct{}
+	if len(c.active) > 0 {
+		drained = make(chan struct{})
+		c.drained = drained
+	}
+	c.lifeMu.Unlock()
+
+	start := time.Now()
+	var waitErr error
+	if drained != nil {
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			c.lifeMu.Lock()
+			report.Aborted = len(c.active)
+			for _, cancel := range c.active {
+				cancel(ErrShuttingDown)
+			}
+			c.lifeMu.Unlock()
+			waitErr = fmt.Errorf("%s: shutdown aborted %d in-flight transactions: %w", SigConn, report.Aborted, ctx.Err())
+		}
+	}
+	report.Completed = report.InFlight - report.Aborted
+	report.Waited = time.Since(start)
+
+	closeErr := c.Close()
+
+	c.lifeMu.Lock()
+	c.shutdownReport = &report
+	c.lifeMu.Unlock()
+
+	return report, errors.Join(waitErr, closeErr)
+}
+
+// resetLifecycle permite volver a usar el conector después de un Shutdown.
+func (c *Connector) resetLifecycle() {
+	c.lifeMu.Lock()
+	defer c.lifeMu.Unlock()
+
+	c.shuttingDown = false
+	c.shutdownReport = nil
+}
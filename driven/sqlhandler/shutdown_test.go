@@ -0,0 +1,106 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Run("waits for in-flight transactions", func(t *testing.T) {
+		c := newTxTestConnector(t)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		txErr := make(chan error, 1)
+		go func() {
+			txErr <- c.WithTx(context.Background(), nil, func(tx *sql.Tx) error {
+				close(started)
+				<-release
+				return insertItem(tx, "wheel")
+			})
+		}()
+		<-started
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+		}()
+
+		report, err := c.Shutdown(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error on shutdown: %v", err)
+		}
+		if report.InFlight != 1 || report.Completed != 1 || report.Aborted != 0 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if err := <-txErr; err != nil {
+			t.Fatalf("expected in-flight transaction to commit, got %v", err)
+		}
+		if c.IsConnected() {
+			t.Fatal("expected connection closed after shutdown")
+		}
+	})
+
+	t.Run("aborts on deadline", func(t *testing.T) {
+		c := newTxTestConnector(t)
+
+		started := make(chan struct{})
+		txErr := make(chan error, 1)
+		go func() {
+			txErr <- c.WithTx(context.Background(), nil, func(tx *sql.Tx) error {
+				close(started)
+				<-time.After(200 * time.Millisecond)
+				return insertItem(tx, "wheel")
+			})
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		report, err := c.Shutdown(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline error, got %v", err)
+		}
+		if report.Aborted != 1 {
+			t.Fatalf("expected one aborted transaction, got %+v", report)
+		}
+		if err := <-txErr; err == nil {
+			t.Fatal("expected aborted transaction to fail")
+		}
+	})
+
+	t.Run("rejects new transactions and is idempotent", func(t *testing.T) {
+		c := newTxTestConnector(t)
+
+		first, err := c.Shutdown(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error on shutdown: %v", err)
+		}
+		second, err := c.Shutdown(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error on second shutdown: %v", err)
+		}
+		if first != second {
+			t.Fatalf("expected same report, got %+v and %+v", first, second)
+		}
+
+		err = c.WithTx(context.Background(), nil, func(tx *sql.Tx) error { return nil })
+		if !errors.Is(err, ErrShuttingDown) {
+			t.Fatalf("expected ErrShuttingDown, got %v", err)
+		}
+	})
+
+	t.Run("handler shutdown without connection", func(t *testing.T) {
+		h := NewDataHandler(&strings.Builder{}, []ConnOption{WithURL("file:unused.db")}, nil)
+		if _, err := h.Shutdown(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
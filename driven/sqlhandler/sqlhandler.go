@@ -1,7 +1,10 @@
 package sqlhandler
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"io"
 )
 
@@ -36,6 +39,38 @@ func (h *SQLHandler) Close() error {
 	return nil
 }
 
+func (h *SQLHandler) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	report, err := h.Connector.Shutdown(ctx)
+	h.Migrator.db = nil
+	return report, err
+}
+
+// Start conecta con el driver configurado con WithDriver y aplica las migraciones
+// pendientes si hay una fuente de migraciones. No bloquea.
+func (h *SQLHandler) Start(ctx context.Context) error {
+	if h.Connector.options.driver == nil {
+		return fmt.Errorf("%s: driver is not configured", SigSQLHandler)
+	}
+	if err := h.Connect(*h.Connector.options.driver); err != nil {
+		return err
+	}
+
+	if !h.Migrator.options.hasSource() {
+		return nil
+	}
+	if err := h.Move(0, false); err != nil && !errors.Is(err, ErrNoMigrations) {
+		h.Close()
+		return fmt.Errorf("%s: failed to migrate: %w", SigSQLHandler, err)
+	}
+	return nil
+}
+
+// Stop espera las transacciones en curso hasta el deadline de ctx y cierra la conexión.
+func (h *SQLHandler) Stop(ctx context.Context) error {
+	_, err := h.Shutdown(ctx)
+	return err
+}
+
 func (h *SQLHandler) SetDB(db *sql.DB) {
 	h.Connector.SetDB(db)
 	h.Migrator.SetDB(db)
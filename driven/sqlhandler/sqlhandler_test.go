@@ -1,6 +1,7 @@
 package sqlhandler
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -49,3 +50,43 @@ func TestSQLHandlerIsDataHandler(t *testing.T) {
 
 	AssertDBState(t, dbPath)
 }
+
+func TestSQLHandlerIsLifecycle(t *testing.T) {
+	dbURL, _ := GenTestLibsqlDBPath(t)
+	migrPATH := GetMigrationPATH(t)
+	ctx := context.Background()
+
+	t.Run("start connects and migrates", func(t *testing.T) {
+		var handler interfaces.Lifecycle = NewDataHandler(
+			&strings.Builder{},
+			[]ConnOption{WithURL(dbURL), WithDriver("libsql")},
+			[]MigrOption{WithPATH(migrPATH)},
+		)
+
+		if err := handler.Start(ctx); err != nil {
+			t.Fatalf("unexpected error starting: %v", err)
+		}
+
+		h := handler.(*SQLHandler)
+		if !h.IsConnected() {
+			t.Fatal("expected handler to be connected after Start")
+		}
+		if version, err := h.Version(); err != nil || version == 0 {
+			t.Fatalf("expected migrations to run, got version %d (%v)", version, err)
+		}
+
+		if err := handler.Stop(ctx); err != nil {
+			t.Fatalf("unexpected error stopping: %v", err)
+		}
+		if h.IsConnected() {
+			t.Fatal("expected handler to be disconnected after Stop")
+		}
+	})
+
+	t.Run("start without driver fails", func(t *testing.T) {
+		handler := NewDataHandler(&strings.Builder{}, []ConnOption{WithURL(dbURL)}, nil)
+		if err := handler.Start(ctx); err == nil {
+			t.Fatal("expected error starting without driver")
+		}
+	})
+}
@@ -0,0 +1,432 @@
+package sqlhandler
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+const SigTenant string = "sqlhandler tenant"
+
+// ErrNoTenant se retorna cuando el contexto no contiene la llave del tenant.
+var ErrNoTenant = errors.New("no tenant in context")
+
+type tenantKey struct{}
+
+// ContextWithTenant guarda la llave del tenant que usará TenantHandler.Handler.
+// También la agrega como campo tenant de los logs de ese contexto.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	ctx = interfaces.ContextWithLogFields(ctx, interfaces.LogKeyTenant, tenant)
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext retorna la llave del tenant guardada con ContextWithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+type TenantOption func(options *tenantOpts)
+
+type tenantOpts struct {
+	maxOpen      int
+	idleTimeout  time.Duration
+	closeTimeout time.Duration
+	connOpts     []ConnOption
+	migrOpts     []MigrOption
+}
+
+// WithMaxTenants limita cuántas conexiones de tenants se mantienen abiertas,
+// cerrando la usada hace más tiempo al superar el límite.
+// Panics si n es menor a 1.
+func WithMaxTenants(n int) TenantOption {
+	return func(options *tenantOpts) {
+		if n < 1 {
+			panic(fmt.Sprintf("%s: max tenants must be at least 1, got %d", SigTenant, n))
+		}
+		options.maxOpen = n
+	}
+}
+
+// WithTenantIdleTimeout cierra las conexiones que no se han usado en el tiempo dado.
+// Panics si d no es positivo.
+func WithTenantIdleTimeout(d time.Duration) TenantOption {
+	return func(options *tenantOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: idle timeout must be positive", SigTenant))
+		}
+		options.idleTimeout = d
+	}
+}
+
+// WithTenantConnOptions agrega opciones a la conexión de cada tenant, por ejemplo
+// WithInstrumentation o WithTxRetry. La URL siempre la entrega el resolver.
+func WithTenantConnOptions(opts ...ConnOption) TenantOption {
+	return func(options *tenantOpts) {
+		options.connOpts = append(options.connOpts, opts...)
+	}
+}
+
+// WithTenantMigrOptions configura el Migrator de cada tenant. Sin un path de
+// migraciones las bases de datos de los tenants no se migran.
+func WithTenantMigrOptions(opts ...MigrOption) TenantOption {
+	return func(options *tenantOpts) {
+		options.migrOpts = append(options.migrOpts, opts...)
+	}
+}
+
+type tenantEntry struct {
+	key      string
+	handler  *SQLHandler
+	lastUsed time.Time
+	refs     int
+	ready    chan struct{}
+	err      error
+}
+
+// TenantHandler mantiene un SQLHandler por tenant, abriendo y migrando cada base
+// de datos la primera vez que se usa.
+type TenantHandler struct {
+	stderr  io.Writer
+	driver  string
+	resolve func(tenant string) (string, error)
+	options tenantOpts
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	known   map[string]struct{}
+}
+
+const defaultTenantCloseTimeout = 5 * time.Second
+
+// NewTenantHandler crea un handler multi-tenant. resolve retorna la URL de la base
+// de datos de cada tenant.
+// Panics si stderr o resolve son nil o driver está vacío.
+func NewTenantHandler(stderr io.Writer, driver string, resolve func(tenant string) (string, error), opts ...TenantOption) *TenantHandler {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigTenant))
+	}
+	if driver == "" {
+		panic(fmt.Sprintf("%s: driver cannot be empty", SigTenant))
+	}
+	if resolve == nil {
+		panic(fmt.Sprintf("%s: resolve func cannot be nil", SigTenant))
+	}
+
+	t := &TenantHandler{
+		stderr:  stderr,
+		driver:  driver,
+		resolve: resolve,
+		options: tenantOpts{closeTimeout: defaultTenantCloseTimeout},
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+		known:   map[string]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(&t.options)
+	}
+	return t
+}
+
+// Handler retorna el SQLHandler del tenant guardado en ctx.
+func (t *TenantHandler) Handler(ctx context.Context) (*SQLHandler, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", SigTenant, ErrNoTenant)
+	}
+	return t.ForTenant(tenant)
+}
+
+// ForTenant retorna el SQLHandler de tenant, abriéndolo y migrándolo si no está en cache.
+// El handler puede cerrarse si luego es desalojado de la cache, para operaciones
+// que no deben cortarse se recomienda Use.
+func (t *TenantHandler) ForTenant(tenant string) (*SQLHandler, error) {
+	entry, err := t.acquire(tenant, true)
+	if err != nil {
+		return nil, err
+	}
+	t.release(entry)
+	return entry.handler, nil
+}
+
+// Use ejecuta fn con el SQLHandler del tenant guardado en ctx, garantizando que
+// su conexión no sea desalojada mientras fn se ejecuta.
+func (t *TenantHandler) Use(ctx context.Context, fn func(h *SQLHandler) error) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%s: %w", SigTenant, ErrNoTenant)
+	}
+
+	entry, err := t.acquire(tenant, true)
+	if err != nil {
+		return err
+	}
+	defer t.release(entry)
+
+	return fn(entry.handler)
+}
+
+// acquire retorna la entrada del tenant marcándola en uso, para que no sea desalojada
+// hasta llamar a release. Si tiene que abrir la conexión la migra solo si migrate
+// es true.
+func (t *TenantHandler) acquire(tenant string, migrate bool) (*tenantEntry, error) {
+	if tenant == "" {
+		return nil, fmt.Errorf("%s: %w", SigTenant, ErrNoTenant)
+	}
+
+	t.mu.Lock()
+	t.evictIdleLocked()
+	t.known[tenant] = struct{}{}
+
+	if elem, ok := t.entries[tenant]; ok {
+		entry := elem.Value.(*tenantEntry)
+		entry.lastUsed = time.Now()
+		entry.refs++
+		t.lru.MoveToFront(elem)
+		t.mu.Unlock()
+
+		// Otra goroutine puede estar abriendo la conexión, esperamos su resultado
+		<-entry.ready
+		if entry.err != nil {
+			t.release(entry)
+			return nil, entry.err
+		}
+		return entry, nil
+	}
+
+	entry := &tenantEntry{key: tenant, lastUsed: time.Now(), refs: 1, ready: make(chan struct{})}
+	t.entries[tenant] = t.lru.PushFront(entry)
+	t.mu.Unlock()
+
+	entry.handler, entry.err = t.open(tenant, migrate)
+	close(entry.ready)
+
+	if entry.err != nil {
+		t.mu.Lock()
+		// No guardamos errores en cache para reintentar en la siguiente llamada
+		if elem, ok := t.entries[tenant]; ok && elem.Value == entry {
+			t.lru.Remove(elem)
+			delete(t.entries, tenant)
+		}
+		t.mu.Unlock()
+		return nil, entry.err
+	}
+	return entry, nil
+}
+
+func (t *TenantHandler) release(entry *tenantEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry.refs--
+	t.evictOverflowLocked()
+}
+
+func (t *TenantHandler) open(tenant string, migrate bool) (*SQLHandler, error) {
+	fmt.Fprintf(t.stderr, "%s: opening database for tenant %s", SigTenant, tenant)
+
+	url, err := t.resolve(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to resolve url for tenant %s: %w", SigTenant, tenant, err)
+	}
+
+	connOpts := append(slices.Clone(t.options.connOpts), WithURL(url))
+	h := NewDataHandler(t.stderr, connOpts, t.options.migrOpts)
+	if err := h.Connect(t.driver); err != nil {
+		return nil, fmt.Errorf("%s: failed to connect tenant %s: %w", SigTenant, tenant, err)
+	}
+
+	if !migrate || !h.Migrator.options.hasSource() {
+		return h, nil
+	}
+	if err := h.Move(0, false); err != nil && !errors.Is(err, ErrNoMigrations) {
+		h.Close()
+		return nil, fmt.Errorf("%s: failed to migrate tenant %s: %w", SigTenant, tenant, err)
+	}
+	return h, nil
+}
+
+// Register agrega tenants a la lista de conocidos sin abrir sus conexiones,
+// de modo que MoveAll también los migre.
+func (t *TenantHandler) Register(tenants ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tenant := range tenants {
+		if tenant != "" {
+			t.known[tenant] = struct{}{}
+		}
+	}
+}
+
+// Tenants retorna los tenants conocidos ordenados.
+func (t *TenantHandler) Tenants() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tenants := make([]string, 0, len(t.known))
+	for tenant := range t.known {
+		tenants = append(tenants, tenant)
+	}
+	slices.Sort(tenants)
+	return tenants
+}
+
+// Open retorna cuántas conexiones de tenants están abiertas.
+func (t *TenantHandler) Open() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lru.Len()
+}
+
+// EvictIdle cierra las conexiones sin uso por más del idle timeout y retorna cuántas cerró.
+func (t *TenantHandler) EvictIdle() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.evictIdleLocked()
+}
+
+func (t *TenantHandler) evictIdleLocked() int {
+	if t.options.idleTimeout == 0 {
+		return 0
+	}
+
+	evicted := 0
+	deadline := time.Now().Add(-t.options.idleTimeout)
+	for elem := t.lru.Back(); elem != nil; {
+		entry := elem.Value.(*tenantEntry)
+		if entry.lastUsed.After(deadline) {
+			break
+		}
+		prev := elem.Prev()
+		if t.evictLocked(elem) {
+			evicted++
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+func (t *TenantHandler) evictOverflowLocked() {
+	if t.options.maxOpen == 0 {
+		return
+	}
+
+	for elem := t.lru.Back(); elem != nil && t.lru.Len() > t.options.maxOpen; {
+		prev := elem.Prev()
+		t.evictLocked(elem)
+		elem = prev
+	}
+}
+
+// evictLocked saca una conexión sin uso de la cache y la cierra con Shutdown en
+// segundo plano, para no cortar transacciones iniciadas con ForTenant.
+func (t *TenantHandler) evictLocked(elem *list.Element) bool {
+	entry := elem.Value.(*tenantEntry)
+	if entry.refs > 0 {
+		// Está en uso o todavía se está abriendo, se desalojará al liberarse
+		return false
+	}
+
+	t.lru.Remove(elem)
+	delete(t.entries, entry.key)
+	fmt.Fprintf(t.stderr, "%s: evicting connection of tenant %s", SigTenant, entry.key)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), t.options.closeTimeout)
+		defer cancel()
+		if _, err := entry.handler.Shutdown(ctx); err != nil {
+			fmt.Fprintf(t.stderr, "%s: failed to close tenant %s: %v", SigTenant, entry.key, err)
+		}
+	}()
+	return true
+}
+
+// TenantResult es el resultado de migrar un tenant con MoveAll.
+type TenantResult struct {
+	Tenant  string
+	Version int
+	Err     error
+}
+
+// MoveAll ejecuta Move en todos los tenants conocidos con a lo más concurrency
+// migraciones en paralelo. No tener migraciones pendientes no se considera error.
+// Los tenants que no están abiertos se abren sin migrarlos antes, para que un
+// rollback parta desde su versión actual.
+func (t *TenantHandler) MoveAll(ctx context.Context, steps int, inverse bool, concurrency int) []TenantResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	tenants := t.Tenants()
+	results := make([]TenantResult, len(tenants))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, tenant := range tenants {
+		results[i].Tenant = tenant
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = fmt.Errorf("%s: migration of tenant %s canceled: %w", SigTenant, tenant, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *TenantResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry, err := t.acquire(result.Tenant, false)
+			if err != nil {
+				result.Err = err
+				return
+			}
+			defer t.release(entry)
+
+			h := entry.handler
+			if err := h.Move(steps, inverse); err != nil && !errors.Is(err, ErrNoMigrations) {
+				result.Err = fmt.Errorf("%s: failed to move tenant %s: %w", SigTenant, result.Tenant, err)
+				return
+			}
+			result.Version, result.Err = h.Version()
+		}(&results[i])
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Shutdown cierra las conexiones de todos los tenants, esperando sus transacciones
+// en curso hasta el deadline de ctx.
+func (t *TenantHandler) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	var entries []*tenantEntry
+	for elem := t.lru.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(*tenantEntry))
+	}
+	t.lru.Init()
+	t.entries = map[string]*list.Element{}
+	t.mu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		<-entry.ready
+		if entry.handler == nil {
+			continue
+		}
+		if _, err := entry.handler.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
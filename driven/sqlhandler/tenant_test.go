@@ -0,0 +1,195 @@
+package sqlhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// lockedWriter permite que varias conexiones de tenants escriban en paralelo.
+type lockedWriter struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func newTestTenantHandler(t *testing.T, opts ...TenantOption) *TenantHandler {
+	dir := t.TempDir()
+	resolve := func(tenant string) (string, error) {
+		if strings.ContainsAny(tenant, "/.") {
+			return "", fmt.Errorf("invalid tenant %q", tenant)
+		}
+		return "file:" + filepath.Join(dir, tenant+".db"), nil
+	}
+
+	opts = append([]TenantOption{WithTenantMigrOptions(WithPATH(GetMigrationPATH(t)))}, opts...)
+	th := NewTenantHandler(&lockedWriter{}, "libsql", resolve, opts...)
+	t.Cleanup(func() {
+		if err := th.Shutdown(context.Background()); err != nil {
+			t.Errorf("error shutting down tenants: %v", err)
+		}
+	})
+	return th
+}
+
+func TestTenantHandler(t *testing.T) {
+	t.Run("opens, migrates and caches per tenant", func(t *testing.T) {
+		th := newTestTenantHandler(t)
+
+		ctx := ContextWithTenant(context.Background(), "acme")
+		h, err := th.Handler(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		version, err := h.Version()
+		if err != nil || version != 2 {
+			t.Fatalf("expected tenant migrated to version 2, got %d (%v)", version, err)
+		}
+
+		again, err := th.Handler(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if again != h {
+			t.Fatal("expected cached handler for same tenant")
+		}
+
+		other, err := th.ForTenant("globex")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if other == h {
+			t.Fatal("expected different handler per tenant")
+		}
+	})
+
+	t.Run("use keeps tenant open", func(t *testing.T) {
+		th := newTestTenantHandler(t, WithMaxTenants(1))
+
+		ctx := ContextWithTenant(context.Background(), "acme")
+		err := th.Use(ctx, func(h *SQLHandler) error {
+			// Abrir otro tenant supera el límite, pero acme está en uso
+			if _, err := th.ForTenant("globex"); err != nil {
+				return err
+			}
+			if !h.IsConnected() {
+				return errors.New("tenant in use was closed")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if th.Open() != 1 {
+			t.Fatalf("expected 1 open tenant after release, got %d", th.Open())
+		}
+	})
+
+	t.Run("missing tenant in context", func(t *testing.T) {
+		th := newTestTenantHandler(t)
+		if _, err := th.Handler(context.Background()); !errors.Is(err, ErrNoTenant) {
+			t.Fatalf("expected ErrNoTenant, got %v", err)
+		}
+	})
+
+	t.Run("resolve errors are not cached", func(t *testing.T) {
+		th := newTestTenantHandler(t)
+		if _, err := th.ForTenant("../escape"); err == nil {
+			t.Fatal("expected resolve error")
+		}
+		if th.Open() != 0 {
+			t.Fatalf("expected no open tenants, got %d", th.Open())
+		}
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		th := newTestTenantHandler(t, WithMaxTenants(2))
+		for _, tenant := range []string{"a", "b", "a", "c"} {
+			if _, err := th.ForTenant(tenant); err != nil {
+				t.Fatalf("unexpected error on tenant %s: %v", tenant, err)
+			}
+		}
+
+		if th.Open() != 2 {
+			t.Fatalf("expected 2 open tenants, got %d", th.Open())
+		}
+		th.mu.Lock()
+		_, hasB := th.entries["b"]
+		_, hasA := th.entries["a"]
+		th.mu.Unlock()
+		if hasB || !hasA {
+			t.Fatal("expected tenant b evicted and a kept")
+		}
+		if got := th.Tenants(); len(got) != 3 {
+			t.Fatalf("expected 3 known tenants, got %v", got)
+		}
+	})
+
+	t.Run("evicts idle tenants", func(t *testing.T) {
+		th := newTestTenantHandler(t, WithTenantIdleTimeout(10*time.Millisecond))
+		if _, err := th.ForTenant("a"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if evicted := th.EvictIdle(); evicted != 1 {
+			t.Fatalf("expected 1 idle tenant evicted, got %d", evicted)
+		}
+	})
+
+	t.Run("move all tenants", func(t *testing.T) {
+		th := newTestTenantHandler(t, WithMaxTenants(1))
+		th.Register("a", "b", "c")
+
+		results := th.MoveAll(context.Background(), 0, true, 2)
+		if len(results) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(results))
+		}
+		for _, r := range results {
+			if r.Err != nil {
+				t.Fatalf("unexpected error on tenant %s: %v", r.Tenant, r.Err)
+			}
+			if r.Version != 0 {
+				t.Fatalf("expected tenant %s at version 0, got %d", r.Tenant, r.Version)
+			}
+		}
+	})
+
+	t.Run("inverse move does not migrate cold tenants up first", func(t *testing.T) {
+		th := newTestTenantHandler(t)
+
+		// Dejamos al tenant en la versión 1 con una fila que choca con el seed de la 2
+		url, err := th.resolve("acme")
+		if err != nil {
+			t.Fatalf("unexpected error resolving: %v", err)
+		}
+		h := NewDataHandler(&lockedWriter{}, []ConnOption{WithURL(url)}, []MigrOption{WithPATH(GetMigrationPATH(t))})
+		if err := h.Connect("libsql"); err != nil {
+			t.Fatalf("unexpected error connecting: %v", err)
+		}
+		if err := h.Move(1, false); err != nil {
+			t.Fatalf("unexpected error migrating: %v", err)
+		}
+		if _, err := h.DB().Exec(`INSERT INTO systems (name, code) VALUES ('navigation', 'NAV-01')`); err != nil {
+			t.Fatalf("unexpected error inserting: %v", err)
+		}
+		h.Close()
+		th.Register("acme")
+
+		results := th.MoveAll(context.Background(), 1, true, 1)
+		if r := results[0]; r.Err != nil || r.Version != 0 {
+			t.Fatalf("expected tenant rolled back to version 0, got %d (%v)", r.Version, r.Err)
+		}
+	})
+}
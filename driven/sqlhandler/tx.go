@@ -0,0 +1,217 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const SigTx string = "sqlhandler tx"
+
+// ErrTxPanic se retorna cuando la función de una transacción hace panic.
+var ErrTxPanic = errors.New("panic inside transaction")
+
+type txPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+var defaultTxPolicy = txPolicy{attempts: 3, backoff: 10 * time.Millisecond, maxBackoff: time.Second}
+
+// defaultRetryPolicy es más insistente que la de transacciones porque se usa en
+// sentencias cortas que compiten por escribir, como los workers de una cola.
+var defaultRetryPolicy = txPolicy{attempts: 20, backoff: 5 * time.Millisecond, maxBackoff: 200 * time.Millisecond}
+
+// delay calcula un backoff exponencial con jitter para el intento dado.
+func (p txPolicy) delay(attempt int) time.Duration {
+	d := p.backoff << (attempt - 1)
+	if d <= 0 || d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+// retryableErrors son fragmentos de errores de SQLite/libsql y Postgres que indican
+// que la transacción puede reintentarse sin cambios.
+var retryableErrors = []string{
+	"SQLITE_BUSY",
+	"database is locked",
+	"database table is locked",
+	"could not serialize access",
+	"deadlock detected",
+	"SQLSTATE 40001",
+	"SQLSTATE 40P01",
+}
+
+// IsRetryable indica si err es un error de bloqueo o serialización tras el cual
+// la operación puede reintentarse sin cambios.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrTxPanic) {
+		return false
+	}
+	msg := err.Error()
+	for _, fragment := range retryableErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Retry ejecuta fn y la reintenta con backoff mientras falle con un error para el
+// que IsRetryable es true, por ejemplo cuando varios procesos escriben a la vez en SQLite.
+func Retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= defaultRetryPolicy.attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: retry canceled after %d attempts: %w", SigTx, attempt, errors.Join(err, ctx.Err()))
+		case <-time.After(defaultRetryPolicy.delay(attempt)):
+		}
+	}
+}
+
+type txKey struct{}
+
+// ContextWithTx guarda una transacción en el contexto, de modo que un WithTx
+// anidado que reciba ese contexto use un savepoint en vez de una nueva transacción.
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext retorna la transacción guardada con ContextWithTx.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok && tx != nil
+}
+
+// WithTx ejecuta fn dentro de una transacción, haciendo commit si fn retorna nil y
+// rollback en caso de error o panic. Los errores de bloqueo o serialización se
+// reintentan con backoff. Si ctx ya contiene una transacción se usa un savepoint.
+// Retorna ErrShuttingDown si el conector está en Shutdown.
+func (c *Connector) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		return Savepoint(ctx, tx, fn)
+	}
+
+	ctx, done, err := c.track(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if c.db == nil {
+		return fmt.Errorf("%s: database connection is nil", SigTx)
+	}
+
+	return withTx(ctx, c.db, opts, c.options.txPolicy(), fn)
+}
+
+// InTx ejecuta fn en la transacción de ctx usando un savepoint, o en una nueva
+// transacción de db, con commit si fn retorna nil y rollback en caso de error o
+// panic. Es para paquetes que reciben un *sql.DB en vez de un Connector. A
+// diferencia de WithTx no reintenta; para eso se puede envolver en Retry.
+func InTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		return Savepoint(ctx, tx, fn)
+	}
+	if db == nil {
+		return fmt.Errorf("%s: database connection is nil", SigTx)
+	}
+	return runTx(ctx, db, opts, fn)
+}
+
+func withTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, policy txPolicy, fn func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt >= policy.attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: retry canceled after %d attempts: %w", SigTx, attempt, errors.Join(err, ctx.Err()))
+		case <-time.After(policy.delay(attempt)):
+		}
+	}
+}
+
+func runTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("%s: failed to start transaction: %w", SigTx, err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %w: %v", SigTx, ErrTxPanic, r)
+			if rollErr := tx.Rollback(); rollErr != nil {
+				err = fmt.Errorf("%w, additionally rollback failed: %v", err, rollErr)
+			}
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollErr := tx.Rollback(); rollErr != nil {
+			// Es crítico saber si falló tanto la transacción como el rollback
+			return fmt.Errorf("%w, additionally rollback failed: %v", err, rollErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", SigTx, err)
+	}
+	return nil
+}
+
+var savepointSeq atomic.Uint64
+
+// Savepoint ejecuta fn dentro de un savepoint de tx. Si fn falla o hace panic solo
+// se deshacen los cambios hechos dentro del savepoint.
+func Savepoint(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) (err error) {
+	name := fmt.Sprintf("bike_sp_%d", savepointSeq.Add(1))
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("%s: failed to create savepoint: %w", SigTx, err)
+	}
+
+	rollback := func(cause error) error {
+		if _, rollErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollErr != nil {
+			return fmt.Errorf("%w, additionally savepoint rollback failed: %v", cause, rollErr)
+		}
+		if _, relErr := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); relErr != nil {
+			return fmt.Errorf("%w, additionally savepoint release failed: %v", cause, relErr)
+		}
+		return cause
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = rollback(fmt.Errorf("%s: %w: %v", SigTx, ErrTxPanic, r))
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return rollback(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("%s: failed to release savepoint: %w", SigTx, err)
+	}
+	return nil
+}
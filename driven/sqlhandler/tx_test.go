@@ -0,0 +1,251 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+func newTxTestConnector(t *testing.T, opts ...ConnOption) *Connector {
+	dbURL, _ := GenTestLibsqlDBPath(t)
+	stderr := &strings.Builder{}
+
+	c := NewConnector(stderr, append([]ConnOption{WithURL(dbURL)}, opts...)...)
+	if err := c.Connect("libsql"); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	t.Cleanup(func() {
+		if c.db != nil {
+			c.Close()
+		}
+	})
+
+	if _, err := c.db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("failed to create items table: %v", err)
+	}
+	return c
+}
+
+func countItems(t *testing.T, c *Connector) int {
+	var count int
+	if err := c.db.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&count); err != nil {
+		t.Fatalf("failed to count items: %v", err)
+	}
+	return count
+}
+
+func insertItem(tx *sql.Tx, name string) error {
+	_, err := tx.Exec(`INSERT INTO items (name) VALUES (?)`, name)
+	return err
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits on success", func(t *testing.T) {
+		c := newTxTestConnector(t)
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return insertItem(tx, "wheel")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := countItems(t, c); got != 1 {
+			t.Fatalf("expected 1 item, got %d", got)
+		}
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		c := newTxTestConnector(t)
+		errBoom := errors.New("boom")
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			if err := insertItem(tx, "wheel"); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected boom error, got %v", err)
+		}
+		if got := countItems(t, c); got != 0 {
+			t.Fatalf("expected 0 items, got %d", got)
+		}
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		c := newTxTestConnector(t)
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			if err := insertItem(tx, "wheel"); err != nil {
+				return err
+			}
+			panic("flat tire")
+		})
+		if !errors.Is(err, ErrTxPanic) {
+			t.Fatalf("expected ErrTxPanic, got %v", err)
+		}
+		if got := countItems(t, c); got != 0 {
+			t.Fatalf("expected 0 items, got %d", got)
+		}
+	})
+
+	t.Run("retries busy errors", func(t *testing.T) {
+		c := newTxTestConnector(t, WithTxRetry(3, time.Millisecond))
+		attempts := 0
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("SQLITE_BUSY: database is locked")
+			}
+			return insertItem(tx, "wheel")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if attempts != 3 {
+			t.Fatalf("expected 3 attempts, got %d", attempts)
+		}
+		if got := countItems(t, c); got != 1 {
+			t.Fatalf("expected 1 item, got %d", got)
+		}
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		c := newTxTestConnector(t, WithTxRetry(3, time.Millisecond))
+		attempts := 0
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			attempts++
+			return errors.New("constraint failed")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if attempts != 1 {
+			t.Fatalf("expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("nested calls use savepoints", func(t *testing.T) {
+		c := newTxTestConnector(t)
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			if err := insertItem(tx, "frame"); err != nil {
+				return err
+			}
+
+			txCtx := ContextWithTx(ctx, tx)
+			nestedErr := c.WithTx(txCtx, nil, func(tx *sql.Tx) error {
+				if err := insertItem(tx, "chain"); err != nil {
+					return err
+				}
+				return errors.New("chain broke")
+			})
+			if nestedErr == nil {
+				t.Error("expected nested error")
+			}
+
+			return c.WithTx(txCtx, nil, func(tx *sql.Tx) error {
+				return insertItem(tx, "pedal")
+			})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := countItems(t, c); got != 2 {
+			t.Fatalf("expected 2 items, got %d", got)
+		}
+	})
+
+	t.Run("without connection fails", func(t *testing.T) {
+		c, _ := NewTestConnector(t, &strings.Builder{})
+		err := c.WithTx(ctx, nil, func(tx *sql.Tx) error { return nil })
+		if err == nil {
+			t.Fatal("expected error without connection")
+		}
+	})
+}
+
+func TestInTx(t *testing.T) {
+	ctx := context.Background()
+	c := newTxTestConnector(t)
+
+	err := InTx(ctx, c.db, nil, func(tx *sql.Tx) error {
+		if err := insertItem(tx, "wheel"); err != nil {
+			return err
+		}
+		panic("flat tire")
+	})
+	if !errors.Is(err, ErrTxPanic) {
+		t.Fatalf("expected ErrTxPanic, got %v", err)
+	}
+	if got := countItems(t, c); got != 0 {
+		t.Fatalf("expected 0 items after panic, got %d", got)
+	}
+
+	err = c.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		if err := insertItem(tx, "frame"); err != nil {
+			return err
+		}
+		nestedErr := InTx(ContextWithTx(ctx, tx), c.db, nil, func(tx *sql.Tx) error {
+			if err := insertItem(tx, "chain"); err != nil {
+				return err
+			}
+			return errors.New("chain broke")
+		})
+		if nestedErr == nil {
+			t.Error("expected nested error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := countItems(t, c); got != 1 {
+		t.Fatalf("expected only the outer item, got %d", got)
+	}
+
+	if err := InTx(ctx, nil, nil, func(tx *sql.Tx) error { return nil }); err == nil {
+		t.Fatal("expected error without connection")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries lock errors", func(t *testing.T) {
+		attempts := 0
+		err := Retry(ctx, func() error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("database is locked")
+			}
+			return nil
+		})
+		if err != nil || attempts != 3 {
+			t.Fatalf("expected success on third attempt, got %d attempts (%v)", attempts, err)
+		}
+	})
+
+	t.Run("returns other errors at once", func(t *testing.T) {
+		attempts := 0
+		err := Retry(ctx, func() error {
+			attempts++
+			return errors.New("constraint failed")
+		})
+		if err == nil || attempts != 1 {
+			t.Fatalf("expected one failed attempt, got %d (%v)", attempts, err)
+		}
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := Retry(canceled, func() error { return errors.New("SQLITE_BUSY") })
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const SigHTTP string = "httpserver"
+
+// Server es el adaptador HTTP. Envuelve un http.ServeMux con recuperación de
+// panics, registro de requests y los endpoints /healthz y /readyz.
+type Server struct {
+	stderr  io.Writer
+	mux     *http.ServeMux
+	options serverOpts
+
+	mu       sync.Mutex
+	listener net.Listener
+	cancel   context.CancelFunc
+	ready    chan struct{}
+	done     chan struct{}
+}
+
+func NewServer(stderr io.Writer, opts ...ServerOption) *Server {
+	if stderr == nil {
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigHTTP))
+	}
+
+	s := &Server{
+		stderr: stderr,
+		mux:    http.NewServeMux(),
+		options: serverOpts{
+			addr:              ":8080",
+			readTimeout:       15 * time.Second,
+			readHeaderTimeout: 5 * time.Second,
+			writeTimeout:      15 * time.Second,
+			idleTimeout:       60 * time.Second,
+			shutdownTimeout:   10 * time.Second,
+		},
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+
+	s.mux.HandleFunc("GET /healthz", s.healthz)
+	s.mux.HandleFunc("GET /readyz", s.readyz)
+
+	return s
+}
+
+// Handle registra handler para pattern, con la sintaxis de http.ServeMux.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registra handler para pattern, con la sintaxis de http.ServeMux.
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// Handler retorna el handler completo del servidor, con sus middlewares.
+func (s *Server) Handler() http.Handler {
+	return s.requestID(s.log(s.recover(s.mux)))
+}
+
+// Ready se cierra cuando el servidor empieza a aceptar conexiones.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Addr retorna la dirección en que escucha el servidor. Útil con ":0".
+// Retorna "" si el servidor no ha partido.
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Start escucha y atiende requests hasta que ctx se cancele. Al cancelar deja de
+// aceptar conexiones y espera a las requests en curso hasta el shutdown timeout.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.listener != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("%s: server already started", SigHTTP)
+	}
+	listener, err := net.Listen("tcp", s.options.addr)
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("%s: failed to listen on %s: %w", SigHTTP, s.options.addr, err)
+	}
+	s.listener = listener
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.mu.Unlock()
+	defer close(s.done)
+
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadTimeout:       s.options.readTimeout,
+		ReadHeaderTimeout: s.options.readHeaderTimeout,
+		WriteTimeout:      s.options.writeTimeout,
+		IdleTimeout:       s.options.idleTimeout,
+		BaseContext:       func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(listener) }()
+
+	fmt.Fprintf(s.stderr, "%s: listening on %s", SigHTTP, listener.Addr())
+	close(s.ready)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("%s: server stopped: %w", SigHTTP, err)
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintf(s.stderr, "%s: shutting down", SigHTTP)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.options.shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("%s: graceful shutdown failed: %w", SigHTTP, err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: server stopped: %w", SigHTTP, err)
+	}
+	return nil
+}
+
+// Stop inicia el shutdown de Start y espera a que termine hasta el deadline de ctx.
+// Si el servidor no ha partido retorna de inmediato.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: stop timed out: %w", SigHTTP, ctx.Err())
+	}
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+type readyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	resp := readyResponse{Status: "ok", Checks: map[string]string{}}
+	status := http.StatusOK
+
+	for _, c := range s.options.checks {
+		if err := c.check(r.Context()); err != nil {
+			resp.Status = "unavailable"
+			resp.Checks[c.name] = err.Error()
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[c.name] = "ok"
+	}
+
+	writeJSON(w, status, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,283 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+	"github.com/go-on-bike/bike/tester/logtest"
+)
+
+type fakeConnector struct{ connected bool }
+
+func (f *fakeConnector) Connect(driver string) error { return nil }
+func (f *fakeConnector) Close() error                { return nil }
+func (f *fakeConnector) IsConnected() bool           { return f.connected }
+
+type fakeMigrator struct {
+	version int
+	err     error
+}
+
+func (f *fakeMigrator) Version() (int, error)              { return f.version, f.err }
+func (f *fakeMigrator) Move(steps int, inverse bool) error { return nil }
+
+func serve(s *Server, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestProbes(t *testing.T) {
+	t.Run("healthz always answers", func(t *testing.T) {
+		s := NewServer(&strings.Builder{})
+		rec := serve(s, http.MethodGet, "/healthz")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ok"`) {
+			t.Fatalf("unexpected healthz response %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("readyz reports every check", func(t *testing.T) {
+		conn := &fakeConnector{connected: true}
+		migr := &fakeMigrator{version: 3}
+		s := NewServer(&strings.Builder{}, WithConnectorCheck(conn), WithMigrationCheck(migr, 3))
+
+		rec := serve(s, http.MethodGet, "/readyz")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected ready, got %d %q", rec.Code, rec.Body.String())
+		}
+
+		conn.connected = false
+		migr.version = 2
+		rec = serve(s, http.MethodGet, "/readyz")
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected unavailable, got %d", rec.Code)
+		}
+
+		var resp readyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid readyz body: %v", err)
+		}
+		if resp.Status != "unavailable" || resp.Checks["database"] == "ok" || !strings.Contains(resp.Checks["migrations"], "behind 3") {
+			t.Fatalf("unexpected readyz body %+v", resp)
+		}
+	})
+
+	t.Run("readyz reports check errors", func(t *testing.T) {
+		s := NewServer(&strings.Builder{}, WithMigrationCheck(&fakeMigrator{err: errors.New("db closed")}, 0))
+		rec := serve(s, http.MethodGet, "/readyz")
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "db closed") {
+			t.Fatalf("unexpected readyz response %d %q", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("logs requests to logger", func(t *testing.T) {
+		logger := &logtest.Logger{}
+		s := NewServer(&strings.Builder{}, WithLogger(logger))
+		s.HandleFunc("POST /bikes", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, "created")
+		})
+
+		serve(s, http.MethodPost, "/bikes")
+
+		entry, ok := logger.Find(slog.LevelInfo, "httpserver request")
+		if !ok {
+			t.Fatal("expected request log")
+		}
+		if entry.Value("method") != "POST" || entry.Value("path") != "/bikes" ||
+			entry.Value("status") != http.StatusCreated || entry.Value("bytes") != 7 {
+			t.Fatalf("unexpected log args %v", entry.Args)
+		}
+	})
+
+	t.Run("logs requests to stderr without logger", func(t *testing.T) {
+		stderr := &strings.Builder{}
+		s := NewServer(stderr)
+		serve(s, http.MethodGet, "/missing")
+		if !strings.Contains(stderr.String(), "httpserver: GET /missing 404") {
+			t.Fatalf("unexpected stderr %q", stderr.String())
+		}
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		logger := &logtest.Logger{}
+		s := NewServer(&strings.Builder{}, WithLogger(logger))
+		s.HandleFunc("GET /boom", func(w http.ResponseWriter, r *http.Request) {
+			panic("flat tire")
+		})
+
+		rec := serve(s, http.MethodGet, "/boom")
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", rec.Code)
+		}
+
+		entry, ok := logger.Find(slog.LevelError, "httpserver panic")
+		if !ok || entry.Value("panic") != "flat tire" {
+			t.Fatalf("expected panic log, got %+v", entry)
+		}
+		if entry, ok := logger.Find(slog.LevelInfo, "httpserver request"); !ok || entry.Value("status") != http.StatusInternalServerError {
+			t.Fatalf("expected request logged as 500, got %+v", entry)
+		}
+	})
+
+	t.Run("propagates request and trace ids", func(t *testing.T) {
+		logger := &logtest.Logger{}
+		s := NewServer(&strings.Builder{}, WithLogger(logger))
+		var fromHandler []any
+		s.HandleFunc("GET /bikes", func(w http.ResponseWriter, r *http.Request) {
+			fromHandler = logtest.WithFields(r.Context(), nil)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/bikes", nil)
+		req.Header.Set(HeaderRequestID, "req-42")
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+
+		if rec.Header().Get(HeaderRequestID) != "req-42" {
+			t.Fatalf("expected request id in response, got %q", rec.Header().Get(HeaderRequestID))
+		}
+		if logtest.ArgValue(fromHandler, "request_id") != "req-42" {
+			t.Fatalf("expected request id in handler context, got %v", fromHandler)
+		}
+		entry, _ := logger.Find(slog.LevelInfo, "httpserver request")
+		if entry.Value("request_id") != "req-42" ||
+			entry.Value("trace_id") != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("expected ids in request log, got %v", entry.Args)
+		}
+	})
+
+	t.Run("replaces invalid request ids", func(t *testing.T) {
+		s := NewServer(&strings.Builder{})
+		for _, header := range []string{"", "bad id\nforged=1", strings.Repeat("x", maxRequestID+1)} {
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			req.Header.Set(HeaderRequestID, header)
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if id := rec.Header().Get(HeaderRequestID); len(id) != 32 || id == header {
+				t.Errorf("expected generated id for %q, got %q", header, id)
+			}
+		}
+	})
+}
+
+func TestStart(t *testing.T) {
+	t.Run("serves until canceled and drains requests", func(t *testing.T) {
+		stderr := &lockedWriter{}
+		s := NewServer(stderr, WithAddr("127.0.0.1:0"), WithShutdownTimeout(time.Second))
+
+		started := make(chan struct{})
+		s.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(w, "done")
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		result := make(chan error, 1)
+		go func() { result <- s.Start(ctx) }()
+
+		select {
+		case <-s.Ready():
+		case err := <-result:
+			t.Fatalf("server failed to start: %v", err)
+		}
+
+		body := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + s.Addr() + "/slow")
+			if err != nil {
+				body <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			raw, _ := io.ReadAll(resp.Body)
+			body <- string(raw)
+		}()
+
+		<-started
+		cancel()
+
+		if got := <-body; got != "done" {
+			t.Fatalf("expected in-flight request to finish, got %q", got)
+		}
+		if err := <-result; err != nil {
+			t.Fatalf("unexpected error from Start: %v", err)
+		}
+		if !strings.Contains(stderr.String(), "shutting down") {
+			t.Fatalf("expected shutdown log, got %q", stderr.String())
+		}
+	})
+
+	t.Run("stop shuts down the server", func(t *testing.T) {
+		s := NewServer(&lockedWriter{}, WithAddr("127.0.0.1:0"))
+		var _ interfaces.Lifecycle = s
+
+		if err := s.Stop(context.Background()); err != nil {
+			t.Fatalf("unexpected error stopping before start: %v", err)
+		}
+
+		result := make(chan error, 1)
+		go func() { result <- s.Start(context.Background()) }()
+		<-s.Ready()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("unexpected error stopping: %v", err)
+		}
+		if err := <-result; err != nil {
+			t.Fatalf("unexpected error from Start: %v", err)
+		}
+		if _, err := http.Get("http://" + s.Addr() + "/healthz"); err == nil {
+			t.Fatal("expected server to refuse connections after Stop")
+		}
+	})
+
+	t.Run("fails on invalid address", func(t *testing.T) {
+		s := NewServer(&strings.Builder{}, WithAddr("not-an-address"))
+		if err := s.Start(context.Background()); err == nil {
+			t.Fatal("expected error listening on invalid address")
+		}
+	})
+
+	t.Run("invalid options panic", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected panic with negative timeout")
+			}
+		}()
+		NewServer(&strings.Builder{}, WithTimeouts(-1, 0, 0))
+	})
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.Write(p)
+}
+
+func (w *lockedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sb.String()
+}
@@ -0,0 +1,167 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// HeaderRequestID es el header con que llega y se responde el id de la request.
+const HeaderRequestID = "X-Request-ID"
+
+const maxRequestID = 128
+
+// statusRecorder guarda el status y los bytes escritos de una respuesta.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap permite que http.ResponseController llegue al writer original.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// log registra método, path, status, bytes y duración de cada request.
+func (s *Server) log(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			duration := time.Since(start)
+
+			if s.options.logger != nil {
+				s.options.logger.InfoContext(r.Context(), "httpserver request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"status", status,
+					"bytes", rec.bytes,
+					"duration", duration,
+				)
+				return
+			}
+			fmt.Fprintf(s.stderr, "%s: %s %s %d %dB %s", SigHTTP, r.Method, r.URL.Path, status, rec.bytes, duration)
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// recover convierte los panics de los handlers en un 500 y los registra con su stack.
+func (s *Server) recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// http.ErrAbortHandler es la forma documentada de abortar una respuesta
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			if s.options.logger != nil {
+				s.options.logger.ErrorContext(r.Context(), "httpserver panic",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", fmt.Sprint(v),
+					"stack", string(debug.Stack()),
+				)
+			} else {
+				fmt.Fprintf(s.stderr, "%s: panic in %s %s: %v\n%s", SigHTTP, r.Method, r.URL.Path, v, debug.Stack())
+			}
+
+			if rec.status == 0 {
+				http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// requestID agrega al contexto de la request los campos de log request_id, del
+// header X-Request-ID o generado si no viene o no es válido, y trace_id, si
+// llega un header traceparent de W3C. El id se devuelve en la respuesta.
+func (s *Server) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+
+		args := []any{interfaces.LogKeyRequestID, id}
+		if trace, ok := traceID(r.Header.Get("traceparent")); ok {
+			args = append(args, interfaces.LogKeyTraceID, trace)
+		}
+		ctx := interfaces.ContextWithLogFields(r.Context(), args...)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID acepta ids no vacíos de ASCII visible, para que un cliente no
+// pueda meter saltos de línea o basura en los logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		panic(fmt.Sprintf("%s: failed to generate request id: %v", SigHTTP, err))
+	}
+	return hex.EncodeToString(raw)
+}
+
+// traceID extrae el trace id de un header traceparent: version-traceid-spanid-flags.
+func traceID(traceparent string) (string, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || parts[1] == strings.Repeat("0", 32) {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || strings.ToLower(parts[1]) != parts[1] {
+		return "", false
+	}
+	return parts[1], true
+}
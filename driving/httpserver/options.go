@@ -0,0 +1,130 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+type readyCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+type serverOpts struct {
+	addr              string
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	logger            interfaces.Logger
+	checks            []readyCheck
+}
+
+type ServerOption func(options *serverOpts)
+
+// WithAddr establece la dirección donde escucha el servidor, por ejemplo ":8080".
+// Panics si addr está vacío.
+func WithAddr(addr string) ServerOption {
+	return func(options *serverOpts) {
+		if addr == "" {
+			panic(fmt.Sprintf("%s: address cannot be empty", SigHTTP))
+		}
+		options.addr = addr
+	}
+}
+
+// WithTimeouts establece los timeouts de lectura, escritura e inactividad de las
+// conexiones. Panics si alguno es negativo.
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(options *serverOpts) {
+		if read < 0 || write < 0 || idle < 0 {
+			panic(fmt.Sprintf("%s: timeouts cannot be negative", SigHTTP))
+		}
+		options.readTimeout = read
+		options.writeTimeout = write
+		options.idleTimeout = idle
+	}
+}
+
+// WithReadHeaderTimeout establece cuánto se espera por los headers de una request.
+// Panics si d es negativo.
+func WithReadHeaderTimeout(d time.Duration) ServerOption {
+	return func(options *serverOpts) {
+		if d < 0 {
+			panic(fmt.Sprintf("%s: read header timeout cannot be negative", SigHTTP))
+		}
+		options.readHeaderTimeout = d
+	}
+}
+
+// WithShutdownTimeout establece cuánto se espera a las requests en curso al
+// cancelar el contexto de Start. Panics si d no es positivo.
+func WithShutdownTimeout(d time.Duration) ServerOption {
+	return func(options *serverOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: shutdown timeout must be positive", SigHTTP))
+		}
+		options.shutdownTimeout = d
+	}
+}
+
+// WithLogger registra cada request y cada panic en logger. Sin logger se escriben
+// en el stderr del servidor. Panics si logger es nil.
+func WithLogger(logger interfaces.Logger) ServerOption {
+	return func(options *serverOpts) {
+		if logger == nil {
+			panic(fmt.Sprintf("%s: logger cannot be nil", SigHTTP))
+		}
+		options.logger = logger
+	}
+}
+
+// WithReadyCheck agrega un chequeo a /readyz. El servidor está listo solo si
+// todos los chequeos retornan nil. Panics si name está vacío o check es nil.
+func WithReadyCheck(name string, check func(ctx context.Context) error) ServerOption {
+	return func(options *serverOpts) {
+		if name == "" || check == nil {
+			panic(fmt.Sprintf("%s: ready check needs a name and a function", SigHTTP))
+		}
+		options.checks = append(options.checks, readyCheck{name: name, check: check})
+	}
+}
+
+// WithConnectorCheck agrega a /readyz un chequeo de la conexión a la base de datos.
+// Panics si c es nil.
+func WithConnectorCheck(c interfaces.Connector) ServerOption {
+	if c == nil {
+		panic(fmt.Sprintf("%s: connector cannot be nil", SigHTTP))
+	}
+	return WithReadyCheck("database", func(ctx context.Context) error {
+		if !c.IsConnected() {
+			return fmt.Errorf("database is not connected")
+		}
+		return nil
+	})
+}
+
+// WithMigrationCheck agrega a /readyz un chequeo de que la base de datos tenga al
+// menos la versión de migraciones want. Panics si m es nil o want es negativo.
+func WithMigrationCheck(m interfaces.Migrator, want int) ServerOption {
+	if m == nil {
+		panic(fmt.Sprintf("%s: migrator cannot be nil", SigHTTP))
+	}
+	if want < 0 {
+		panic(fmt.Sprintf("%s: migration version cannot be negative", SigHTTP))
+	}
+	return WithReadyCheck("migrations", func(ctx context.Context) error {
+		version, err := m.Version()
+		if err != nil {
+			return err
+		}
+		if version < want {
+			return fmt.Errorf("migration version %d is behind %d", version, want)
+		}
+		return nil
+	})
+}
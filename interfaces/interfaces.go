@@ -1,14 +1,22 @@
 package interfaces
 
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+)
+
 type Migrator interface {
 	Version() (int, error)
-    Move(steps int, inverse bool) error
+	Move(steps int, inverse bool) error
 }
 
 type Connector interface {
-    Connect(driver string) error 
-    Close() error
-    IsConnected() bool
+	Connect(driver string) error
+	Close() error
+	IsConnected() bool
 }
 
 type DataHandler interface {
@@ -16,9 +24,189 @@ type DataHandler interface {
 	Migrator
 }
 
+// Logger es compatible con *slog.Logger. Los métodos *Context reciben el
+// contexto de la operación para que el logger agregue sus campos, como el id de
+// la request.
 type Logger interface {
 	Debug(msg string, args ...any)
 	Info(msg string, args ...any)
 	Warn(msg string, args ...any)
 	Error(msg string, args ...any)
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+// Llaves de los campos de log más comunes, para que todos los paquetes usen
+// las mismas.
+const (
+	LogKeyRequestID = "request_id"
+	LogKeyTraceID   = "trace_id"
+	LogKeyTenant    = "tenant"
+)
+
+type logFieldsKey struct{}
+
+// ContextWithLogFields agrega campos de log al contexto, en pares clave-valor
+// como en slog. Un Logger que los soporte los incluye en cada registro hecho con
+// ese contexto. Un campo con la misma clave que uno anterior lo reemplaza.
+func ContextWithLogFields(ctx context.Context, args ...any) context.Context {
+	attrs := slog.Group("", args...).Value.Group()
+	if len(attrs) == 0 {
+		return ctx
+	}
+
+	prev := LogFieldsFromContext(ctx)
+	fields := make([]slog.Attr, 0, len(prev)+len(attrs))
+	for _, a := range prev {
+		if !slices.ContainsFunc(attrs, func(b slog.Attr) bool { return b.Key == a.Key }) {
+			fields = append(fields, a)
+		}
+	}
+	fields = append(fields, attrs...)
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// LogFieldsFromContext retorna los campos guardados con ContextWithLogFields.
+func LogFieldsFromContext(ctx context.Context) []slog.Attr {
+	fields, _ := ctx.Value(logFieldsKey{}).([]slog.Attr)
+	return fields
+}
+
+// Lifecycle es un componente que el App inicia y detiene. Start puede retornar de
+// inmediato o bloquear hasta que su contexto se cancele; Stop debe liberar sus
+// recursos antes del deadline de ctx.
+type Lifecycle interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// ErrNotFound es retornado por los repositorios cuando la entidad no existe.
+var ErrNotFound = errors.New("entity not found")
+
+type FilterOp string
+
+const (
+	OpEq   FilterOp = "="
+	OpNe   FilterOp = "!="
+	OpLt   FilterOp = "<"
+	OpLte  FilterOp = "<="
+	OpGt   FilterOp = ">"
+	OpGte  FilterOp = ">="
+	OpLike FilterOp = "LIKE"
+	OpIn   FilterOp = "IN"
+)
+
+// Filter compara un campo de la entidad con un valor. Para OpIn Value debe ser un slice.
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value any
+}
+
+// ListQuery describe filtros, orden y paginación de un listado. Limit 0 significa sin límite.
+type ListQuery struct {
+	Filters []Filter
+	OrderBy string
+	Desc    bool
+	Limit   int
+	Offset  int
+}
+
+// Page es una página de resultados junto al total de entidades que cumplen los filtros.
+type Page[T any] struct {
+	Items  []T
+	Total  int
+	Limit  int
+	Offset int
+}
+
+type Repository[T any, K comparable] interface {
+	Create(ctx context.Context, entity *T) error
+	Get(ctx context.Context, id K) (*T, error)
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, id K) error
+	List(ctx context.Context, query ListQuery) (Page[T], error)
+}
+
+// UnitOfWork ejecuta fn de forma atómica. Los repositorios usados con el ctx
+// entregado a fn comparten la misma transacción.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Event es un evento de dominio que se publica después de confirmar la transacción
+// que lo generó.
+type Event struct {
+	ID         int64
+	Topic      string
+	Key        string
+	Payload    []byte
+	Headers    map[string]string
+	OccurredAt time.Time
+}
+
+// Publisher entrega eventos a un sistema externo. Publish puede recibir el mismo
+// evento más de una vez, por lo que los consumidores deben ser idempotentes.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Job es un trabajo en segundo plano. Los jobs con mayor Priority se ejecutan
+// primero y ninguno se ejecuta antes de RunAt.
+type Job struct {
+	ID          int64
+	Queue       string
+	Payload     []byte
+	Priority    int
+	RunAt       time.Time
+	MaxAttempts int
+	Attempts    int
+	LastError   string
+}
+
+// JobHandler procesa un job. Si retorna error el job se reintenta más tarde.
+type JobHandler func(ctx context.Context, job Job) error
+
+type JobQueue interface {
+	Enqueue(ctx context.Context, job Job) (int64, error)
+	Process(ctx context.Context, queue string, handler JobHandler) error
+}
+
+var (
+	// ErrLockHeld se retorna cuando la llave tiene un lease vigente, aunque sea
+	// del mismo dueño. Un lease propio se extiende con Refresh.
+	ErrLockHeld = errors.New("lock is held")
+	// ErrLockLost se retorna cuando el lease expiró o fue tomado por otro dueño.
+	ErrLockLost = errors.New("lock lease was lost")
+)
+
+// Lease es un lock adquirido hasta ExpiresAt. Token es un fencing token que crece
+// en cada adquisición de la llave; los recursos protegidos pueden rechazar
+// escrituras con un token menor al último visto.
+type Lease struct {
+	Key       string
+	Owner     string
+	Token     int64
+	ExpiresAt time.Time
+}
+
+type Locker interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+	Refresh(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error)
+	Release(ctx context.Context, lease Lease) error
+}
+
+// ErrCacheMiss se retorna cuando la llave no está en el cache o ya expiró.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache guarda valores por llave. Un ttl 0 significa que el valor no expira.
+// GetOrLoad llama a load una sola vez por llave aunque haya varias llamadas
+// concurrentes esperando el mismo valor.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error)
 }
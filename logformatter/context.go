@@ -0,0 +1,45 @@
+package logformatter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// ContextHandler envuelve un slog.Handler y agrega a cada registro los campos
+// guardados con interfaces.ContextWithLogFields en el contexto con que se
+// registró. Los loggers que crea NewLogFormatter ya vienen envueltos.
+type ContextHandler struct {
+	inner slog.Handler
+}
+
+// NewContextHandler retorna inner envuelto en un ContextHandler. Panics si inner
+// es nil.
+func NewContextHandler(inner slog.Handler) *ContextHandler {
+	if inner == nil {
+		panic(fmt.Sprintf("%s: handler cannot be nil", SigLogFormatter))
+	}
+	return &ContextHandler{inner: inner}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if fields := interfaces.LogFieldsFromContext(ctx); len(fields) > 0 {
+		r = r.Clone()
+		r.AddAttrs(fields...)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{inner: h.inner.WithGroup(name)}
+}
@@ -0,0 +1,54 @@
+package logformatter
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+func TestContextHandler(t *testing.T) {
+	out := &lockedBuffer{}
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(out, nil)))
+
+	ctx := interfaces.ContextWithLogFields(context.Background(),
+		interfaces.LogKeyRequestID, "req-1",
+		interfaces.LogKeyTenant, "acme",
+	)
+	ctx = interfaces.ContextWithLogFields(ctx, interfaces.LogKeyRequestID, "req-2", "attempt", 2)
+
+	logger.InfoContext(ctx, "rider created", "rider", 7)
+	logger.With("component", "cache").WarnContext(ctx, "cache miss")
+	logger.Info("no context fields")
+
+	lines := jsonLines(t, out)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", out.String())
+	}
+	l := lines[0]
+	if l["request_id"] != "req-2" || l["tenant"] != "acme" || l["attempt"] != float64(2) || l["rider"] != float64(7) {
+		t.Errorf("expected context fields, got %v", l)
+	}
+	if l := lines[1]; l["component"] != "cache" || l["request_id"] != "req-2" {
+		t.Errorf("expected fields with derived logger, got %v", l)
+	}
+	if l := lines[2]; l["request_id"] != nil {
+		t.Errorf("expected no fields without context, got %v", l)
+	}
+}
+
+func TestFormatterLoggerUsesContext(t *testing.T) {
+	out := &lockedBuffer{}
+	lf, _ := NewLogFormatter(out, nil, false, 0, WithSink("file", out))
+
+	ctx := interfaces.ContextWithLogFields(context.Background(), interfaces.LogKeyTraceID, "abc")
+	lf.ReportErrorContext(ctx, errorString("sqlhandler outbox: relay failed"))
+	if err := lf.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	if lines := jsonLines(t, out); len(lines) != 1 || lines[0]["trace_id"] != "abc" {
+		t.Fatalf("expected trace id in sink output, got %q", out.String())
+	}
+}
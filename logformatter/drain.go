@@ -0,0 +1,121 @@
+package logformatter
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"time"
+)
+
+type flushRequest struct {
+	ctx   context.Context
+	reply chan error
+}
+
+// Flush espera a que se registre todo lo escrito hasta ahora, incluida la línea
+// sin terminar y lo guardado en disco. Si Start no está corriendo lo registra en
+// el goroutine del llamador. Retorna el error de ctx si vence antes de terminar.
+func (lf *LogFormatter) Flush(ctx context.Context) error {
+	if !lf.started.Load() {
+		return lf.drain(ctx)
+	}
+
+	req := flushRequest{ctx: ctx, reply: make(chan error, 1)}
+	select {
+	case lf.flushReq <- req:
+	case <-lf.done:
+		return lf.drain(ctx)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close es Flush con el drain timeout configurado. Si Start no está corriendo
+// también cierra los sinks; si no, se cierran cuando Start retorne.
+func (lf *LogFormatter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), lf.options.drainTimeout)
+	defer cancel()
+	if err := lf.Flush(ctx); err != nil {
+		return err
+	}
+	if lf.started.Load() {
+		select {
+		case <-lf.done:
+		default:
+			return nil
+		}
+	}
+	return lf.closeSinks(ctx)
+}
+
+// drain registra los errores, las líneas del canal y del disco, luego la línea
+// sin terminar, que es la más reciente, y por último los resúmenes de errores
+// repetidos y mensajes muestreados que aún no se registraban. Termina cuando
+// los sinks escribieron todo.
+func (lf *LogFormatter) drain(ctx context.Context) error {
+	lf.lineMu.Lock()
+	partial := bytes.TrimSuffix(lf.partial, []byte("\r"))
+	lf.partial = nil
+	lf.lineMu.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case err := <-lf.errChan:
+			lf.handleErr(err)
+			continue
+		case msg := <-lf.msgChan:
+			lf.handleMsg(msg)
+			continue
+		default:
+		}
+
+		if lf.spool == nil {
+			break
+		}
+		rec, ok, err := lf.spool.pop()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		lf.handleMsg(rec)
+	}
+
+	if len(partial) > 0 {
+		lf.handleMsg(partial)
+	}
+	lf.flushRepeats(time.Now(), true)
+	lf.flushSamples(time.Now(), true)
+	return lf.flushSinks(ctx)
+}
+
+// shutdown corre al salir de Start: desde aquí Write registra directo, sin
+// canal, y se drena lo pendiente y se cierran los sinks dentro del drain timeout.
+func (lf *LogFormatter) shutdown() {
+	lf.closed.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), lf.options.drainTimeout)
+	defer cancel()
+	if err := lf.drain(ctx); err != nil {
+		lf.logger.Warn("log drain interrupted", "component", SigLogFormatter, "error", err.Error())
+	}
+	lf.reportStats()
+	if err := lf.closeSinks(ctx); err != nil {
+		lf.logFallback(context.Background(), entry{component: SigLogFormatter, level: slog.LevelError, msg: err.Error()})
+	}
+
+	if lf.spool != nil {
+		lf.spool.close()
+	}
+}
@@ -0,0 +1,147 @@
+package logformatter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowLogger tarda delay en cada mensaje.
+type slowLogger struct {
+	mu    sync.Mutex
+	delay time.Duration
+	msgs  []string
+}
+
+func (l *slowLogger) record(msg string) {
+	time.Sleep(l.delay)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, msg)
+}
+
+func (l *slowLogger) Debug(msg string, args ...any) { l.record(msg) }
+func (l *slowLogger) Info(msg string, args ...any)  { l.record(msg) }
+func (l *slowLogger) Warn(msg string, args ...any)  { l.record(msg) }
+func (l *slowLogger) Error(msg string, args ...any) { l.record(msg) }
+
+func (l *slowLogger) DebugContext(_ context.Context, msg string, args ...any) { l.record(msg) }
+func (l *slowLogger) InfoContext(_ context.Context, msg string, args ...any)  { l.record(msg) }
+func (l *slowLogger) WarnContext(_ context.Context, msg string, args ...any)  { l.record(msg) }
+func (l *slowLogger) ErrorContext(_ context.Context, msg string, args ...any) { l.record(msg) }
+
+func (l *slowLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.msgs...)
+}
+
+func TestDrain(t *testing.T) {
+	t.Run("start drains on cancel", func(t *testing.T) {
+		logger := &slowLogger{}
+		lf, errChan := NewLogFormatter(&lockedBuffer{}, logger, false, 0)
+
+		for i := range 50 {
+			fmt.Fprintf(lf, "cache: line %d\n", i)
+		}
+		errChan <- errors.New("locker: lost lease")
+		lf.Write([]byte("jobqueue: last words"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := lf.Start(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		msgs := logger.messages()
+		if len(msgs) != 52 {
+			t.Fatalf("expected 52 messages, got %d: %q", len(msgs), msgs)
+		}
+		if msgs[len(msgs)-1] != "last words" {
+			t.Fatalf("expected unterminated line last, got %q", msgs[len(msgs)-1])
+		}
+		if !strings.Contains(strings.Join(msgs, "|"), "lost lease") {
+			t.Fatalf("expected queued error to be logged, got %q", msgs)
+		}
+
+		lf.Write([]byte("cache: after stop\n"))
+		if msgs := logger.messages(); msgs[len(msgs)-1] != "after stop" {
+			t.Fatalf("expected writes after Start to be logged directly, got %q", msgs)
+		}
+	})
+
+	t.Run("drain stops at timeout", func(t *testing.T) {
+		logger := &slowLogger{delay: 10 * time.Millisecond}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, logger, false, 0, WithDrainTimeout(30*time.Millisecond))
+
+		for i := range 100 {
+			fmt.Fprintf(lf, "cache: line %d\n", i)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		start := time.Now()
+		lf.Start(ctx)
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("expected drain to stop at its timeout, took %s", elapsed)
+		}
+
+		msgs := logger.messages()
+		if len(msgs) >= 100 || msgs[len(msgs)-1] != "log drain interrupted" {
+			t.Fatalf("expected interrupted drain, got %d messages ending in %q", len(msgs), msgs[len(msgs)-1])
+		}
+	})
+}
+
+func TestFlush(t *testing.T) {
+	t.Run("without start", func(t *testing.T) {
+		logger := &slowLogger{}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, logger, false, 0)
+
+		lf.Write([]byte("cache: one\ncache: two"))
+		if err := lf.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.Join(logger.messages(), "|"); got != "one|two" {
+			t.Fatalf("expected both lines, got %q", got)
+		}
+	})
+
+	t.Run("while running", func(t *testing.T) {
+		logger := &slowLogger{}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, logger, false, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go lf.Start(ctx)
+		<-lf.Ready()
+
+		for i := range 20 {
+			fmt.Fprintf(lf, "cache: line %d\n", i)
+		}
+		if err := lf.Flush(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if msgs := logger.messages(); len(msgs) != 20 {
+			t.Fatalf("expected 20 messages after flush, got %d", len(msgs))
+		}
+	})
+
+	t.Run("respects context", func(t *testing.T) {
+		logger := &slowLogger{delay: 20 * time.Millisecond}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, logger, false, 0)
+		for i := range 10 {
+			fmt.Fprintf(lf, "cache: line %d\n", i)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+		if err := lf.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package logformatter
+
+import (
+	"bytes"
+	"time"
+	"unicode/utf8"
+)
+
+// Write junta los bytes recibidos en líneas y envía cada línea completa al canal
+// sin bloquear. Lo que queda sin salto de línea espera al siguiente Write, hasta
+// el largo máximo o hasta que pase el idle flush. Las líneas se envían sin tomar
+// lineMu, porque con OverflowBlock el envío puede esperar a que Start lea.
+func (lf *LogFormatter) Write(p []byte) (int, error) {
+	lf.lineMu.Lock()
+	lf.partial = append(lf.partial, p...)
+	lf.lastWrite = time.Now()
+
+	var lines [][]byte
+	rest := lf.partial
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+		line := rest[:i]
+		for len(line) > lf.options.maxLine {
+			cut := lf.cut(line)
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+		lines = append(lines, line)
+		rest = rest[i+1:]
+	}
+	for len(rest) > lf.options.maxLine {
+		cut := lf.cut(rest)
+		lines = append(lines, rest[:cut])
+		rest = rest[cut:]
+	}
+
+	// copiamos lo pendiente para no retener el buffer ya procesado, que queda
+	// solo para las líneas que enviamos abajo
+	lf.partial = append(lf.partial[:0:0], rest...)
+	lf.lineMu.Unlock()
+
+	var err error
+	for _, line := range lines {
+		err = firstErr(err, lf.emit(line))
+	}
+	return len(p), err
+}
+
+// cut retorna dónde partir una línea más larga que el máximo, sin cortar una
+// runa a la mitad.
+func (lf *LogFormatter) cut(line []byte) int {
+	cut := lf.options.maxLine
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		return lf.options.maxLine
+	}
+	return cut
+}
+
+// flushIdle registra la línea pendiente si no ha llegado nada en el idle flush.
+func (lf *LogFormatter) flushIdle(now time.Time) {
+	lf.lineMu.Lock()
+	if len(lf.partial) == 0 || now.Sub(lf.lastWrite) < lf.options.idleFlush {
+		lf.lineMu.Unlock()
+		return
+	}
+	line := lf.partial
+	lf.partial = nil
+	lf.lineMu.Unlock()
+
+	lf.emit(line)
+}
+
+// emit envía una línea al canal sin bloquear. Si el canal está lleno aplica la
+// política de overflow, y si Start ya terminó la registra directo. Mientras haya
+// líneas en disco las nuevas también van al disco, para no desordenarlas.
+func (lf *LogFormatter) emit(line []byte) error {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) == 0 {
+		return nil
+	}
+
+	msg := make([]byte, len(line))
+	copy(msg, line)
+
+	// Start ya terminó: nadie leerá el canal
+	if lf.closed.Load() {
+		lf.handleMsg(msg)
+		return nil
+	}
+	if lf.spool != nil && !lf.spool.empty() {
+		return lf.spill(msg)
+	}
+	select {
+	case lf.msgChan <- msg:
+		return nil
+	default:
+		return lf.overflow(msg)
+	}
+}
+
+func firstErr(current, err error) error {
+	if current != nil {
+		return current
+	}
+	return err
+}
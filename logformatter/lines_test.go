@@ -0,0 +1,108 @@
+package logformatter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// drain retorna los mensajes que hay en el canal del formatter sin bloquear.
+func drain(lf *LogFormatter) []string {
+	var msgs []string
+	for {
+		select {
+		case msg := <-lf.msgChan:
+			msgs = append(msgs, string(msg))
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestWriteReassemblesLines(t *testing.T) {
+	t.Run("joins fragments and splits lines", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 0)
+
+		lf.Write([]byte("sqlhandler connector: ping "))
+		lf.Write([]byte("to db"))
+		if msgs := drain(lf); len(msgs) != 0 {
+			t.Fatalf("expected partial line to wait, got %q", msgs)
+		}
+
+		lf.Write([]byte(" connection\nsqlhandler migrator: setting new db\r\n\nsqlhandler: tail"))
+		want := []string{"sqlhandler connector: ping to db connection", "sqlhandler migrator: setting new db"}
+		if got := drain(lf); strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+
+		lf.Write([]byte("\n"))
+		if got := drain(lf); len(got) != 1 || got[0] != "sqlhandler: tail" {
+			t.Fatalf("expected tail line, got %q", got)
+		}
+	})
+
+	t.Run("splits long lines", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 0, WithMaxLineLength(4))
+
+		lf.Write([]byte("abcdefghij"))
+		if got := drain(lf); strings.Join(got, "|") != "abcd|efgh" {
+			t.Fatalf("expected chunks of 4 bytes, got %q", got)
+		}
+
+		lf.Write([]byte("\nñandú\n"))
+		if got := drain(lf); strings.Join(got, "|") != "ij|ñan|dú" {
+			t.Fatalf("expected chunks without broken runes, got %q", got)
+		}
+	})
+
+	t.Run("falls back to stderr when the buffer is full", func(t *testing.T) {
+		stderr := &lockedBuffer{}
+		lf, _ := NewLogFormatter(stderr, nil, false, 1)
+
+		lf.Write([]byte("first\ncache: failed to evict\n"))
+		if got := drain(lf); len(got) != 1 || got[0] != "first" {
+			t.Fatalf("expected first line in channel, got %q", got)
+		}
+		lines := jsonLines(t, stderr)
+		if len(lines) != 1 || lines[0]["msg"] != "failed to evict" || lines[0]["component"] != "cache" || lines[0]["level"] != "ERROR" {
+			t.Fatalf("expected second line as JSON in stderr, got %q", stderr.String())
+		}
+	})
+}
+
+func TestIdleFlush(t *testing.T) {
+	lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 0, WithIdleFlush(time.Minute))
+
+	lf.Write([]byte("jobqueue: unterminated"))
+	lf.flushIdle(time.Now())
+	if got := drain(lf); len(got) != 0 {
+		t.Fatalf("expected line to wait before idle flush, got %q", got)
+	}
+
+	lf.flushIdle(time.Now().Add(time.Minute))
+	if got := drain(lf); len(got) != 1 || got[0] != "jobqueue: unterminated" {
+		t.Fatalf("expected line after idle flush, got %q", got)
+	}
+
+	t.Run("start flushes idle lines", func(t *testing.T) {
+		out := &lockedBuffer{}
+		lf, _ := NewLogFormatter(out, nil, true, 0, WithIdleFlush(10*time.Millisecond))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go lf.Start(ctx)
+		<-lf.Ready()
+
+		lf.Write([]byte("cache: no newline here"))
+
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(out.String(), "no newline here") {
+			if time.Now().After(deadline) {
+				t.Fatal("idle line was never logged")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+}
@@ -2,37 +2,70 @@ package logformatter
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-on-bike/bike/interfaces"
 )
 
+const SigLogFormatter string = "logformatter"
+
+// LogFormatter recibe las líneas que los paquetes escriben en su stderr y las
+// registra en logger, con el prefijo Sig* como atributo component y el nivel
+// deducido del mensaje.
 type LogFormatter struct {
-	stderr  io.Writer
-	logger  interfaces.Logger
-	msgChan chan []byte
-	errChan chan error
+	stderr    io.Writer
+	fallback  *slog.Logger
+	logger    interfaces.Logger
+	redactLog bool
+	options   formatterOpts
+	sinks     []*sink
+
+	lineMu    sync.Mutex
+	partial   []byte
+	lastWrite time.Time
+	msgChan   chan []byte
+	errChan   chan error
+
+	spool      *spool
+	spillReady chan struct{}
+	dropped    atomic.Uint64
+	spilled    atomic.Uint64
+	reported   OverflowStats
+
+	repeatMu sync.Mutex
+	repeats  map[string]*repeat
+	sampleMu sync.Mutex
+	samples  map[string]*sampleState
+
+	flushReq chan flushRequest
+	closed   atomic.Bool
+
+	started  atomic.Bool
+	ready    chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
 }
 
 const defaultBufferSize = 1024
 
+// NewLogFormatter crea el formatter. El canal retornado se mantiene por
+// compatibilidad: lo que se envía por él se registra como ReportError, pero sin
+// la ubicación del llamador. Para código nuevo se prefiere ReportError o Report.
 func NewLogFormatter(
 	stderr io.Writer,
 	logger interfaces.Logger,
 	textFormat bool,
 	bufferSize int,
+	opts ...FormatterOption,
 ) (*LogFormatter, chan error) {
 	if stderr == nil {
-		panic("logformatter: stderr cannot be nil")
-	}
-
-	if logger == nil {
-		if textFormat {
-			logger = slog.New(slog.NewTextHandler(stderr, nil))
-		} else {
-			logger = slog.New(slog.NewJSONHandler(stderr, nil))
-		}
+		panic(fmt.Sprintf("%s: stderr cannot be nil", SigLogFormatter))
 	}
 
 	if bufferSize == 0 {
@@ -42,51 +75,198 @@ func NewLogFormatter(
 	// Creamos un buffer suficientemente grande para evitar bloqueos
 	lf := &LogFormatter{
 		stderr:  stderr,
-		logger:  logger,
 		msgChan: make(chan []byte, bufferSize),
 		errChan: make(chan error, bufferSize),
+		ready:   make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+
+		spillReady: make(chan struct{}, 1),
+		flushReq:   make(chan flushRequest),
+		repeats:    make(map[string]*repeat),
+		samples:    make(map[string]*sampleState),
+	}
+	lf.options = formatterOpts{
+		maxLine:       defaultMaxLine,
+		idleFlush:     defaultIdleFlush,
+		statsInterval: defaultStats,
+		drainTimeout:  defaultDrain,
+	}
+	for _, opt := range opts {
+		opt(&lf.options)
 	}
 
-	return lf, lf.errChan
-}
+	switch {
+	case len(lf.options.sinks) > 0:
+		if logger != nil {
+			panic(fmt.Sprintf("%s: logger cannot be used with sinks", SigLogFormatter))
+		}
+		handlers := make(fanout, 0, len(lf.options.sinks))
+		for _, cfg := range lf.options.sinks {
+			s := newSink(cfg)
+			lf.sinks = append(lf.sinks, s)
+			handlers = append(handlers, s.handler(cfg.opts))
+		}
+		logger = slog.New(lf.wrap(handlers))
+	case logger == nil && textFormat:
+		logger = slog.New(lf.wrap(slog.NewTextHandler(stderr, nil)))
+	case logger == nil:
+		logger = slog.New(lf.wrap(slog.NewJSONHandler(stderr, nil)))
+	default:
+		// un logger propio no pasa por el RedactHandler: redactamos antes de entregarle
+		lf.redactLog = len(lf.options.redactors) > 0
+	}
+	lf.logger = logger
 
-// Write ahora simplemente envía los bytes al canal y retorna inmediatamente
-func (lf *LogFormatter) Write(p []byte) (n int, err error) {
-	// Hacemos una copia de los bytes porque p podría ser reusado por el caller
-	msg := make([]byte, len(p))
-	copy(msg, p)
+	// el respaldo escribe en stderr con el mismo formato que el logger por
+	// defecto, para no mezclar líneas sin formato en la salida
+	if textFormat {
+		lf.fallback = slog.New(lf.wrap(slog.NewTextHandler(stderr, nil)))
+	} else {
+		lf.fallback = slog.New(lf.wrap(slog.NewJSONHandler(stderr, nil)))
+	}
 
-	// Enviamos al canal de manera no bloqueante
-	select {
-	case lf.msgChan <- msg:
-		return len(p), nil
-	default:
-		// Si el canal está lleno, escribimos directamente al stderr
-		// para evitar pérdida de logs
-		return lf.stderr.Write(p)
+	if lf.options.overflow == OverflowSpill {
+		lf.spool = newSpool(lf.options.spillPath, lf.options.spillSize)
 	}
+
+	return lf, lf.errChan
 }
 
+// Start formatea los mensajes y errores recibidos hasta que ctx se cancele o se
+// llame a Stop. También lanza el goroutine de cada sink, que se detiene al
+// cerrar los sinks. Antes de retornar drena lo pendiente durante el drain
+// timeout. Bloquea, y solo puede llamarse una vez.
 func (lf *LogFormatter) Start(ctx context.Context) error {
+	if !lf.started.CompareAndSwap(false, true) {
+		return fmt.Errorf("%s: already started", SigLogFormatter)
+	}
+	for _, s := range lf.sinks {
+		s.start()
+	}
+	defer close(lf.done)
+	defer lf.shutdown()
+	close(lf.ready)
+
+	var idle <-chan time.Time
+	if lf.options.idleFlush > 0 {
+		ticker := time.NewTicker(max(lf.options.idleFlush/2, time.Millisecond))
+		defer ticker.Stop()
+		idle = ticker.C
+	}
+	stats := time.NewTicker(lf.options.statsInterval)
+	defer stats.Stop()
+	var repeats <-chan time.Time
+	if lf.options.dedupWindow > 0 {
+		ticker := time.NewTicker(lf.options.dedupWindow)
+		defer ticker.Stop()
+		repeats = ticker.C
+	}
+	var samples <-chan time.Time
+	if d := lf.sampleInterval(); d > 0 {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		samples = ticker.C
+	}
+
 	for {
 		select {
+		case now := <-idle:
+			lf.flushIdle(now)
+		case <-stats.C:
+			lf.reportStats()
+		case now := <-repeats:
+			lf.flushRepeats(now, false)
+		case now := <-samples:
+			lf.flushSamples(now, false)
+		case <-lf.spillReady:
+			lf.drainSpool()
+		case req := <-lf.flushReq:
+			req.reply <- lf.drain(req.ctx)
 		case err := <-lf.errChan:
-			if err == nil {
-				continue
-			}
-			lf.logger.Error(err.Error())
+			lf.handleErr(err)
 		case msg := <-lf.msgChan:
-			// Eliminamos los saltos de línea al final
-			msgLen := len(msg)
-			for msgLen > 0 && msg[msgLen-1] == '\n' {
-				msgLen--
-			}
-			// Solo logeamos si quedó algo después de limpiar los saltos de línea
-			if msgLen > 0 {
-				lf.logger.Info(string(msg[:msgLen]))
-			}
+			lf.handleMsg(msg)
 		case <-ctx.Done():
 			return nil
+		case <-lf.stop:
+			return nil
 		}
 	}
 }
+
+func (lf *LogFormatter) handleMsg(msg []byte) {
+	e := lf.parse(string(msg), slog.LevelInfo, true)
+	if len(lf.options.sampling) > 0 && !lf.sample(e, time.Now()) {
+		return
+	}
+	lf.log(e)
+}
+
+// handleErr registra lo que llega por el canal de errores: lo encolado por
+// Report, o un error enviado directo al canal que retorna NewLogFormatter.
+func (lf *LogFormatter) handleErr(err error) {
+	r, ok := err.(*reported)
+	if !ok {
+		r = &reported{ctx: context.Background(), level: slog.LevelError, err: err}
+	}
+	lf.report(r)
+}
+
+// wrap envuelve inner en un RedactHandler, si hay redactores, dentro de un
+// ContextHandler, para que la redacción también cubra los campos del contexto.
+func (lf *LogFormatter) wrap(inner slog.Handler) slog.Handler {
+	if len(lf.options.redactors) > 0 {
+		inner = &RedactHandler{inner: inner, redactors: lf.options.redactors}
+	}
+	return NewContextHandler(inner)
+}
+
+func (lf *LogFormatter) log(e entry, attrs ...any) {
+	lf.logContext(context.Background(), e, attrs...)
+}
+
+// logContext registra e con ctx, para que el logger agregue sus campos de log.
+func (lf *LogFormatter) logContext(ctx context.Context, e entry, attrs ...any) {
+	var args []any
+	if e.component != "" {
+		args = append(args, "component", e.component)
+	}
+	if lf.redactLog {
+		e.msg = lf.options.redactors.redact(e.msg)
+		attrs = lf.options.redactors.redactArgs(attrs)
+	}
+	args = append(args, attrs...)
+
+	switch {
+	case e.level >= slog.LevelError:
+		lf.logger.ErrorContext(ctx, e.msg, args...)
+	case e.level >= slog.LevelWarn:
+		lf.logger.WarnContext(ctx, e.msg, args...)
+	case e.level >= slog.LevelInfo:
+		lf.logger.InfoContext(ctx, e.msg, args...)
+	default:
+		lf.logger.DebugContext(ctx, e.msg, args...)
+	}
+}
+
+// Ready se cierra cuando Start empieza a procesar mensajes.
+func (lf *LogFormatter) Ready() <-chan struct{} {
+	return lf.ready
+}
+
+// Stop detiene el loop de Start y espera a que termine de drenar lo pendiente,
+// hasta el deadline de ctx. Si Start no ha partido retorna de inmediato.
+func (lf *LogFormatter) Stop(ctx context.Context) error {
+	lf.stopOnce.Do(func() { close(lf.stop) })
+	if !lf.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-lf.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-on-bike/bike/interfaces"
 	"github.com/go-on-bike/bike/tester"
 	_ "github.com/tursodatabase/go-libsql"
 )
@@ -36,7 +37,9 @@ func TestLogFormatter_Integration(t *testing.T) {
 	connecter.IsConnected()
 	errChan <- connecter.Close()
 	connecter.IsConnected()
-	time.Sleep(time.Millisecond * 100)
+	if err := formatter.Flush(ctx); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
 
 	logs := stderr.String()
 	if len(logs) == 0 {
@@ -67,3 +70,35 @@ func TestLogFormatter_Integration(t *testing.T) {
 		}
 	}
 }
+
+func TestLogFormatter_Lifecycle(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	formatter, _ := NewLogFormatter(stderr, nil, true, 0)
+
+	var _ interfaces.Lifecycle = formatter
+
+	done := make(chan error, 1)
+	go func() { done <- formatter.Start(context.Background()) }()
+
+	select {
+	case <-formatter.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("formatter never became ready")
+	}
+
+	if err := formatter.Start(context.Background()); err == nil {
+		t.Fatal("expected error starting twice")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := formatter.Stop(ctx); err != nil {
+		t.Fatalf("unexpected error stopping: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error from Start: %v", err)
+	}
+	if err := formatter.Stop(ctx); err != nil {
+		t.Fatalf("expected Stop to be idempotent, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package logformatter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	defaultMaxLine   = 64 * 1024
+	defaultIdleFlush = 100 * time.Millisecond
+	defaultStats     = time.Minute
+	defaultDrain     = 5 * time.Second
+)
+
+type formatterOpts struct {
+	components    []string
+	maxLine       int
+	idleFlush     time.Duration
+	overflow      Overflow
+	blockTimeout  time.Duration
+	spillPath     string
+	spillSize     int64
+	statsInterval time.Duration
+	drainTimeout  time.Duration
+	dedupWindow   time.Duration
+	sinks         []sinkConfig
+	sampling      []SamplingRule
+	redactors     redactChain
+}
+
+type FormatterOption func(options *formatterOpts)
+
+// WithComponents limita los prefijos que se reconocen como componente a sigs,
+// por ejemplo sqlhandler.SigConn. Sin esta opción se reconocen los Sig* de los
+// paquetes de bike.
+// Panics si no se entrega ningún prefijo o alguno está vacío.
+func WithComponents(sigs ...string) FormatterOption {
+	return func(options *formatterOpts) {
+		if len(sigs) == 0 {
+			panic(fmt.Sprintf("%s: at least one component is required", SigLogFormatter))
+		}
+		for _, sig := range sigs {
+			if sig == "" {
+				panic(fmt.Sprintf("%s: component cannot be empty", SigLogFormatter))
+			}
+		}
+		options.components = append(options.components, sigs...)
+		// los más largos primero, para que "sqlhandler connector" gane sobre "sqlhandler"
+		sort.SliceStable(options.components, func(i, j int) bool {
+			return len(options.components[i]) > len(options.components[j])
+		})
+	}
+}
+
+// WithMaxLineLength establece el largo máximo de una línea. Una línea más larga
+// se registra en varios mensajes de a lo más n bytes.
+// Panics si n no es positivo.
+func WithMaxLineLength(n int) FormatterOption {
+	return func(options *formatterOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: max line length must be positive", SigLogFormatter))
+		}
+		options.maxLine = n
+	}
+}
+
+// WithIdleFlush establece cuánto espera una línea sin salto de línea final antes
+// de registrarse tal cual. Panics si d no es positivo.
+func WithIdleFlush(d time.Duration) FormatterOption {
+	return func(options *formatterOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: idle flush must be positive", SigLogFormatter))
+		}
+		options.idleFlush = d
+	}
+}
+
+// WithOverflowBlock hace que Write espere hasta timeout a que haya espacio en el
+// canal. Si no lo hay, la línea se descarta. Panics si timeout no es positivo.
+func WithOverflowBlock(timeout time.Duration) FormatterOption {
+	return func(options *formatterOpts) {
+		if timeout <= 0 {
+			panic(fmt.Sprintf("%s: block timeout must be positive", SigLogFormatter))
+		}
+		options.overflow = OverflowBlock
+		options.blockTimeout = timeout
+	}
+}
+
+// WithOverflowDropNewest descarta las líneas nuevas mientras el canal esté lleno.
+func WithOverflowDropNewest() FormatterOption {
+	return func(options *formatterOpts) {
+		options.overflow = OverflowDropNewest
+	}
+}
+
+// WithOverflowDropOldest descarta la línea más antigua del canal para hacer
+// espacio a la nueva.
+func WithOverflowDropOldest() FormatterOption {
+	return func(options *formatterOpts) {
+		options.overflow = OverflowDropOldest
+	}
+}
+
+// WithOverflowSpill guarda las líneas que no caben en el canal en un buffer
+// circular en path de a lo más size bytes. Si se llena, se descartan las más
+// antiguas. Panics si path está vacío o size no es positivo.
+func WithOverflowSpill(path string, size int64) FormatterOption {
+	return func(options *formatterOpts) {
+		if path == "" {
+			panic(fmt.Sprintf("%s: spill path cannot be empty", SigLogFormatter))
+		}
+		if size <= spoolHeader {
+			panic(fmt.Sprintf("%s: spill size must be greater than %d bytes", SigLogFormatter, spoolHeader))
+		}
+		options.overflow = OverflowSpill
+		options.spillPath = path
+		options.spillSize = size
+	}
+}
+
+// WithStatsInterval establece cada cuánto Start registra las líneas descartadas o
+// guardadas en disco. Panics si d no es positivo.
+func WithStatsInterval(d time.Duration) FormatterOption {
+	return func(options *formatterOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: stats interval must be positive", SigLogFormatter))
+		}
+		options.statsInterval = d
+	}
+}
+
+// WithDrainTimeout establece cuánto tiempo tiene Start para registrar lo pendiente
+// al cancelarse su contexto o al llamar a Stop. Panics si d no es positivo.
+func WithDrainTimeout(d time.Duration) FormatterOption {
+	return func(options *formatterOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: drain timeout must be positive", SigLogFormatter))
+		}
+		options.drainTimeout = d
+	}
+}
+
+// WithErrorDedup hace que un error idéntico a otro reportado hace menos de window
+// no se registre. Al vencer la ventana se registra una vez más con el atributo
+// repeated, la cantidad de veces que se omitió. Dos errores son idénticos si
+// tienen el mismo nivel, mensaje y ubicación. Panics si window no es positivo.
+func WithErrorDedup(window time.Duration) FormatterOption {
+	return func(options *formatterOpts) {
+		if window <= 0 {
+			panic(fmt.Sprintf("%s: dedup window must be positive", SigLogFormatter))
+		}
+		options.dedupWindow = window
+	}
+}
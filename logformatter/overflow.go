@@ -0,0 +1,182 @@
+package logformatter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// spoolBatch es cuántas líneas del disco procesa Start por vuelta.
+const spoolBatch = 256
+
+// Overflow es lo que hace Write cuando el canal de mensajes está lleno.
+type Overflow int
+
+const (
+	// OverflowStderr registra la línea directo en stderr, sin pasar por el logger,
+	// en JSON o en texto según textFormat. Es el comportamiento por defecto.
+	OverflowStderr Overflow = iota
+	// OverflowBlock espera a que haya espacio hasta un timeout, y luego descarta.
+	OverflowBlock
+	// OverflowDropNewest descarta la línea nueva.
+	OverflowDropNewest
+	// OverflowDropOldest descarta la línea más antigua del canal para hacer espacio.
+	OverflowDropOldest
+	// OverflowSpill guarda la línea en un buffer circular en disco hasta que Start
+	// pueda procesarla.
+	OverflowSpill
+)
+
+// OverflowStats cuenta las líneas afectadas por el canal lleno.
+type OverflowStats struct {
+	Dropped uint64
+	Spilled uint64
+}
+
+// Stats retorna los contadores acumulados desde que se creó el formatter.
+func (lf *LogFormatter) Stats() OverflowStats {
+	return OverflowStats{Dropped: lf.dropped.Load(), Spilled: lf.spilled.Load()}
+}
+
+// overflow aplica la política configurada a una línea que no cupo en el canal.
+func (lf *LogFormatter) overflow(msg []byte) error {
+	if enqueue(lf, lf.msgChan, msg) {
+		return nil
+	}
+	if lf.options.overflow == OverflowSpill {
+		return lf.spill(msg)
+	}
+	lf.logFallback(context.Background(), lf.parse(string(msg), slog.LevelInfo, true))
+	return nil
+}
+
+// enqueue aplica a v las políticas que solo dependen del canal ch, que está
+// lleno. Retorna false con OverflowStderr y OverflowSpill, que quedan a cargo de
+// quien llama.
+func enqueue[T any](lf *LogFormatter, ch chan T, v T) bool {
+	switch lf.options.overflow {
+	case OverflowBlock:
+		timer := time.NewTimer(lf.options.blockTimeout)
+		defer timer.Stop()
+		select {
+		case ch <- v:
+		case <-timer.C:
+			lf.dropped.Add(1)
+		}
+		return true
+	case OverflowDropNewest:
+		lf.dropped.Add(1)
+		return true
+	case OverflowDropOldest:
+		select {
+		case <-ch:
+			lf.dropped.Add(1)
+		default:
+		}
+		select {
+		case ch <- v:
+		default:
+			lf.dropped.Add(1)
+		}
+		return true
+	}
+	return false
+}
+
+// logFallback registra e en stderr con el logger de respaldo.
+func (lf *LogFormatter) logFallback(ctx context.Context, e entry) {
+	var args []any
+	if e.component != "" {
+		args = append(args, "component", e.component)
+	}
+	lf.fallback.Log(ctx, e.level, e.msg, args...)
+}
+
+// spill guarda msg en disco y avisa a Start que hay líneas pendientes.
+func (lf *LogFormatter) spill(msg []byte) error {
+	dropped, err := lf.spool.push(msg)
+	lf.dropped.Add(uint64(dropped))
+	if err != nil {
+		lf.dropped.Add(1)
+		return err
+	}
+	lf.spilled.Add(1)
+
+	select {
+	case lf.spillReady <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// drainSpool registra las líneas guardadas en disco. Antes procesa el canal,
+// porque sus líneas son más antiguas que las del disco. Si el disco no se puede
+// leer, lo pendiente se pierde y las líneas nuevas vuelven al canal.
+func (lf *LogFormatter) drainSpool() {
+	for drained := false; !drained; {
+		select {
+		case msg := <-lf.msgChan:
+			lf.handleMsg(msg)
+		default:
+			drained = true
+		}
+	}
+
+	for range spoolBatch {
+		rec, ok, err := lf.spool.pop()
+		if err != nil {
+			lf.dropped.Add(1)
+			lf.logger.Warn("log spill file discarded", "component", SigLogFormatter, "error", err.Error())
+			return
+		}
+		if !ok {
+			return
+		}
+		lf.handleMsg(rec)
+	}
+
+	// quedan líneas: seguimos en la próxima vuelta para no acaparar el loop
+	select {
+	case lf.spillReady <- struct{}{}:
+	default:
+	}
+}
+
+// reportStats registra cuántas líneas se descartaron o guardaron en disco, y
+// cuántos registros perdió cada sink, desde el último reporte. No registra nada
+// si no hubo.
+func (lf *LogFormatter) reportStats() {
+	lf.reportOverflow()
+	for _, s := range lf.sinks {
+		stats := s.stats()
+		dropped := stats.Dropped - s.reported.Dropped
+		failed := stats.Failed - s.reported.Failed
+		s.reported = stats
+
+		if dropped == 0 && failed == 0 {
+			continue
+		}
+		lf.logger.Warn("log sink lost records",
+			"component", SigLogFormatter,
+			"sink", s.name,
+			"dropped", dropped,
+			"failed", failed,
+		)
+	}
+}
+
+func (lf *LogFormatter) reportOverflow() {
+	stats := lf.Stats()
+	dropped := stats.Dropped - lf.reported.Dropped
+	spilled := stats.Spilled - lf.reported.Spilled
+	lf.reported = stats
+
+	if dropped == 0 && spilled == 0 {
+		return
+	}
+	lf.logger.Warn("log buffer overflowed",
+		"component", SigLogFormatter,
+		"dropped", dropped,
+		"spilled", spilled,
+	)
+}
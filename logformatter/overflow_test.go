@@ -0,0 +1,241 @@
+package logformatter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOverflowPolicies(t *testing.T) {
+	t.Run("drop newest", func(t *testing.T) {
+		stderr := &bytes.Buffer{}
+		lf, _ := NewLogFormatter(stderr, nil, false, 1, WithOverflowDropNewest())
+
+		lf.Write([]byte("first\nsecond\nthird\n"))
+		if got := drain(lf); strings.Join(got, "|") != "first" {
+			t.Fatalf("expected only the first line, got %q", got)
+		}
+		if stats := lf.Stats(); stats.Dropped != 2 {
+			t.Fatalf("expected 2 dropped lines, got %+v", stats)
+		}
+		if stderr.Len() != 0 {
+			t.Fatalf("expected nothing in stderr, got %q", stderr.String())
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 2, WithOverflowDropOldest())
+
+		lf.Write([]byte("first\nsecond\nthird\nfourth\n"))
+		if got := drain(lf); strings.Join(got, "|") != "third|fourth" {
+			t.Fatalf("expected the newest lines, got %q", got)
+		}
+		if stats := lf.Stats(); stats.Dropped != 2 {
+			t.Fatalf("expected 2 dropped lines, got %+v", stats)
+		}
+	})
+
+	t.Run("block waits for space", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 1, WithOverflowBlock(time.Second))
+		lf.Write([]byte("first\n"))
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			<-lf.msgChan
+		}()
+
+		start := time.Now()
+		lf.Write([]byte("second\n"))
+		if time.Since(start) < 20*time.Millisecond {
+			t.Fatal("expected Write to block until there was space")
+		}
+		if got := drain(lf); strings.Join(got, "|") != "second" {
+			t.Fatalf("expected second line, got %q", got)
+		}
+	})
+
+	t.Run("blocked write does not hold the partial line", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 1, WithOverflowBlock(time.Second))
+		lf.Write([]byte("first\n"))
+
+		blocked := make(chan struct{})
+		go func() {
+			defer close(blocked)
+			lf.Write([]byte("second\n"))
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		start := time.Now()
+		lf.Write([]byte("third"))
+		if time.Since(start) > 500*time.Millisecond {
+			t.Fatal("expected the partial line not to wait for the blocked Write")
+		}
+
+		<-lf.msgChan
+		<-blocked
+	})
+
+	t.Run("block drops after timeout", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 1, WithOverflowBlock(10*time.Millisecond))
+
+		lf.Write([]byte("first\nsecond\n"))
+		if stats := lf.Stats(); stats.Dropped != 1 {
+			t.Fatalf("expected 1 dropped line, got %+v", stats)
+		}
+	})
+
+	t.Run("spill keeps order", func(t *testing.T) {
+		lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 2,
+			WithOverflowSpill(filepath.Join(t.TempDir(), "spill"), 1024))
+
+		for i := range 6 {
+			fmt.Fprintf(lf, "line %d\n", i)
+		}
+		if stats := lf.Stats(); stats.Spilled != 4 || stats.Dropped != 0 {
+			t.Fatalf("expected 4 spilled lines, got %+v", stats)
+		}
+
+		var got []string
+		for {
+			msgs := drain(lf)
+			rec, ok, err := lf.spool.pop()
+			if err != nil {
+				t.Fatalf("unexpected error popping: %v", err)
+			}
+			got = append(got, msgs...)
+			if !ok {
+				break
+			}
+			got = append(got, string(rec))
+		}
+		if strings.Join(got, "|") != "line 0|line 1|line 2|line 3|line 4|line 5" {
+			t.Fatalf("expected lines in order, got %q", got)
+		}
+	})
+}
+
+func TestSpool(t *testing.T) {
+	s := newSpool(filepath.Join(t.TempDir(), "spill"), 20)
+	defer s.close()
+
+	// cada registro ocupa 4 bytes de largo más sus datos
+	for _, rec := range []string{"aaaa", "bbbb"} {
+		if dropped, err := s.push([]byte(rec)); err != nil || dropped != 0 {
+			t.Fatalf("unexpected push result %d (%v)", dropped, err)
+		}
+	}
+
+	// no cabe: se descarta el más antiguo y el nuevo da la vuelta al archivo
+	dropped, err := s.push([]byte("cccccc"))
+	if err != nil || dropped != 1 {
+		t.Fatalf("expected 1 dropped record, got %d (%v)", dropped, err)
+	}
+
+	var got []string
+	for {
+		rec, ok, err := s.pop()
+		if err != nil {
+			t.Fatalf("unexpected error popping: %v", err)
+		}
+		if !ok {
+			break
+		}
+		got = append(got, string(rec))
+	}
+	if strings.Join(got, "|") != "bbbb|cccccc" {
+		t.Fatalf("expected remaining records, got %q", got)
+	}
+
+	if _, err := s.push(make([]byte, 20)); err == nil {
+		t.Fatal("expected error for record larger than the file")
+	}
+}
+
+func TestSpillCorrupt(t *testing.T) {
+	out := &lockedBuffer{}
+	logger := slog.New(slog.NewJSONHandler(out, nil))
+	lf, _ := NewLogFormatter(out, logger, false, 1, WithOverflowSpill(filepath.Join(t.TempDir(), "spill"), 4096))
+
+	for i := range 3 {
+		fmt.Fprintf(lf, "cache: line %d\n", i)
+	}
+	// un largo imposible en el primer registro del disco
+	if _, err := lf.spool.f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, 0); err != nil {
+		t.Fatalf("unexpected error corrupting spool: %v", err)
+	}
+
+	lf.drainSpool()
+	lf.drainSpool()
+	if !lf.spool.empty() {
+		t.Fatal("expected corrupt spool to be discarded")
+	}
+
+	fmt.Fprintf(lf, "cache: after\n")
+	if got := drain(lf); strings.Join(got, "|") != "cache: after" {
+		t.Fatalf("expected new line back in the channel, got %q", got)
+	}
+
+	var msgs []string
+	for _, line := range jsonLines(t, out) {
+		msgs = append(msgs, line["msg"].(string))
+	}
+	if strings.Join(msgs, "|") != "line 0|log spill file discarded" {
+		t.Fatalf("expected one discard warning, got %q", msgs)
+	}
+}
+
+func TestSpillDrainAndStats(t *testing.T) {
+	out := &lockedBuffer{}
+	lf, _ := NewLogFormatter(out, nil, false, 1,
+		WithOverflowSpill(filepath.Join(t.TempDir(), "spill"), 4096),
+		WithStatsInterval(10*time.Millisecond),
+	)
+
+	for i := range 5 {
+		fmt.Fprintf(lf, "cache: line %d\n", i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go lf.Start(ctx)
+
+	var msgs []string
+	var report map[string]any
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		msgs, report = msgs[:0], nil
+		for _, raw := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var line map[string]any
+			if err := json.Unmarshal([]byte(raw), &line); err != nil {
+				continue
+			}
+			if line["msg"] == "log buffer overflowed" {
+				report = line
+				continue
+			}
+			msgs = append(msgs, line["msg"].(string))
+		}
+		if len(msgs) == 5 && report != nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if strings.Join(msgs, "|") != "line 0|line 1|line 2|line 3|line 4" {
+		t.Fatalf("expected every line in order, got %q", msgs)
+	}
+	if report == nil || report["spilled"] != float64(4) || report["component"] != SigLogFormatter {
+		t.Fatalf("unexpected stats report %v", report)
+	}
+	for _, raw := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !json.Valid([]byte(raw)) {
+			t.Fatalf("expected only JSON lines, got %q", raw)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package logformatter
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// knownComponents son los Sig* de los paquetes de bike, los más largos primero
+// para que "sqlhandler connector" gane sobre "sqlhandler". Se repiten aquí para
+// no importar los drivers desde el formateador.
+var knownComponents = []string{
+	"sqlhandler instrumentation",
+	"sqlhandler repository",
+	"sqlhandler connector",
+	"sqlhandler migrator",
+	"sqlhandler outbox",
+	"sqlhandler tenant",
+	"sqlhandler query",
+	"sqlhandler scan",
+	"sqlhandler tx",
+	"logformatter",
+	"httpserver",
+	"sqlhandler",
+	"bike app",
+	"jobqueue",
+	"locker",
+	"config",
+	"cache",
+}
+
+// levelWords son las palabras que delatan el nivel de una línea sin level=.
+var levelWords = map[string]slog.Level{
+	"error":      slog.LevelError,
+	"errors":     slog.LevelError,
+	"fail":       slog.LevelError,
+	"failed":     slog.LevelError,
+	"failure":    slog.LevelError,
+	"fatal":      slog.LevelError,
+	"panic":      slog.LevelError,
+	"cannot":     slog.LevelError,
+	"warn":       slog.LevelWarn,
+	"warning":    slog.LevelWarn,
+	"retrying":   slog.LevelWarn,
+	"deprecated": slog.LevelWarn,
+	"debug":      slog.LevelDebug,
+}
+
+var levelNames = map[string]slog.Level{
+	"debug":   slog.LevelDebug,
+	"info":    slog.LevelInfo,
+	"warn":    slog.LevelWarn,
+	"warning": slog.LevelWarn,
+	"error":   slog.LevelError,
+}
+
+// entry es una línea ya interpretada.
+type entry struct {
+	component string
+	level     slog.Level
+	msg       string
+}
+
+// parse separa el componente del mensaje. Si detect es true también deduce el
+// nivel: primero de un token level=, y si no hay, de las palabras de la línea
+// completa.
+func (lf *LogFormatter) parse(line string, level slog.Level, detect bool) entry {
+	e := entry{level: level, msg: line}
+	e.component, e.msg = lf.component(line)
+
+	if !detect {
+		return e
+	}
+	if lvl, msg, ok := explicitLevel(e.msg); ok {
+		e.level, e.msg = lvl, msg
+		return e
+	}
+	e.level = guessLevel(line)
+	return e
+}
+
+func (lf *LogFormatter) component(line string) (string, string) {
+	components := knownComponents
+	if len(lf.options.components) > 0 {
+		components = lf.options.components
+	}
+	for _, sig := range components {
+		if rest, ok := strings.CutPrefix(line, sig+": "); ok {
+			return sig, rest
+		}
+	}
+	return "", line
+}
+
+// explicitLevel busca un token level=x y lo quita del mensaje.
+func explicitLevel(msg string) (slog.Level, string, bool) {
+	fields := strings.Fields(msg)
+	for i, f := range fields {
+		name, ok := strings.CutPrefix(strings.ToLower(f), "level=")
+		if !ok {
+			continue
+		}
+		lvl, ok := levelNames[name]
+		if !ok {
+			continue
+		}
+		rest := append(fields[:i:i], fields[i+1:]...)
+		return lvl, strings.Join(rest, " "), true
+	}
+	return slog.LevelInfo, msg, false
+}
+
+// guessLevel retorna el nivel más alto entre las palabras del mensaje.
+func guessLevel(msg string) slog.Level {
+	level := slog.LevelInfo
+	found := false
+	for _, word := range strings.FieldsFunc(strings.ToLower(msg), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	}) {
+		lvl, ok := levelWords[word]
+		if !ok {
+			continue
+		}
+		if !found || lvl > level {
+			level, found = lvl, true
+		}
+	}
+	return level
+}
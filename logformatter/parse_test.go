@@ -0,0 +1,194 @@
+package logformatter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 0)
+
+	cases := []struct {
+		line string
+		want entry
+	}{
+		{"sqlhandler connector: ping to db connection", entry{"sqlhandler connector", slog.LevelInfo, "ping to db connection"}},
+		{"sqlhandler: failed to migrate: boom", entry{"sqlhandler", slog.LevelError, "failed to migrate: boom"}},
+		{"locker: failed to refresh key, retrying: timeout", entry{"locker", slog.LevelError, "failed to refresh key, retrying: timeout"}},
+		{"jobqueue: retrying job 3", entry{"jobqueue", slog.LevelWarn, "retrying job 3"}},
+		{"cache: level=debug hit for key", entry{"cache", slog.LevelDebug, "hit for key"}},
+		{"cache: level=warn failed lookup", entry{"cache", slog.LevelWarn, "failed lookup"}},
+		{"cache: debug dump of state", entry{"cache", slog.LevelDebug, "debug dump of state"}},
+		{"plain message without prefix", entry{"", slog.LevelInfo, "plain message without prefix"}},
+		{"Not A Sig: message", entry{"", slog.LevelInfo, "Not A Sig: message"}},
+		{"errorless message", entry{"", slog.LevelInfo, "errorless message"}},
+		{"warning: disk full", entry{"", slog.LevelWarn, "warning: disk full"}},
+		{"connection refused: dial tcp", entry{"", slog.LevelInfo, "connection refused: dial tcp"}},
+	}
+
+	for _, tc := range cases {
+		if got := lf.parse(tc.line, slog.LevelInfo, true); got != tc.want {
+			t.Errorf("parse(%q) = %+v, want %+v", tc.line, got, tc.want)
+		}
+	}
+
+	if got := lf.parse("sqlhandler connector: closing", slog.LevelError, false); got.level != slog.LevelError || got.component != "sqlhandler connector" {
+		t.Errorf("expected error level to be kept, got %+v", got)
+	}
+}
+
+// TestKnownComponents lee las constantes Sig* de todo el módulo, así un paquete
+// nuevo o un prefijo renombrado no pasan sin actualizar knownComponents.
+func TestKnownComponents(t *testing.T) {
+	var sigs []string
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".") && path != "..") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if !strings.HasPrefix(name.Name, "Sig") || i >= len(vs.Values) {
+						continue
+					}
+					lit, ok := vs.Values[i].(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					sig, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						return err
+					}
+					sigs = append(sigs, sig)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read module sources: %v", err)
+	}
+
+	if !slices.Contains(sigs, SigLogFormatter) {
+		t.Fatalf("expected to find the Sig constants of the module, got %v", sigs)
+	}
+	for _, sig := range sigs {
+		if !slices.Contains(knownComponents, sig) {
+			t.Errorf("expected %q to be a known component", sig)
+		}
+	}
+	for _, known := range knownComponents {
+		if !slices.Contains(sigs, known) {
+			t.Errorf("known component %q has no Sig constant", known)
+		}
+	}
+	if !slices.IsSortedFunc(knownComponents, func(a, b string) int { return len(b) - len(a) }) {
+		t.Errorf("expected known components sorted longest first, got %v", knownComponents)
+	}
+}
+
+func TestParseWithComponents(t *testing.T) {
+	lf, _ := NewLogFormatter(&bytes.Buffer{}, nil, false, 0, WithComponents("sqlhandler", "sqlhandler connector"))
+
+	cases := map[string]entry{
+		"sqlhandler connector: ping":  {"sqlhandler connector", slog.LevelInfo, "ping"},
+		"sqlhandler: started":         {"sqlhandler", slog.LevelInfo, "started"},
+		"dispatch failed: no network": {"", slog.LevelError, "dispatch failed: no network"},
+	}
+	for line, want := range cases {
+		if got := lf.parse(line, slog.LevelInfo, true); got != want {
+			t.Errorf("parse(%q) = %+v, want %+v", line, got, want)
+		}
+	}
+}
+
+func TestStructuredOutput(t *testing.T) {
+	out := &lockedBuffer{}
+	logger := slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	lf, errChan := NewLogFormatter(out, logger, false, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go lf.Start(ctx)
+	<-lf.Ready()
+
+	lf.Write([]byte("sqlhandler migrator: setting new db\n"))
+	errChan <- errorString("sqlhandler connector: cannot create new connection")
+
+	var lines []map[string]any
+	deadline := time.Now().Add(time.Second)
+	for len(lines) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		lines = lines[:0]
+		for _, raw := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var line map[string]any
+			if json.Unmarshal([]byte(raw), &line) == nil {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", out.String())
+	}
+
+	byComponent := map[string]map[string]any{}
+	for _, line := range lines {
+		byComponent[line["component"].(string)] = line
+	}
+	if l := byComponent["sqlhandler migrator"]; l == nil || l["level"] != "INFO" || l["msg"] != "setting new db" {
+		t.Errorf("unexpected migrator line %v", l)
+	}
+	if l := byComponent["sqlhandler connector"]; l == nil || l["level"] != "ERROR" || l["msg"] != "cannot create new connection" {
+		t.Errorf("unexpected connector line %v", l)
+	}
+}
+
+type errorString string
+
+func (e errorString) Error() string { return string(e) }
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,261 @@
+package logformatter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/go-on-bike/bike/validator"
+)
+
+// Redacted reemplaza los datos sensibles que encuentran los redactores.
+const Redacted = "[REDACTED]"
+
+// Redactor recibe un texto y lo retorna sin los datos sensibles que reconoce.
+type Redactor func(s string) string
+
+var (
+	urlUserRX   = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]*):[^/\s@]*@`)
+	urlSecretRX = regexp.MustCompile(`(?i)([?&;](?:auth_?token|access_?token|token|password|passwd|pwd|secret|api_?key)=)[^&;\s"']+`)
+	bearerRX    = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	emailCandRX = regexp.MustCompile("[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9-]+(?:\\.[a-zA-Z0-9-]+)+")
+	rutCandRX   = regexp.MustCompile(`\b[0-9]{1,2}(?:\.?[0-9]{3}){2}-[0-9kK]\b`)
+	cardCandRX  = regexp.MustCompile(`\b[0-9](?:[ -]?[0-9]){12,18}\b`)
+)
+
+// RedactURLCredentials oculta la contraseña de las URL con user:pass@, como los
+// DSN, y los parámetros que suelen llevar secretos, como authToken o password.
+func RedactURLCredentials(s string) string {
+	s = urlUserRX.ReplaceAllString(s, "${1}:"+Redacted+"@")
+	return urlSecretRX.ReplaceAllString(s, "${1}"+Redacted)
+}
+
+// RedactBearerTokens oculta las credenciales de los headers Authorization
+// Bearer y Basic.
+func RedactBearerTokens(s string) string {
+	return bearerRX.ReplaceAllString(s, "${1} "+Redacted)
+}
+
+// RedactEmails oculta los emails que acepta validator.StringEmail. Exige un
+// dominio con punto, para no confundir cosas como "v1.2@latest" en un texto libre.
+func RedactEmails(s string) string {
+	return emailCandRX.ReplaceAllStringFunc(s, func(m string) string {
+		trimmed := strings.TrimRight(m, ".-")
+		if !validator.StringEmail(trimmed) {
+			return m
+		}
+		return Redacted + m[len(trimmed):]
+	})
+}
+
+// RedactRUTs oculta los RUT chilenos que acepta validator.StringRut, con o sin
+// puntos.
+func RedactRUTs(s string) string {
+	return rutCandRX.ReplaceAllStringFunc(s, func(m string) string {
+		if !validator.StringRut(strings.ReplaceAll(m, ".", "")) {
+			return m
+		}
+		return Redacted
+	})
+}
+
+// RedactCardNumbers oculta los números de 13 a 19 dígitos, con o sin espacios o
+// guiones, que pasan el dígito verificador de Luhn.
+func RedactCardNumbers(s string) string {
+	return cardCandRX.ReplaceAllStringFunc(s, func(m string) string {
+		if !luhn(m) {
+			return m
+		}
+		return Redacted
+	})
+}
+
+// RedactRegex retorna un Redactor que reemplaza lo que calce con pattern por
+// replacement, que puede usar $1 como en regexp.ReplaceAllString.
+// Panics si pattern no es una expresión regular válida.
+func RedactRegex(pattern, replacement string) Redactor {
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("%s: invalid redaction pattern: %v", SigLogFormatter, err))
+	}
+	return func(s string) string {
+		return rx.ReplaceAllString(s, replacement)
+	}
+}
+
+// DefaultRedactors retorna los redactores incluidos en el paquete.
+func DefaultRedactors() []Redactor {
+	return []Redactor{
+		RedactURLCredentials,
+		RedactBearerTokens,
+		RedactEmails,
+		RedactRUTs,
+		RedactCardNumbers,
+	}
+}
+
+// WithRedaction aplica redactors, en orden, al mensaje y a los valores de los
+// atributos de cada registro, y a las líneas que van a stderr cuando el canal
+// está lleno. Sin redactores usa DefaultRedactors. En los loggers que crea
+// NewLogFormatter la redacción es un RedactHandler dentro del ContextHandler,
+// así que también cubre los campos del contexto. Con un logger propio solo se
+// redactan el mensaje y los atributos antes de entregárselos; para cubrir sus
+// campos del contexto debe usar NewRedactHandler. Las líneas guardadas en disco
+// por WithOverflowSpill no se redactan hasta que se registran.
+func WithRedaction(redactors ...Redactor) FormatterOption {
+	return func(options *formatterOpts) {
+		if len(redactors) == 0 {
+			redactors = DefaultRedactors()
+		}
+		for _, r := range redactors {
+			if r == nil {
+				panic(fmt.Sprintf("%s: redactor cannot be nil", SigLogFormatter))
+			}
+		}
+		options.redactors = append(options.redactors, redactors...)
+	}
+}
+
+// RedactHandler envuelve un slog.Handler y aplica los redactores al mensaje y a
+// los valores de los atributos de cada registro, también a los que recibe con
+// WithAttrs. Para que cubra los campos del contexto debe ir dentro del
+// ContextHandler: NewContextHandler(NewRedactHandler(h)).
+type RedactHandler struct {
+	inner     slog.Handler
+	redactors redactChain
+}
+
+// NewRedactHandler retorna inner envuelto en un RedactHandler. Sin redactores
+// usa DefaultRedactors. Panics si inner o alguno de los redactores es nil.
+func NewRedactHandler(inner slog.Handler, redactors ...Redactor) *RedactHandler {
+	if inner == nil {
+		panic(fmt.Sprintf("%s: handler cannot be nil", SigLogFormatter))
+	}
+	if len(redactors) == 0 {
+		redactors = DefaultRedactors()
+	}
+	for _, r := range redactors {
+		if r == nil {
+			panic(fmt.Sprintf("%s: redactor cannot be nil", SigLogFormatter))
+		}
+	}
+	return &RedactHandler{inner: inner, redactors: redactors}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.redactors.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redactors.redactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactors.redactAttr(a)
+	}
+	return &RedactHandler{inner: h.inner.WithAttrs(redacted), redactors: h.redactors}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{inner: h.inner.WithGroup(name), redactors: h.redactors}
+}
+
+// redactChain aplica los redactores en orden.
+type redactChain []Redactor
+
+func (rc redactChain) redact(s string) string {
+	for _, r := range rc {
+		s = r(s)
+	}
+	return s
+}
+
+// redactArgs retorna una copia de args, pares clave-valor o slog.Attr, con los
+// valores de texto y los errores redactados. Las claves y los demás valores,
+// como números o duraciones, no se tocan.
+func (rc redactChain) redactArgs(args []any) []any {
+	out := make([]any, len(args))
+	for i := 0; i < len(args); i++ {
+		if a, ok := args[i].(slog.Attr); ok {
+			out[i] = rc.redactAttr(a)
+			continue
+		}
+		out[i] = args[i]
+		if i+1 < len(args) {
+			i++
+			out[i] = rc.redactAny(args[i])
+		}
+	}
+	return out
+}
+
+func (rc redactChain) redactAttr(a slog.Attr) slog.Attr {
+	return slog.Attr{Key: a.Key, Value: rc.redactValue(a.Value)}
+}
+
+func (rc redactChain) redactValue(v slog.Value) slog.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(rc.redact(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			redacted[i] = rc.redactAttr(a)
+		}
+		return slog.GroupValue(redacted...)
+	case slog.KindAny:
+		return slog.AnyValue(rc.redactAny(v.Any()))
+	}
+	return v
+}
+
+func (rc redactChain) redactAny(v any) any {
+	switch x := v.(type) {
+	case string:
+		return rc.redact(x)
+	case []string:
+		redacted := make([]string, len(x))
+		for i, s := range x {
+			redacted[i] = rc.redact(s)
+		}
+		return redacted
+	case error:
+		return rc.redact(x.Error())
+	case slog.Value:
+		return rc.redactValue(x)
+	}
+	return v
+}
+
+// luhn valida el dígito verificador de un número de tarjeta, ignorando espacios
+// y guiones.
+func luhn(number string) bool {
+	sum, n := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
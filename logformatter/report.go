@@ -0,0 +1,203 @@
+package logformatter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// repeat es un error ya registrado dentro de la ventana de dedup.
+type repeat struct {
+	ctx   context.Context
+	entry entry
+	attrs []any
+	first time.Time
+	count int
+}
+
+// reported es un error entregado a Report, que viaja por el canal de errores
+// hasta Start junto a lo necesario para registrarlo.
+type reported struct {
+	ctx    context.Context
+	level  slog.Level
+	err    error
+	source string
+	attrs  []any
+}
+
+func (r *reported) Error() string { return r.err.Error() }
+func (r *reported) Unwrap() error { return r.err }
+
+// ReportError registra err con nivel Error. Es Report con slog.LevelError.
+func (lf *LogFormatter) ReportError(err error, attrs ...any) {
+	lf.enqueueReport(context.Background(), slog.LevelError, err, caller(1), attrs)
+}
+
+// ReportErrorContext es ReportError con el contexto de la operación, cuyos
+// campos de log se agregan al registro.
+func (lf *LogFormatter) ReportErrorContext(ctx context.Context, err error, attrs ...any) {
+	lf.enqueueReport(ctx, slog.LevelError, err, caller(1), attrs)
+}
+
+// Report registra err con el nivel dado. El mensaje pasa por el mismo análisis
+// que las líneas de stderr para separar el componente, y se agregan como
+// atributos la ubicación del llamador (source), las causas de err si viene de
+// errors.Join o de %w (causes) y attrs, en pares clave-valor como en slog.
+// Un err nil no registra nada.
+//
+// El error se encola como las líneas de stderr, así que lo registra Start y le
+// aplican el muestreo, el dedup y la política de overflow. Con OverflowSpill un
+// error que no cabe en el canal se registra directo en stderr, porque el disco
+// solo guarda líneas.
+func (lf *LogFormatter) Report(level slog.Level, err error, attrs ...any) {
+	lf.enqueueReport(context.Background(), level, err, caller(1), attrs)
+}
+
+// ReportContext es Report con el contexto de la operación, cuyos campos de log
+// se agregan al registro.
+func (lf *LogFormatter) ReportContext(ctx context.Context, level slog.Level, err error, attrs ...any) {
+	lf.enqueueReport(ctx, level, err, caller(1), attrs)
+}
+
+func (lf *LogFormatter) enqueueReport(ctx context.Context, level slog.Level, err error, source string, attrs []any) {
+	if err == nil {
+		return
+	}
+	r := &reported{ctx: ctx, level: level, err: err, source: source, attrs: attrs}
+
+	// Start ya terminó: nadie leerá el canal
+	if lf.closed.Load() {
+		lf.report(r)
+		return
+	}
+	select {
+	case lf.errChan <- r:
+		return
+	default:
+	}
+	if !enqueue(lf, lf.errChan, error(r)) {
+		lf.logFallback(ctx, lf.parse(strings.ReplaceAll(err.Error(), "\n", "; "), level, false))
+	}
+}
+
+func (lf *LogFormatter) report(r *reported) {
+	if r.err == nil {
+		return
+	}
+
+	e := lf.parse(strings.ReplaceAll(r.err.Error(), "\n", "; "), r.level, false)
+	now := time.Now()
+	if len(lf.options.sampling) > 0 && !lf.sample(e, now) {
+		return
+	}
+
+	var args []any
+	if r.source != "" {
+		args = append(args, "source", r.source)
+	}
+	if causes := causesOf(r.err); len(causes) > 0 {
+		args = append(args, "causes", causes)
+	}
+	args = append(args, r.attrs...)
+
+	if lf.options.dedupWindow > 0 && !lf.dedup(r.ctx, e, r.source, args, now) {
+		return
+	}
+	lf.logContext(r.ctx, e, args...)
+}
+
+// dedup retorna false si el mismo error ya se registró dentro de la ventana, y
+// en ese caso solo cuenta la repetición. Al vencer la ventana se registra un
+// resumen con la cantidad de repeticiones antes del error nuevo.
+func (lf *LogFormatter) dedup(ctx context.Context, e entry, source string, args []any, now time.Time) bool {
+	key := fmt.Sprintf("%d|%s|%s|%s", e.level, source, e.component, e.msg)
+
+	lf.repeatMu.Lock()
+	defer lf.repeatMu.Unlock()
+
+	if r, ok := lf.repeats[key]; ok {
+		if now.Sub(r.first) < lf.options.dedupWindow {
+			r.count++
+			return false
+		}
+		lf.logRepeat(r)
+	}
+	lf.repeats[key] = &repeat{ctx: ctx, entry: e, attrs: args, first: now}
+	return true
+}
+
+// flushRepeats registra el resumen de los errores cuya ventana venció en now.
+// Si force es true los registra todos, sin importar la ventana.
+func (lf *LogFormatter) flushRepeats(now time.Time, force bool) {
+	lf.repeatMu.Lock()
+	defer lf.repeatMu.Unlock()
+
+	var keys []string
+	for key, r := range lf.repeats {
+		if force || now.Sub(r.first) >= lf.options.dedupWindow {
+			keys = append(keys, key)
+		}
+	}
+	// en el orden en que aparecieron, para que el log sea estable
+	sort.Slice(keys, func(i, j int) bool {
+		return lf.repeats[keys[i]].first.Before(lf.repeats[keys[j]].first)
+	})
+	for _, key := range keys {
+		lf.logRepeat(lf.repeats[key])
+		delete(lf.repeats, key)
+	}
+}
+
+func (lf *LogFormatter) logRepeat(r *repeat) {
+	if r.count == 0 {
+		return
+	}
+	lf.logContext(r.ctx, r.entry, append(r.attrs[:len(r.attrs):len(r.attrs)], "repeated", r.count)...)
+}
+
+// causesOf retorna los mensajes de las causas finales de err, recorriendo
+// errors.Join y los errores envueltos con %w. Retorna nil si err no envuelve a
+// nadie, porque su mensaje ya lo dice todo.
+func causesOf(err error) []string {
+	var causes []string
+	var walk func(err error)
+	walk = func(err error) {
+		switch x := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range x.Unwrap() {
+				if inner != nil {
+					walk(inner)
+				}
+			}
+		case interface{ Unwrap() error }:
+			if inner := x.Unwrap(); inner != nil {
+				walk(inner)
+				return
+			}
+			causes = append(causes, err.Error())
+		default:
+			causes = append(causes, err.Error())
+		}
+	}
+	walk(err)
+
+	if len(causes) == 1 && causes[0] == err.Error() {
+		return nil
+	}
+	return causes
+}
+
+// caller retorna "paquete/archivo.go:línea" de la función skip niveles arriba
+// de la que llama a caller.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(file)), filepath.Base(file), line)
+}
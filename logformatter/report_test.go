@@ -0,0 +1,215 @@
+package logformatter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// jsonLines retorna las líneas JSON escritas en out.
+func jsonLines(t *testing.T, out *lockedBuffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("expected JSON line, got %q", raw)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func newReportFormatter(opts ...FormatterOption) (*LogFormatter, *lockedBuffer) {
+	out := &lockedBuffer{}
+	logger := slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	lf, _ := NewLogFormatter(out, logger, false, 0, opts...)
+	return lf, out
+}
+
+// processErrors registra lo encolado por Report sin que Start esté corriendo.
+func processErrors(lf *LogFormatter) {
+	for {
+		select {
+		case err := <-lf.errChan:
+			lf.handleErr(err)
+		default:
+			return
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	t.Run("structured fields", func(t *testing.T) {
+		lf, out := newReportFormatter()
+
+		base := errorString("sqlhandler connector: cannot create new connection")
+		err := fmt.Errorf("sqlhandler: failed to start: %w", base)
+		lf.ReportError(err, "attempt", 3)
+		lf.ReportError(nil)
+		processErrors(lf)
+
+		lines := jsonLines(t, out)
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 log line, got %q", out.String())
+		}
+		l := lines[0]
+		if l["level"] != "ERROR" || l["component"] != "sqlhandler" || l["msg"] != "failed to start: "+string(base) {
+			t.Errorf("unexpected line %v", l)
+		}
+		if src, _ := l["source"].(string); !strings.HasPrefix(src, "logformatter/report_test.go:") {
+			t.Errorf("expected caller location, got %v", l["source"])
+		}
+		if causes, _ := l["causes"].([]any); len(causes) != 1 || causes[0] != string(base) {
+			t.Errorf("expected wrapped cause, got %v", l["causes"])
+		}
+		if l["attempt"] != float64(3) {
+			t.Errorf("expected extra attribute, got %v", l["attempt"])
+		}
+	})
+
+	t.Run("joined errors and severity", func(t *testing.T) {
+		lf, out := newReportFormatter()
+
+		err := errors.Join(
+			errorString("cache: eviction failed"),
+			fmt.Errorf("wrapped: %w", errorString("outbox: relay stopped")),
+			nil,
+		)
+		lf.Report(slog.LevelWarn, err)
+		processErrors(lf)
+
+		lines := jsonLines(t, out)
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 log line, got %q", out.String())
+		}
+		l := lines[0]
+		if l["level"] != "WARN" || l["msg"] != "eviction failed; wrapped: outbox: relay stopped" {
+			t.Errorf("unexpected line %v", l)
+		}
+		causes, _ := l["causes"].([]any)
+		if len(causes) != 2 || causes[0] != "cache: eviction failed" || causes[1] != "outbox: relay stopped" {
+			t.Errorf("expected both causes, got %v", l["causes"])
+		}
+	})
+
+	t.Run("plain errors have no causes", func(t *testing.T) {
+		lf, out := newReportFormatter()
+
+		lf.ReportError(errors.New("locker: lock lost"))
+		processErrors(lf)
+		if l := jsonLines(t, out)[0]; l["causes"] != nil {
+			t.Errorf("expected no causes, got %v", l["causes"])
+		}
+	})
+
+	t.Run("channel shim", func(t *testing.T) {
+		lf, out := newReportFormatter()
+
+		lf.errChan <- errorString("jobqueue: failed to claim job")
+		lf.errChan <- nil
+		if err := lf.Close(); err != nil {
+			t.Fatalf("unexpected error closing: %v", err)
+		}
+
+		lines := jsonLines(t, out)
+		if len(lines) != 1 || lines[0]["component"] != "jobqueue" || lines[0]["source"] != nil {
+			t.Fatalf("unexpected lines %v", lines)
+		}
+	})
+}
+
+func TestReportQueue(t *testing.T) {
+	t.Run("context fields", func(t *testing.T) {
+		out := &lockedBuffer{}
+		lf, _ := NewLogFormatter(out, nil, false, 0)
+
+		ctx := interfaces.ContextWithLogFields(context.Background(), interfaces.LogKeyRequestID, "req-7")
+		lf.ReportErrorContext(ctx, errorString("cache: cannot reach redis"))
+		lf.ReportContext(ctx, slog.LevelWarn, errorString("locker: lease expiring"))
+		processErrors(lf)
+
+		lines := jsonLines(t, out)
+		if len(lines) != 2 || lines[0]["request_id"] != "req-7" || lines[1]["request_id"] != "req-7" || lines[1]["level"] != "WARN" {
+			t.Fatalf("expected context fields in both reports, got %q", out.String())
+		}
+	})
+
+	t.Run("overflow policy", func(t *testing.T) {
+		out := &lockedBuffer{}
+		lf, _ := NewLogFormatter(out, nil, false, 1, WithOverflowDropNewest())
+
+		lf.ReportError(errorString("cache: first"))
+		lf.ReportError(errorString("cache: second"))
+		if stats := lf.Stats(); stats.Dropped != 1 {
+			t.Fatalf("expected 1 dropped report, got %+v", stats)
+		}
+		processErrors(lf)
+		if lines := jsonLines(t, out); len(lines) != 1 || lines[0]["msg"] != "first" {
+			t.Fatalf("expected only the first report, got %q", out.String())
+		}
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		lf, out := newReportFormatter(WithSampling(SamplingRule{MaxLevel: slog.LevelError, First: 1, Interval: time.Minute}))
+
+		for range 3 {
+			lf.ReportError(errorString("jobqueue: failed to claim job"))
+		}
+		processErrors(lf)
+		if lines := jsonLines(t, out); len(lines) != 1 {
+			t.Fatalf("expected reports sampled, got %q", out.String())
+		}
+	})
+}
+
+func TestReportDedup(t *testing.T) {
+	lf, out := newReportFormatter(WithErrorDedup(time.Minute))
+
+	for range 3 {
+		lf.ReportError(errorString("cache: cannot reach redis"))
+	}
+	lf.ReportError(errorString("cache: cannot reach memcached"))
+	processErrors(lf)
+
+	lines := jsonLines(t, out)
+	if len(lines) != 2 || lines[0]["repeated"] != nil {
+		t.Fatalf("expected each distinct error once, got %q", out.String())
+	}
+
+	lf.flushRepeats(time.Now(), false)
+	if got := len(jsonLines(t, out)); got != 2 {
+		t.Fatalf("expected no summary before the window ends, got %d lines", got)
+	}
+
+	lf.flushRepeats(time.Now().Add(time.Minute), false)
+	lines = jsonLines(t, out)
+	if len(lines) != 3 {
+		t.Fatalf("expected one summary, got %q", out.String())
+	}
+	if l := lines[2]; l["msg"] != "cannot reach redis" || l["repeated"] != float64(2) {
+		t.Errorf("unexpected summary %v", l)
+	}
+
+	// con la ventana vencida el mismo error se registra de nuevo
+	for range 2 {
+		lf.ReportError(errorString("cache: cannot reach redis"))
+	}
+	if err := lf.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+	lines = jsonLines(t, out)
+	if len(lines) != 5 || lines[3]["repeated"] != nil || lines[4]["repeated"] != float64(1) {
+		t.Fatalf("expected the error again and a summary on close, got %q", out.String())
+	}
+}
@@ -0,0 +1,445 @@
+package logformatter
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupLayout es el sufijo de tiempo de los respaldos: app.log.20261019T150405.000
+const backupLayout = "20060102T150405.000"
+
+type rotateOpts struct {
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+	signals    []os.Signal
+	errors     io.Writer
+	now        func() time.Time
+}
+
+type RotateOption func(options *rotateOpts)
+
+// WithMaxSize rota el archivo antes de que un Write lo haga pasar de n bytes.
+// Panics si n no es positivo.
+func WithMaxSize(n int64) RotateOption {
+	return func(options *rotateOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: max size must be positive", SigLogFormatter))
+		}
+		options.maxSize = n
+	}
+}
+
+// WithMaxAge rota el archivo cuando lleva d abierto. Panics si d no es positivo.
+func WithMaxAge(d time.Duration) RotateOption {
+	return func(options *rotateOpts) {
+		if d <= 0 {
+			panic(fmt.Sprintf("%s: max age must be positive", SigLogFormatter))
+		}
+		options.maxAge = d
+	}
+}
+
+// WithMaxBackups establece cuántos respaldos se conservan; los más antiguos se
+// borran. Sin esta opción se conservan todos. Panics si n no es positivo.
+func WithMaxBackups(n int) RotateOption {
+	return func(options *rotateOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: max backups must be positive", SigLogFormatter))
+		}
+		options.maxBackups = n
+	}
+}
+
+// WithCompress comprime con gzip los respaldos después de rotar.
+func WithCompress() RotateOption {
+	return func(options *rotateOpts) {
+		options.compress = true
+	}
+}
+
+// WithReopenSignals hace que el archivo se vuelva a abrir al recibir signals,
+// como lo espera logrotate. Sin señales usa SIGHUP. Sin esta opción no se
+// escucha ninguna, para no quitarle la señal al resto del proceso.
+func WithReopenSignals(signals ...os.Signal) RotateOption {
+	return func(options *rotateOpts) {
+		if len(signals) == 0 {
+			signals = []os.Signal{syscall.SIGHUP}
+		}
+		options.signals = signals
+	}
+}
+
+// WithRotateErrors escribe en w, una línea por error, los errores de la
+// compresión y limpieza de respaldos, que corren después de rotar y no tienen
+// a quién retornarlos. Puede ser el mismo LogFormatter. Sin esta opción se
+// descartan. Panics si w es nil.
+func WithRotateErrors(w io.Writer) RotateOption {
+	return func(options *rotateOpts) {
+		if w == nil {
+			panic(fmt.Sprintf("%s: rotate errors writer cannot be nil", SigLogFormatter))
+		}
+		options.errors = w
+	}
+}
+
+// WithRotateClock reemplaza el reloj usado para rotar por tiempo y nombrar los
+// respaldos. Útil en tests. Panics si now es nil.
+func WithRotateClock(now func() time.Time) RotateOption {
+	return func(options *rotateOpts) {
+		if now == nil {
+			panic(fmt.Sprintf("%s: clock cannot be nil", SigLogFormatter))
+		}
+		options.now = now
+	}
+}
+
+// RotatingFile es un io.Writer sobre un archivo que rota por tamaño o por tiempo.
+// Al rotar, el archivo actual se renombra con la hora como sufijo y se abre uno
+// nuevo. Se puede usar como stderr de NewLogFormatter o con WithSink, y es
+// seguro para escrituras concurrentes. El llamador debe cerrarlo.
+type RotatingFile struct {
+	path    string
+	options rotateOpts
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+
+	millMu  sync.Mutex
+	millers sync.WaitGroup
+
+	sigs chan os.Signal
+	done chan struct{}
+}
+
+// NewRotatingFile abre path, o lo crea junto a su directorio, para agregar al
+// final.
+func NewRotatingFile(path string, opts ...RotateOption) (*RotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%s: log file path cannot be empty", SigLogFormatter)
+	}
+
+	rf := &RotatingFile{
+		path: path,
+		options: rotateOpts{
+			errors: io.Discard,
+			now:    time.Now,
+		},
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&rf.options)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("%s: failed to create log dir: %w", SigLogFormatter, err)
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	if len(rf.options.signals) > 0 {
+		rf.sigs = make(chan os.Signal, 1)
+		signal.Notify(rf.sigs, rf.options.signals...)
+		go rf.watchSignals()
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed() {
+		return 0, fmt.Errorf("%s: log file is closed", SigLogFormatter)
+	}
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+	if rf.shouldRotate(len(p)) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("%s: failed to write log file: %w", SigLogFormatter, err)
+	}
+	return n, nil
+}
+
+// Rotate rota el archivo ahora, sin importar su tamaño ni su edad.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed() {
+		return fmt.Errorf("%s: log file is closed", SigLogFormatter)
+	}
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return err
+		}
+	}
+	return rf.rotate()
+}
+
+// Reopen cierra el archivo; el próximo Write lo vuelve a abrir en path. Sirve
+// cuando otro proceso, como logrotate, movió el archivo.
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.closeFile()
+}
+
+// Close deja de escuchar las señales, cierra el archivo y espera a que termine
+// la compresión y limpieza de respaldos.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	if rf.closed() {
+		rf.mu.Unlock()
+		return nil
+	}
+	close(rf.done)
+	if rf.sigs != nil {
+		signal.Stop(rf.sigs)
+	}
+	err := rf.closeFile()
+	rf.mu.Unlock()
+
+	rf.millers.Wait()
+	return err
+}
+
+func (rf *RotatingFile) closed() bool {
+	select {
+	case <-rf.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (rf *RotatingFile) watchSignals() {
+	for {
+		select {
+		case <-rf.sigs:
+			rf.Reopen()
+		case <-rf.done:
+			return
+		}
+	}
+}
+
+func (rf *RotatingFile) shouldRotate(n int) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.options.maxSize > 0 && rf.size+int64(n) > rf.options.maxSize {
+		return true
+	}
+	return rf.options.maxAge > 0 && rf.options.now().Sub(rf.opened) >= rf.options.maxAge
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("%s: failed to open log file: %w", SigLogFormatter, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("%s: failed to stat log file: %w", SigLogFormatter, err)
+	}
+	rf.f, rf.size, rf.opened = f, info.Size(), rf.options.now()
+	return nil
+}
+
+func (rf *RotatingFile) closeFile() error {
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	if err != nil {
+		return fmt.Errorf("%s: failed to close log file: %w", SigLogFormatter, err)
+	}
+	return nil
+}
+
+// rotate renombra el archivo actual y abre uno nuevo. La compresión y la
+// limpieza de respaldos corren aparte para no frenar los Write.
+func (rf *RotatingFile) rotate() error {
+	if err := rf.closeFile(); err != nil {
+		return err
+	}
+
+	backup := rf.backupName()
+	if err := os.Rename(rf.path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: failed to rotate log file: %w", SigLogFormatter, err)
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	rf.millers.Add(1)
+	go func() {
+		defer rf.millers.Done()
+		rf.mill(backup)
+	}()
+	return nil
+}
+
+// backupName retorna un nombre de respaldo que no exista todavía.
+func (rf *RotatingFile) backupName() string {
+	name := rf.path + "." + rf.options.now().Format(backupLayout)
+	candidate := name
+	for i := 1; ; i++ {
+		_, errPlain := os.Stat(candidate)
+		_, errGzip := os.Stat(candidate + ".gz")
+		if errors.Is(errPlain, os.ErrNotExist) && errors.Is(errGzip, os.ErrNotExist) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// mill comprime backup si corresponde y borra los respaldos que sobran.
+func (rf *RotatingFile) mill(backup string) {
+	rf.millMu.Lock()
+	defer rf.millMu.Unlock()
+
+	// si el respaldo no existe es que una limpieza anterior ya lo borró
+	if rf.options.compress {
+		if err := compressFile(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(rf.options.errors, "%s\n", err)
+		}
+	}
+	if rf.options.maxBackups > 0 {
+		if err := rf.prune(); err != nil {
+			fmt.Fprintf(rf.options.errors, "%s\n", err)
+		}
+	}
+}
+
+// Backups retorna los respaldos existentes, del más nuevo al más antiguo.
+func (rf *RotatingFile) Backups() ([]string, error) {
+	dir, base := filepath.Split(rf.path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list log dir: %w", SigLogFormatter, err)
+	}
+
+	type backup struct {
+		path  string
+		stamp time.Time
+		seq   int
+	}
+	var found []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		rest, ok := strings.CutPrefix(name, base+".")
+		if !ok || entry.IsDir() || strings.HasSuffix(rest, ".tmp") {
+			continue
+		}
+		rest = strings.TrimSuffix(rest, ".gz")
+
+		b := backup{path: filepath.Join(dir, name)}
+		if stamp, seq, ok := strings.Cut(rest, "-"); ok {
+			if b.seq, err = strconv.Atoi(seq); err != nil {
+				continue
+			}
+			rest = stamp
+		}
+		if b.stamp, err = time.Parse(backupLayout, rest); err != nil {
+			continue
+		}
+		found = append(found, b)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].stamp.Equal(found[j].stamp) {
+			return found[i].stamp.After(found[j].stamp)
+		}
+		return found[i].seq > found[j].seq
+	})
+	backups := make([]string, len(found))
+	for i, b := range found {
+		backups[i] = b.path
+	}
+	return backups, nil
+}
+
+func (rf *RotatingFile) prune() error {
+	backups, err := rf.Backups()
+	if err != nil {
+		return err
+	}
+	if len(backups) <= rf.options.maxBackups {
+		return nil
+	}
+
+	var errs []error
+	for _, old := range backups[rf.options.maxBackups:] {
+		if err := os.Remove(old); err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to remove old log file: %w", SigLogFormatter, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compressFile reemplaza path por path.gz. Escribe primero en un .tmp para no
+// dejar un .gz a medias si algo falla.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s: failed to open log file to compress: %w", SigLogFormatter, err)
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("%s: failed to create compressed log file: %w", SigLogFormatter, err)
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: failed to compress log file: %w", SigLogFormatter, err)
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: failed to compress log file: %w", SigLogFormatter, err)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,229 @@
+package logformatter
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeClock es un reloj que solo avanza con advance.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error opening %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("unexpected error reading %s: %v", path, err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error reading %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	var clock *fakeClock
+	newFile := func(t *testing.T, opts ...RotateOption) (*RotatingFile, string) {
+		t.Helper()
+		clock = &fakeClock{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+		path := filepath.Join(t.TempDir(), "logs", "app.log")
+		opts = append([]RotateOption{WithRotateClock(clock.Now)}, opts...)
+		rf, err := NewRotatingFile(path, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error opening: %v", err)
+		}
+		t.Cleanup(func() { rf.Close() })
+		return rf, path
+	}
+
+	t.Run("rotates by size", func(t *testing.T) {
+		rf, path := newFile(t, WithMaxSize(10))
+
+		for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+			if _, err := rf.Write([]byte(line)); err != nil {
+				t.Fatalf("unexpected error writing: %v", err)
+			}
+			clock.advance(time.Millisecond)
+		}
+		if err := rf.Close(); err != nil {
+			t.Fatalf("unexpected error closing: %v", err)
+		}
+
+		backups, _ := rf.Backups()
+		if len(backups) != 1 || readFile(t, backups[0]) != "aaaa\nbbbb\n" {
+			t.Fatalf("expected one backup with the first lines, got %q", backups)
+		}
+		if got := readFile(t, path); got != "cccc\n" {
+			t.Fatalf("expected current file with the last line, got %q", got)
+		}
+	})
+
+	t.Run("rotates by age", func(t *testing.T) {
+		rf, path := newFile(t, WithMaxAge(time.Hour))
+
+		rf.Write([]byte("old\n"))
+		clock.advance(30 * time.Minute)
+		rf.Write([]byte("still old\n"))
+		clock.advance(30 * time.Minute)
+		rf.Write([]byte("new\n"))
+		rf.Close()
+
+		backups, _ := rf.Backups()
+		if len(backups) != 1 || readFile(t, backups[0]) != "old\nstill old\n" {
+			t.Fatalf("expected one backup, got %q", backups)
+		}
+		if !strings.HasSuffix(backups[0], ".20260102T040405.000") {
+			t.Errorf("expected backup named after the clock, got %s", backups[0])
+		}
+		if got := readFile(t, path); got != "new\n" {
+			t.Fatalf("expected new file, got %q", got)
+		}
+	})
+
+	t.Run("keeps n compressed backups", func(t *testing.T) {
+		rf, _ := newFile(t, WithMaxBackups(2), WithCompress())
+
+		for i := range 4 {
+			fmt.Fprintf(rf, "file %d\n", i)
+			if err := rf.Rotate(); err != nil {
+				t.Fatalf("unexpected error rotating: %v", err)
+			}
+		}
+		rf.Close()
+
+		backups, _ := rf.Backups()
+		if len(backups) != 2 {
+			t.Fatalf("expected 2 backups, got %q", backups)
+		}
+		// el reloj no avanzó: los respaldos se distinguen por el sufijo
+		for i, want := range []string{"file 3\n", "file 2\n"} {
+			if !strings.HasSuffix(backups[i], ".gz") || readFile(t, backups[i]) != want {
+				t.Errorf("unexpected backup %s", backups[i])
+			}
+		}
+	})
+
+	t.Run("background errors go to the errors writer", func(t *testing.T) {
+		errs := &lockedBuffer{}
+		rf, path := newFile(t, WithCompress(), WithRotateErrors(errs))
+
+		// un directorio donde va el .gz.tmp hace fallar la compresión
+		backup := path + "." + clock.Now().Format(backupLayout)
+		if err := os.MkdirAll(backup+".gz.tmp", 0o755); err != nil {
+			t.Fatalf("unexpected error creating dir: %v", err)
+		}
+		rf.Write([]byte("line\n"))
+		if err := rf.Rotate(); err != nil {
+			t.Fatalf("unexpected error rotating: %v", err)
+		}
+		rf.Close()
+
+		if got := errs.String(); !strings.HasPrefix(got, SigLogFormatter+": failed to create compressed log file") {
+			t.Fatalf("expected compression error in the writer, got %q", got)
+		}
+	})
+
+	t.Run("listens to no signals by default", func(t *testing.T) {
+		rf, _ := newFile(t)
+		if rf.sigs != nil {
+			t.Fatal("expected no reopen signals without WithReopenSignals")
+		}
+	})
+
+	t.Run("reopens after logrotate", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		rf, err := NewRotatingFile(path, WithReopenSignals(syscall.SIGUSR1))
+		if err != nil {
+			t.Fatalf("unexpected error opening: %v", err)
+		}
+		defer rf.Close()
+
+		rf.Write([]byte("before\n"))
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatalf("unexpected error moving: %v", err)
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			rf.Write([]byte("after\n"))
+			if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "after") {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("file was never reopened")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := readFile(t, path+".1"); !strings.HasPrefix(got, "before\n") {
+			t.Errorf("expected moved file to keep old lines, got %q", got)
+		}
+	})
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		rf, path := newFile(t, WithMaxSize(100))
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 20 {
+					fmt.Fprintf(rf, "writer %d line %02d\n", i, j)
+				}
+			}()
+		}
+		wg.Wait()
+		rf.Close()
+
+		backups, _ := rf.Backups()
+		lines := strings.Count(readFile(t, path), "\n")
+		for _, b := range backups {
+			lines += strings.Count(readFile(t, b), "\n")
+		}
+		if lines != 200 {
+			t.Fatalf("expected 200 lines across files, got %d", lines)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		rf, _ := newFile(t)
+		rf.Close()
+		if _, err := rf.Write([]byte("late\n")); err == nil {
+			t.Fatal("expected error writing after close")
+		}
+	})
+}
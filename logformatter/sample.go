@@ -0,0 +1,154 @@
+package logformatter
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// digitsRX agrupa los mensajes que solo cambian en sus números, como
+// "retrying in 3s" y "retrying in 5s", bajo la misma llave de muestreo.
+var digitsRX = regexp.MustCompile(`[0-9]+`)
+
+// SamplingRule limita cuántas veces se registra un mismo mensaje por intervalo:
+// los primeros First, y después uno de cada Thereafter. Al terminar el
+// intervalo se registra el mensaje una vez más con el atributo suppressed, la
+// cantidad que se omitió.
+type SamplingRule struct {
+	// Component limita la regla a un componente. Vacío aplica a todos, pero una
+	// regla con componente gana sobre una sin él.
+	Component string
+	// MaxLevel es el nivel más alto que se muestrea. El valor cero es Info, así
+	// que warnings y errores se registran siempre salvo que se pida lo contrario.
+	MaxLevel slog.Level
+	// First es cuántos mensajes se registran al inicio de cada intervalo.
+	First int
+	// Thereafter registra uno de cada Thereafter después de los primeros. Cero
+	// omite todos los siguientes.
+	Thereafter int
+	// Interval es cada cuánto se reinicia la cuenta de un mensaje.
+	Interval time.Duration
+}
+
+// WithSampling agrega reglas de muestreo para las líneas escritas al formatter.
+// Dos líneas son el mismo mensaje si tienen el mismo componente, nivel y texto,
+// sin contar sus números. Panics si una regla tiene First o Thereafter negativo,
+// ambos en cero, o un Interval que no es positivo.
+func WithSampling(rules ...SamplingRule) FormatterOption {
+	return func(options *formatterOpts) {
+		for _, rule := range rules {
+			if rule.First < 0 || rule.Thereafter < 0 {
+				panic(fmt.Sprintf("%s: sampling counts cannot be negative", SigLogFormatter))
+			}
+			if rule.First == 0 && rule.Thereafter == 0 {
+				panic(fmt.Sprintf("%s: sampling rule would drop every message", SigLogFormatter))
+			}
+			if rule.Interval <= 0 {
+				panic(fmt.Sprintf("%s: sampling interval must be positive", SigLogFormatter))
+			}
+		}
+		options.sampling = append(options.sampling, rules...)
+	}
+}
+
+// sampleState cuenta un mensaje dentro del intervalo actual.
+type sampleState struct {
+	entry      entry
+	rule       SamplingRule
+	start      time.Time
+	seen       int
+	suppressed int
+}
+
+// sample retorna false si e debe omitirse según su regla de muestreo.
+func (lf *LogFormatter) sample(e entry, now time.Time) bool {
+	rule, ok := lf.samplingRule(e)
+	if !ok {
+		return true
+	}
+	key := fmt.Sprintf("%d|%s|%s", e.level, e.component, digitsRX.ReplaceAllString(e.msg, "#"))
+
+	lf.sampleMu.Lock()
+	defer lf.sampleMu.Unlock()
+
+	s, ok := lf.samples[key]
+	if !ok || now.Sub(s.start) >= rule.Interval {
+		if ok {
+			lf.logSuppressed(s)
+		}
+		s = &sampleState{entry: e, rule: rule, start: now}
+		lf.samples[key] = s
+	}
+
+	s.seen++
+	if s.seen <= rule.First {
+		return true
+	}
+	if rule.Thereafter > 0 && (s.seen-rule.First)%rule.Thereafter == 0 {
+		return true
+	}
+	s.suppressed++
+	return false
+}
+
+// samplingRule retorna la regla que aplica a e, prefiriendo las de su componente.
+func (lf *LogFormatter) samplingRule(e entry) (SamplingRule, bool) {
+	var fallback *SamplingRule
+	for i, rule := range lf.options.sampling {
+		if e.level > rule.MaxLevel {
+			continue
+		}
+		if rule.Component == e.component && e.component != "" {
+			return rule, true
+		}
+		if rule.Component == "" && fallback == nil {
+			fallback = &lf.options.sampling[i]
+		}
+	}
+	if fallback == nil {
+		return SamplingRule{}, false
+	}
+	return *fallback, true
+}
+
+// flushSamples registra el resumen de los mensajes cuyo intervalo venció en now.
+// Si force es true los registra todos, sin importar el intervalo.
+func (lf *LogFormatter) flushSamples(now time.Time, force bool) {
+	lf.sampleMu.Lock()
+	defer lf.sampleMu.Unlock()
+
+	var keys []string
+	for key, s := range lf.samples {
+		if force || now.Sub(s.start) >= s.rule.Interval {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lf.samples[keys[i]].start.Before(lf.samples[keys[j]].start)
+	})
+	for _, key := range keys {
+		lf.logSuppressed(lf.samples[key])
+		delete(lf.samples, key)
+	}
+}
+
+func (lf *LogFormatter) logSuppressed(s *sampleState) {
+	if s.suppressed == 0 {
+		return
+	}
+	lf.log(s.entry, "suppressed", s.suppressed)
+}
+
+// sampleInterval es cada cuánto Start revisa los intervalos vencidos: el menor
+// de las reglas, o cero si no hay.
+func (lf *LogFormatter) sampleInterval() time.Duration {
+	var d time.Duration
+	for _, rule := range lf.options.sampling {
+		if d == 0 || rule.Interval < d {
+			d = rule.Interval
+		}
+	}
+	return d
+}
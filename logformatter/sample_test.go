@@ -0,0 +1,106 @@
+package logformatter
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	t.Run("first n then one in m", func(t *testing.T) {
+		lf, out := newReportFormatter(WithSampling(SamplingRule{First: 2, Thereafter: 3, Interval: time.Minute}))
+
+		for range 10 {
+			lf.handleMsg([]byte("sqlhandler connector: checking if db is still conected"))
+		}
+		// 1 y 2 por First, luego 5 y 8 por Thereafter
+		if got := len(jsonLines(t, out)); got != 4 {
+			t.Fatalf("expected 4 sampled lines, got %d: %q", got, out.String())
+		}
+
+		lf.flushSamples(time.Now(), false)
+		if got := len(jsonLines(t, out)); got != 4 {
+			t.Fatalf("expected no summary before the interval ends, got %d lines", got)
+		}
+
+		lf.flushSamples(time.Now().Add(time.Minute), false)
+		lines := jsonLines(t, out)
+		if len(lines) != 5 {
+			t.Fatalf("expected a summary line, got %q", out.String())
+		}
+		if l := lines[4]; l["component"] != "sqlhandler connector" || l["suppressed"] != float64(6) {
+			t.Errorf("unexpected summary %v", l)
+		}
+
+		// el intervalo vencido reinicia la cuenta
+		lf.handleMsg([]byte("sqlhandler connector: checking if db is still conected"))
+		if got := len(jsonLines(t, out)); got != 6 {
+			t.Fatalf("expected the message again after the interval, got %d lines", got)
+		}
+	})
+
+	t.Run("messages differing in numbers share a key", func(t *testing.T) {
+		lf, out := newReportFormatter(WithSampling(SamplingRule{First: 1, Interval: time.Minute}))
+
+		for i := range 5 {
+			lf.handleMsg([]byte(fmt.Sprintf("jobqueue: polled %d jobs", i)))
+		}
+		lf.handleMsg([]byte("jobqueue: worker idle"))
+		if err := lf.Close(); err != nil {
+			t.Fatalf("unexpected error closing: %v", err)
+		}
+
+		lines := jsonLines(t, out)
+		if len(lines) != 3 || lines[0]["msg"] != "polled 0 jobs" || lines[1]["msg"] != "worker idle" {
+			t.Fatalf("unexpected lines %q", out.String())
+		}
+		if lines[2]["msg"] != "polled 0 jobs" || lines[2]["suppressed"] != float64(4) {
+			t.Errorf("expected summary on close, got %v", lines[2])
+		}
+	})
+
+	t.Run("rules per component and level", func(t *testing.T) {
+		lf, out := newReportFormatter(WithSampling(
+			SamplingRule{First: 1, Interval: time.Minute},
+			SamplingRule{Component: "cache", MaxLevel: slog.LevelWarn, First: 2, Interval: time.Minute},
+		))
+
+		for range 4 {
+			lf.handleMsg([]byte("sqlhandler outbox: relay tick"))
+			lf.handleMsg([]byte("sqlhandler outbox: relay failed"))
+			lf.handleMsg([]byte("cache: retrying connection"))
+		}
+
+		counts := map[string]int{}
+		for _, l := range jsonLines(t, out) {
+			counts[l["msg"].(string)]++
+		}
+		if counts["relay tick"] != 1 {
+			t.Errorf("expected info line sampled by the default rule, got %d", counts["relay tick"])
+		}
+		if counts["relay failed"] != 4 {
+			t.Errorf("expected errors never sampled, got %d", counts["relay failed"])
+		}
+		if counts["retrying connection"] != 2 {
+			t.Errorf("expected warn line sampled by the cache rule, got %d", counts["retrying connection"])
+		}
+	})
+
+	t.Run("invalid rules panic", func(t *testing.T) {
+		for _, rule := range []SamplingRule{
+			{First: -1, Interval: time.Second},
+			{Interval: time.Second},
+			{First: 1},
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("expected panic for %+v", rule)
+					}
+				}()
+				WithSampling(rule)(&formatterOpts{})
+			}()
+		}
+	})
+}
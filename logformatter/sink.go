@@ -0,0 +1,398 @@
+package logformatter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSinkBuffer  = 1024
+	defaultDialTimeout = 5 * time.Second
+)
+
+// Format es el formato en que un sink escribe cada registro.
+type Format int
+
+const (
+	// FormatJSON escribe un objeto JSON por línea. Es el formato por defecto.
+	FormatJSON Format = iota
+	// FormatText escribe clave=valor, más fácil de leer en una terminal.
+	FormatText
+)
+
+type sinkOpts struct {
+	level  slog.Level
+	format Format
+	buffer int
+}
+
+type SinkOption func(options *sinkOpts)
+
+// WithSinkLevel establece el nivel mínimo que registra el sink. Por defecto Info.
+func WithSinkLevel(level slog.Level) SinkOption {
+	return func(options *sinkOpts) {
+		options.level = level
+	}
+}
+
+// WithSinkFormat establece el formato del sink. Panics si f no es un Format válido.
+func WithSinkFormat(f Format) SinkOption {
+	return func(options *sinkOpts) {
+		if f != FormatJSON && f != FormatText {
+			panic(fmt.Sprintf("%s: unknown sink format %d", SigLogFormatter, f))
+		}
+		options.format = f
+	}
+}
+
+// WithSinkBuffer establece cuántos registros espera el sink antes de descartar.
+// Panics si n no es positivo.
+func WithSinkBuffer(n int) SinkOption {
+	return func(options *sinkOpts) {
+		if n <= 0 {
+			panic(fmt.Sprintf("%s: sink buffer must be positive", SigLogFormatter))
+		}
+		options.buffer = n
+	}
+}
+
+// SinkStats cuenta los registros que un sink no escribió.
+type SinkStats struct {
+	// Dropped son los registros descartados porque el sink estaba lleno o ya se
+	// había cerrado.
+	Dropped uint64
+	// Failed son los registros cuya escritura retornó error.
+	Failed uint64
+}
+
+// WithSink agrega un destino para los registros. Mientras Start corre, cada sink
+// escribe en su propio goroutine con un buffer propio, así un sink lento descarta
+// sus registros en vez de frenar a los demás; antes de Start escribe directo y
+// después de cerrarse descarta. Con al menos un sink, NewLogFormatter no acepta
+// logger y textFormat no se usa. w no se cierra: sigue siendo del llamador.
+// Panics si name está vacío o repetido, o si w es nil.
+func WithSink(name string, w io.Writer, opts ...SinkOption) FormatterOption {
+	return func(options *formatterOpts) {
+		if w == nil {
+			panic(fmt.Sprintf("%s: sink writer cannot be nil", SigLogFormatter))
+		}
+		addSink(options, name, w, false, opts)
+	}
+}
+
+// WithNetworkSink agrega un sink que envía cada registro a addr por network,
+// "tcp" o "udp", por ejemplo a un colector de logs. La conexión se abre al
+// primer registro y se vuelve a abrir después de un error.
+// Panics si name está vacío o repetido, network no es tcp o udp, o addr está vacío.
+func WithNetworkSink(name, network, addr string, opts ...SinkOption) FormatterOption {
+	return func(options *formatterOpts) {
+		switch network {
+		case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		default:
+			panic(fmt.Sprintf("%s: unsupported sink network %q", SigLogFormatter, network))
+		}
+		if addr == "" {
+			panic(fmt.Sprintf("%s: sink address cannot be empty", SigLogFormatter))
+		}
+		w := &netWriter{network: network, addr: addr, timeout: defaultDialTimeout}
+		addSink(options, name, w, true, opts)
+	}
+}
+
+func addSink(options *formatterOpts, name string, w io.Writer, owned bool, opts []SinkOption) {
+	if name == "" {
+		panic(fmt.Sprintf("%s: sink name cannot be empty", SigLogFormatter))
+	}
+	for _, s := range options.sinks {
+		if s.name == name {
+			panic(fmt.Sprintf("%s: sink %q already exists", SigLogFormatter, name))
+		}
+	}
+
+	sopts := sinkOpts{level: slog.LevelInfo, format: FormatJSON, buffer: defaultSinkBuffer}
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+	options.sinks = append(options.sinks, sinkConfig{name: name, w: w, owned: owned, opts: sopts})
+}
+
+// sinkConfig es un sink antes de crearse. owned indica que el sink creó w y
+// debe cerrarlo.
+type sinkConfig struct {
+	name  string
+	w     io.Writer
+	owned bool
+	opts  sinkOpts
+}
+
+type sinkItem struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+// sink escribe en w desde su propio goroutine entre start y close. Antes de start
+// escribe directo, para no dejar un goroutine vivo si nadie llama a Start; después
+// de close descarta, ya que un w propio está cerrado y escribirle lo reabriría.
+type sink struct {
+	name  string
+	w     io.Writer
+	owned bool
+
+	mu      sync.RWMutex
+	running bool
+	closed  bool
+	queue   chan sinkItem
+	done    chan struct{}
+
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+	reported SinkStats
+}
+
+func newSink(cfg sinkConfig) *sink {
+	s := &sink{
+		name:  cfg.name,
+		w:     cfg.w,
+		owned: cfg.owned,
+		queue: make(chan sinkItem, cfg.opts.buffer),
+		done:  make(chan struct{}),
+	}
+	return s
+}
+
+// start lanza el goroutine del sink, si no se ha cerrado.
+func (s *sink) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running || s.closed {
+		return
+	}
+	s.running = true
+	go s.run()
+}
+
+// handler retorna el slog.Handler que formatea hacia el sink.
+func (s *sink) handler(opts sinkOpts) slog.Handler {
+	hopts := &slog.HandlerOptions{Level: opts.level}
+	if opts.format == FormatText {
+		return slog.NewTextHandler(s, hopts)
+	}
+	return slog.NewJSONHandler(s, hopts)
+}
+
+func (s *sink) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		s.dropped.Add(1)
+		return len(p), nil
+	}
+	if !s.running {
+		if _, err := s.w.Write(p); err != nil {
+			s.failed.Add(1)
+		}
+		return len(p), nil
+	}
+
+	// el handler reutiliza p, así que guardamos una copia
+	select {
+	case s.queue <- sinkItem{data: bytes.Clone(p)}:
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+func (s *sink) run() {
+	defer close(s.done)
+	for item := range s.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if _, err := s.w.Write(item.data); err != nil {
+			s.failed.Add(1)
+		}
+	}
+}
+
+// flush espera a que se escriba lo que está en el buffer.
+func (s *sink) flush(ctx context.Context) error {
+	s.mu.RLock()
+	if !s.running {
+		s.mu.RUnlock()
+		return nil
+	}
+	item := sinkItem{flushed: make(chan struct{})}
+	select {
+	case s.queue <- item:
+		s.mu.RUnlock()
+	case <-ctx.Done():
+		s.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-item.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close escribe lo pendiente y cierra w si el sink lo creó.
+func (s *sink) close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	running := s.running
+	s.closed, s.running = true, false
+	if running {
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	if running {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			return fmt.Errorf("%s: sink %q: %w", SigLogFormatter, s.name, ctx.Err())
+		}
+	}
+	if c, ok := s.w.(io.Closer); ok && s.owned {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("%s: sink %q: %w", SigLogFormatter, s.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *sink) stats() SinkStats {
+	return SinkStats{Dropped: s.dropped.Load(), Failed: s.failed.Load()}
+}
+
+// SinkStats retorna los contadores de cada sink por nombre.
+func (lf *LogFormatter) SinkStats() map[string]SinkStats {
+	stats := make(map[string]SinkStats, len(lf.sinks))
+	for _, s := range lf.sinks {
+		stats[s.name] = s.stats()
+	}
+	return stats
+}
+
+func (lf *LogFormatter) flushSinks(ctx context.Context) error {
+	var errs []error
+	for _, s := range lf.sinks {
+		if err := s.flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (lf *LogFormatter) closeSinks(ctx context.Context) error {
+	var errs []error
+	for _, s := range lf.sinks {
+		if err := s.close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fanout entrega cada registro a todos los handlers que lo aceptan.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make(fanout, len(f))
+	for i, h := range f {
+		hs[i] = h.WithAttrs(attrs)
+	}
+	return hs
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	hs := make(fanout, len(f))
+	for i, h := range f {
+		hs[i] = h.WithGroup(name)
+	}
+	return hs
+}
+
+// netWriter envía cada Write a addr, reconectando después de un error.
+type netWriter struct {
+	network string
+	addr    string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.addr, w.timeout)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to dial sink %s: %w", SigLogFormatter, w.addr, err)
+		}
+		w.conn = conn
+	}
+
+	w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	n, err := w.conn.Write(p)
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return n, fmt.Errorf("%s: failed to write sink %s: %w", SigLogFormatter, w.addr, err)
+	}
+	return n, nil
+}
+
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
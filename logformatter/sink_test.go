@@ -0,0 +1,218 @@
+package logformatter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingWriter no escribe hasta que se cierre release.
+type blockingWriter struct {
+	release chan struct{}
+	out     lockedBuffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.out.Write(p)
+}
+
+func TestSinks(t *testing.T) {
+	t.Run("per sink level and format", func(t *testing.T) {
+		text, jsonOut := &lockedBuffer{}, &lockedBuffer{}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, nil, false, 0,
+			WithSink("console", text, WithSinkFormat(FormatText), WithSinkLevel(slog.LevelWarn)),
+			WithSink("file", jsonOut, WithSinkLevel(slog.LevelDebug)),
+		)
+
+		lf.Write([]byte("cache: debug dump of state\nsqlhandler connector: cannot create new connection\n"))
+		if err := lf.Close(); err != nil {
+			t.Fatalf("unexpected error closing: %v", err)
+		}
+
+		if got := strings.Count(text.String(), "\n"); got != 1 || !strings.Contains(text.String(), "level=ERROR") {
+			t.Errorf("expected only the error line as text, got %q", text.String())
+		}
+		lines := jsonLines(t, jsonOut)
+		if len(lines) != 2 || lines[0]["level"] != "DEBUG" || lines[1]["level"] != "ERROR" {
+			t.Errorf("expected both lines as JSON, got %q", jsonOut.String())
+		}
+	})
+
+	t.Run("sinks write directly until Start", func(t *testing.T) {
+		out := &lockedBuffer{}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, nil, false, 0, WithSink("file", out))
+
+		for _, s := range lf.sinks {
+			if s.running {
+				t.Fatalf("expected sink %q not running before Start", s.name)
+			}
+		}
+		lf.handleMsg([]byte("cache: warmed up"))
+		if lines := jsonLines(t, out); len(lines) != 1 || lines[0]["msg"] != "warmed up" {
+			t.Fatalf("expected the line written without Start, got %q", out.String())
+		}
+	})
+
+	t.Run("closed sinks drop writes", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer ln.Close()
+		accepted := make(chan net.Conn, 4)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				accepted <- conn
+			}
+		}()
+
+		lf, _ := NewLogFormatter(&lockedBuffer{}, nil, false, 0, WithNetworkSink("collector", "tcp", ln.Addr().String()))
+		lf.handleMsg([]byte("cache: warmed up"))
+		conn := <-accepted
+		defer conn.Close()
+
+		if err := lf.Close(); err != nil {
+			t.Fatalf("unexpected error closing: %v", err)
+		}
+		lf.handleMsg([]byte("cache: too late"))
+
+		select {
+		case late := <-accepted:
+			late.Close()
+			t.Fatal("expected no new connection after close")
+		case <-time.After(50 * time.Millisecond):
+		}
+		if stats := lf.SinkStats()["collector"]; stats.Dropped != 1 {
+			t.Fatalf("expected the late record dropped, got %+v", stats)
+		}
+	})
+
+	t.Run("slow sink does not block the others", func(t *testing.T) {
+		slow := &blockingWriter{release: make(chan struct{})}
+		fast := &lockedBuffer{}
+		lf, _ := NewLogFormatter(&lockedBuffer{}, nil, false, 0,
+			WithSink("slow", slow, WithSinkBuffer(1)),
+			WithSink("fast", fast),
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go lf.Start(ctx)
+		<-lf.Ready()
+
+		for range 10 {
+			lf.ReportError(errorString("outbox: relay failed"))
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for len(jsonLines(t, fast)) < 10 {
+			if time.Now().After(deadline) {
+				t.Fatalf("fast sink was blocked, got %q", fast.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		// uno en escritura y uno en el buffer, el resto se descarta
+		if stats := lf.SinkStats()["slow"]; stats.Dropped < 8 {
+			t.Errorf("expected slow sink to drop records, got %+v", stats)
+		}
+
+		close(slow.release)
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), time.Second)
+		defer stopCancel()
+		if err := lf.Stop(stopCtx); err != nil {
+			t.Fatalf("unexpected error stopping: %v", err)
+		}
+
+		var report map[string]any
+		for _, line := range jsonLines(t, fast) {
+			if line["msg"] == "log sink lost records" {
+				report = line
+			}
+		}
+		if report == nil || report["sink"] != "slow" || report["component"] != SigLogFormatter {
+			t.Errorf("expected a report for the slow sink, got %q", fast.String())
+		}
+	})
+
+	t.Run("network sinks", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error listening: %v", err)
+		}
+		defer ln.Close()
+		received := make(chan string, 1)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			received <- line
+		}()
+
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error listening: %v", err)
+		}
+		defer pc.Close()
+
+		lf, _ := NewLogFormatter(&lockedBuffer{}, nil, false, 0,
+			WithNetworkSink("collector", "tcp", ln.Addr().String()),
+			WithNetworkSink("syslog", "udp", pc.LocalAddr().String(), WithSinkFormat(FormatText)),
+		)
+		lf.ReportError(errorString("jobqueue: failed to claim job"))
+		if err := lf.Close(); err != nil {
+			t.Fatalf("unexpected error closing: %v", err)
+		}
+
+		select {
+		case line := <-received:
+			var rec map[string]any
+			if err := json.Unmarshal([]byte(line), &rec); err != nil || rec["component"] != "jobqueue" {
+				t.Errorf("unexpected tcp record %q", line)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("tcp sink never sent the record")
+		}
+
+		buf := make([]byte, 1024)
+		pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil || !strings.Contains(string(buf[:n]), "component=jobqueue") {
+			t.Errorf("unexpected udp record %q (%v)", buf[:n], err)
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		cases := map[string]func(){
+			"logger with sinks": func() {
+				NewLogFormatter(&lockedBuffer{}, slog.Default(), false, 0, WithSink("a", &lockedBuffer{}))
+			},
+			"duplicated name": func() {
+				NewLogFormatter(&lockedBuffer{}, nil, false, 0, WithSink("a", &lockedBuffer{}), WithSink("a", &lockedBuffer{}))
+			},
+			"bad network": func() { WithNetworkSink("a", "unix", "/tmp/sock")(&formatterOpts{}) },
+		}
+		for name, fn := range cases {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: expected panic", name)
+					}
+				}()
+				fn()
+			}()
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package logformatter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const spoolHeader = 4
+
+// spool es un buffer circular en disco para las líneas que no caben en el canal.
+// Cada registro se guarda como [largo uint32][bytes] y puede dar la vuelta al
+// final del archivo. head y tail son posiciones lógicas que solo crecen; la
+// posición en el archivo es el resto con size.
+type spool struct {
+	path string
+	size int64
+
+	mu   sync.Mutex
+	f    *os.File
+	head int64
+	tail int64
+}
+
+func newSpool(path string, size int64) *spool {
+	return &spool{path: path, size: size}
+}
+
+// open crea el archivo la primera vez que se necesita.
+func (s *spool) open() error {
+	if s.f != nil {
+		return nil
+	}
+	f, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: failed to open spill file: %w", SigLogFormatter, err)
+	}
+	s.f = f
+	return nil
+}
+
+func (s *spool) empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head == s.tail
+}
+
+// push agrega rec descartando los registros más antiguos si no hay espacio.
+// Retorna cuántos registros se descartaron.
+func (s *spool) push(rec []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	need := int64(spoolHeader + len(rec))
+	if need > s.size {
+		return 0, fmt.Errorf("%s: line of %d bytes does not fit in spill file", SigLogFormatter, len(rec))
+	}
+	if err := s.open(); err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+	for s.size-(s.tail-s.head) < need {
+		n, err := s.recordLen(s.head)
+		if err != nil {
+			s.head = s.tail
+			return dropped, err
+		}
+		s.head += spoolHeader + n
+		dropped++
+	}
+
+	var header [spoolHeader]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(rec)))
+	if err := s.writeAt(s.tail, header[:]); err != nil {
+		return dropped, err
+	}
+	if err := s.writeAt(s.tail+spoolHeader, rec); err != nil {
+		return dropped, err
+	}
+	s.tail += need
+	return dropped, nil
+}
+
+// pop retorna el registro más antiguo, o false si no hay. Si el registro no se
+// puede leer descarta todo lo pendiente, porque sin su largo no hay forma de
+// encontrar el siguiente, y retorna el error.
+func (s *spool) pop() ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.head == s.tail {
+		return nil, false, nil
+	}
+	n, err := s.recordLen(s.head)
+	if err != nil {
+		s.head = s.tail
+		return nil, false, err
+	}
+	rec := make([]byte, n)
+	if err := s.readAt(s.head+spoolHeader, rec); err != nil {
+		s.head = s.tail
+		return nil, false, err
+	}
+	s.head += spoolHeader + n
+	return rec, true, nil
+}
+
+// recordLen lee el largo del registro en pos y revisa que quepa entre pos y tail.
+func (s *spool) recordLen(pos int64) (int64, error) {
+	var header [spoolHeader]byte
+	if err := s.readAt(pos, header[:]); err != nil {
+		return 0, err
+	}
+	n := int64(binary.BigEndian.Uint32(header[:]))
+	if n > s.tail-pos-spoolHeader {
+		return 0, fmt.Errorf("%s: corrupt spill record of %d bytes", SigLogFormatter, n)
+	}
+	return n, nil
+}
+
+func (s *spool) writeAt(pos int64, p []byte) error {
+	off := pos % s.size
+	first := min(int64(len(p)), s.size-off)
+	if _, err := s.f.WriteAt(p[:first], off); err != nil {
+		return fmt.Errorf("%s: failed to write spill file: %w", SigLogFormatter, err)
+	}
+	if _, err := s.f.WriteAt(p[first:], 0); err != nil {
+		return fmt.Errorf("%s: failed to write spill file: %w", SigLogFormatter, err)
+	}
+	return nil
+}
+
+func (s *spool) readAt(pos int64, p []byte) error {
+	off := pos % s.size
+	first := min(int64(len(p)), s.size-off)
+	if _, err := s.f.ReadAt(p[:first], off); err != nil {
+		return fmt.Errorf("%s: failed to read spill file: %w", SigLogFormatter, err)
+	}
+	if _, err := s.f.ReadAt(p[first:], 0); err != nil {
+		return fmt.Errorf("%s: failed to read spill file: %w", SigLogFormatter, err)
+	}
+	return nil
+}
+
+func (s *spool) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	s.head, s.tail = 0, 0
+	return err
+}
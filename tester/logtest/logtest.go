@@ -0,0 +1,100 @@
+// Package logtest tiene un interfaces.Logger que guarda los registros en
+// memoria, para revisarlos en los tests de los paquetes que registran logs.
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/go-on-bike/bike/interfaces"
+)
+
+// Entry es un registro guardado por Logger.
+type Entry struct {
+	Level slog.Level
+	Msg   string
+	Args  []any
+}
+
+// Value retorna el valor de key en los pares clave-valor de Args, o nil.
+func (e Entry) Value(key string) any {
+	return ArgValue(e.Args, key)
+}
+
+// Logger guarda los registros recibidos. Los métodos *Context agregan los
+// campos del contexto, como lo haría logformatter.ContextHandler.
+type Logger struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (l *Logger) record(level slog.Level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, Entry{Level: level, Msg: msg, Args: args})
+}
+
+func (l *Logger) Debug(msg string, args ...any) { l.record(slog.LevelDebug, msg, args) }
+func (l *Logger) Info(msg string, args ...any)  { l.record(slog.LevelInfo, msg, args) }
+func (l *Logger) Warn(msg string, args ...any)  { l.record(slog.LevelWarn, msg, args) }
+func (l *Logger) Error(msg string, args ...any) { l.record(slog.LevelError, msg, args) }
+
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.record(slog.LevelDebug, msg, WithFields(ctx, args))
+}
+func (l *Logger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.record(slog.LevelInfo, msg, WithFields(ctx, args))
+}
+func (l *Logger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.record(slog.LevelWarn, msg, WithFields(ctx, args))
+}
+func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.record(slog.LevelError, msg, WithFields(ctx, args))
+}
+
+// Entries retorna una copia de los registros guardados, en orden.
+func (l *Logger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// Find retorna el primer registro con level y msg.
+func (l *Logger) Find(level slog.Level, msg string) (Entry, bool) {
+	for _, e := range l.Entries() {
+		if e.Level == level && e.Msg == msg {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// String retorna un registro por línea como "LEVEL msg [args]".
+func (l *Logger) String() string {
+	var lines []string
+	for _, e := range l.Entries() {
+		lines = append(lines, fmt.Sprintf("%s %s %v", e.Level, e.Msg, e.Args))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WithFields agrega a args los campos de log del contexto.
+func WithFields(ctx context.Context, args []any) []any {
+	for _, a := range interfaces.LogFieldsFromContext(ctx) {
+		args = append(args, a.Key, a.Value.Any())
+	}
+	return args
+}
+
+// ArgValue retorna el valor de key en los pares clave-valor de args, o nil.
+func ArgValue(args []any, key string) any {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == key {
+			return args[i+1]
+		}
+	}
+	return nil
+}